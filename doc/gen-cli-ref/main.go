@@ -70,6 +70,8 @@ var categoryOrder = []string{
 	"Masking",
 	"Display",
 	"Chroot",
+	"Merge",
+	"Apply",
 	"AI Summary",
 	"Configuration",
 	"Other",
@@ -0,0 +1,1204 @@
+// apply.go - Applying a patch to YAML source.
+//
+// ApplyPatch reads either an RFC 6902 JSON Patch (as JSONPatchFormatter
+// writes it, or from any other tool) or the difference array JSONFormatter
+// writes, and edits the source's *yaml.Node tree in place. Only the nodes a
+// patch touches are replaced, so comments, key order, anchors and scalar
+// styles everywhere else survive; a replaced value keeps its comments, anchor
+// and, where the type is unchanged, its quoting or flow style. Unlike
+// comparison, merge keys (<<) are left unexpanded: a value inherited through
+// one is overridden by adding the key to the mapping.
+//
+// Multi-document sources are addressed the way buildJSONPatchPath writes
+// paths: the first pointer segment is the document index ("/1/spec/replicas"),
+// and a bare index adds, removes or replaces a whole document. In
+// JSONFormatter output the index is the leading "[N]" path segment.
+// Key functions: ApplyPatch().
+package diffyml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// patchOp is one edit, decoded from either patch format. Paths addressing a
+// document carry its index as a leading "[N]" segment ("[-]" appends).
+type patchOp struct {
+	op    string   // add, remove, replace, move, copy or test
+	path  DiffPath // target
+	from  DiffPath // move and copy source
+	value *yaml.Node
+	// old is the removed value of a JSONFormatter difference; it picks the
+	// item to drop from an identifier-matched list.
+	old *yaml.Node
+	// diff marks operations decoded from JSONFormatter output, whose paths
+	// follow the comparator's conventions (see applyDiff).
+	diff bool
+	// item marks a JSONFormatter addition or removal of a whole list item
+	// (list_item).
+	item bool
+	// pointer marks JSON Pointer paths, whose document index, if any, is
+	// still an ordinary first segment (see indexDocuments).
+	pointer bool
+	text    string // path as written in the patch, for errors
+}
+
+// patchSlot is the place a path names: seg within parent, or a whole
+// document when parent is nil.
+type patchSlot struct {
+	parent *yaml.Node // mapping or sequence; nil for a document
+	seg    string     // key, index, "-" or list item identifier
+	at     DiffPath   // path of parent within its document, for ListKeys
+	// apiVersion and kind identify the Kubernetes resource of the document,
+	// for its list keys.
+	apiVersion string
+	kind       string
+	doc        int
+	// docs is set when a document is addressed by index, so that add and
+	// remove insert and delete documents rather than replace the content.
+	docs bool
+}
+
+// path returns the path of the slot's value within its document.
+func (s patchSlot) path() DiffPath {
+	if s.parent == nil {
+		return nil
+	}
+	return s.at.Append(s.seg)
+}
+
+// applier holds the documents being patched.
+type applier struct {
+	opts     *Options
+	docs     []*yaml.Node // DocumentNodes
+	unshared map[*yaml.Node]bool
+	expanded map[*yaml.Node]*yaml.Node // expanded alias -> its anchored target
+}
+
+// ApplyPatch applies patch to the YAML source and returns the edited YAML.
+// The patch is a JSON array of either RFC 6902 operations (add, remove,
+// replace, move, copy, test) or differences as JSONFormatter writes them;
+// order_changed, unchanged, comment_changed and warning entries are skipped. RFC 6902 operations apply
+// in order and the first failing one is returned as an error. opts supplies
+// AdditionalIdentifiers and ListKeys for path segments naming list items, and
+// with DetectKubernetes the built-in Kubernetes list keys; it may be nil.
+func ApplyPatch(source, patch []byte, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	docs, err := decodeDocuments(source)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) > 1 || pointersIndexDocuments(ops, docs[0]) {
+		if err := indexDocuments(ops); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &applier{opts: opts, docs: docs, unshared: make(map[*yaml.Node]bool), expanded: make(map[*yaml.Node]*yaml.Node)}
+	for _, op := range ops {
+		if err := a.apply(op); err != nil {
+			return nil, fmt.Errorf("%s %q: %w", op.op, op.text, err)
+		}
+	}
+	a.reshare()
+	return encodeDocuments(a.docs, source)
+}
+
+// decodeDocuments parses every document of src without resolving merge keys,
+// so that the tree re-encodes the way it was written. Empty input yields one
+// empty document.
+func decodeDocuments(src []byte) ([]*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		doc := &yaml.Node{}
+		if err := dec.Decode(doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		untagMergeKeys(doc)
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		docs = append(docs, &yaml.Node{Kind: yaml.DocumentNode})
+	}
+	return docs, nil
+}
+
+// untagMergeKeys drops the !!merge tag the decoder puts on merge keys, which
+// the encoder would otherwise write out explicitly.
+func untagMergeKeys(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if isMergeKeyNode(n.Content[i]) && n.Content[i].Tag == "!!merge" {
+				n.Content[i].Tag = ""
+			}
+		}
+	}
+	for _, c := range n.Content {
+		untagMergeKeys(c)
+	}
+}
+
+// encodeDocuments writes the documents back with the indentation and
+// sequence style detected in the original source.
+func encodeDocuments(docs []*yaml.Node, src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(src))
+	if detectCompactSequences(src) {
+		enc.CompactSeqIndent()
+	}
+	for _, doc := range docs {
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("encoding patched YAML: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding patched YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectCompactSequences reports whether src writes block sequences at the
+// indentation of their parent key ("key:\n- a"), as kubectl does, judged by
+// the first sequence under a key.
+func detectCompactSequences(src []byte) bool {
+	keyIndent := -1
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if keyIndent >= 0 && (trimmed == "-" || strings.HasPrefix(trimmed, "- ")) {
+			return indent == keyIndent
+		}
+		keyIndent = -1
+		if strings.HasSuffix(trimmed, ":") {
+			keyIndent = indent
+		}
+	}
+	return false
+}
+
+// parsePatch decodes a JSON Patch or a JSONFormatter difference array.
+func parsePatch(data []byte) ([]patchOp, error) {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+
+	var ops []patchOp
+	diffs := false
+	within := make(map[string]bool)
+	for i, entry := range entries {
+		var (
+			op  patchOp
+			err error
+		)
+		switch {
+		case entry["op"] != nil:
+			op, err = parsePatchOperation(entry)
+		case entry["type"] != nil && entry["within"] != nil:
+			op, err = parsePatchWithin(entry["within"])
+			if err == nil && within[op.text] {
+				continue
+			}
+			within[op.text] = true
+			diffs = true
+		case entry["type"] != nil:
+			var skip bool
+			op, skip, err = parsePatchDifference(entry)
+			if skip {
+				continue
+			}
+			diffs = true
+		default:
+			err = errors.New(`expected an "op" or a "type" field`)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch entry %d: %w", i, err)
+		}
+		ops = append(ops, op)
+	}
+	if diffs {
+		ops = orderDiffOps(ops)
+	}
+	return ops, nil
+}
+
+// parsePatchOperation decodes one RFC 6902 operation.
+func parsePatchOperation(entry map[string]json.RawMessage) (patchOp, error) {
+	op := patchOp{pointer: true}
+	if err := json.Unmarshal(entry["op"], &op.op); err != nil {
+		return op, fmt.Errorf("invalid op: %w", err)
+	}
+	switch op.op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	default:
+		return op, fmt.Errorf("unsupported op %q", op.op)
+	}
+
+	var err error
+	if op.text, op.path, err = patchPointerField(entry, "path"); err != nil {
+		return op, err
+	}
+	if op.op == "move" || op.op == "copy" {
+		if _, op.from, err = patchPointerField(entry, "from"); err != nil {
+			return op, err
+		}
+	}
+	if op.op == "add" || op.op == "replace" || op.op == "test" {
+		raw, ok := entry["value"]
+		if !ok {
+			return op, fmt.Errorf("%s operation requires a value", op.op)
+		}
+		if op.value, err = jsonValueNode(raw); err != nil {
+			return op, err
+		}
+	}
+	return op, nil
+}
+
+// parsePatchDifference decodes one JSONFormatter difference into the
+// operation that replays it. skip is set for entries that change nothing
+// applicable (order_changed, unchanged, comment_changed, warning).
+func parsePatchDifference(entry map[string]json.RawMessage) (op patchOp, skip bool, err error) {
+	var kind string
+	if err := json.Unmarshal(entry["type"], &kind); err != nil {
+		return op, false, fmt.Errorf("invalid type: %w", err)
+	}
+	if err := json.Unmarshal(entry["path"], &op.text); err != nil {
+		return op, false, fmt.Errorf("invalid path: %w", err)
+	}
+	if strings.HasPrefix(op.text, "/") {
+		// --use-go-patch-style output.
+		op.pointer = true
+		if op.path, err = parsePointer(op.text); err != nil {
+			return op, false, err
+		}
+	} else {
+		op.path = parseDisplayPath(op.text)
+	}
+
+	op.diff = true
+	if raw, ok := entry["list_item"]; ok {
+		if err := json.Unmarshal(raw, &op.item); err != nil {
+			return op, false, fmt.Errorf("invalid list_item: %w", err)
+		}
+	}
+	switch kind {
+	case "added":
+		op.op = "add"
+		op.value, err = jsonValueNode(entry["to"])
+	case "removed":
+		op.op = "remove"
+		op.old, err = jsonValueNode(entry["from"])
+	case "type_changed":
+		op.op = "replace"
+		op.value, err = jsonValueNode(entry["to"])
+	case "modified":
+		op.op = "replace"
+		op.value, err = jsonValueNode(entry["to"])
+		// A removed document without Kubernetes matching is reported as
+		// the document becoming null.
+		if err == nil && op.path.IsBareDocIndex() && op.value.Tag == "!!null" {
+			op.op = "remove"
+		}
+	case "moved":
+		op.op = "move"
+		var from string
+		if err := json.Unmarshal(entry["from_path"], &from); err != nil {
+			return op, false, fmt.Errorf("invalid from_path: %w", err)
+		}
+		if op.pointer {
+			op.from, err = parsePointer(from)
+		} else {
+			op.from = parseDisplayPath(from)
+		}
+	case "order_changed", "unchanged", "comment_changed", "warning":
+		return op, true, nil
+	default:
+		return op, false, fmt.Errorf("unsupported type %q", kind)
+	}
+	return op, false, err
+}
+
+// parsePatchWithin decodes the within field of a JSONFormatter difference
+// inside an embedded document or decoded payload into a replacement of the
+// whole string, which the difference's own path reaches inside of.
+func parsePatchWithin(raw json.RawMessage) (op patchOp, err error) {
+	var within struct {
+		Path string          `json:"path"`
+		To   json.RawMessage `json:"to"`
+	}
+	if err := json.Unmarshal(raw, &within); err != nil {
+		return op, fmt.Errorf("invalid within: %w", err)
+	}
+	op = patchOp{op: "replace", text: within.Path, diff: true}
+	if strings.HasPrefix(within.Path, "/") {
+		op.pointer = true
+		if op.path, err = parsePointer(within.Path); err != nil {
+			return op, err
+		}
+	} else {
+		op.path = parseDisplayPath(within.Path)
+	}
+	op.value, err = jsonValueNode(within.To)
+	return op, err
+}
+
+// orderDiffOps orders replayed differences so that every path still means
+// what it meant to the comparator. Paths name items by their index in the
+// old file, except that an added list item or document is named by its index
+// in the new file. Moves, which rename mapping entries only, go first, since
+// the changes inside a moved subtree are named by its new path; replacements
+// and added mapping entries follow; removals and added items and documents follow, deepest paths first
+// so that no list is shifted before the paths through it are used, and at
+// equal depth removals go last-first before additions.
+func orderDiffOps(ops []patchOp) []patchOp {
+	ordered := make([]patchOp, 0, len(ops))
+	var moves, removals, additions []patchOp
+	for _, op := range ops {
+		switch {
+		case op.op == "move":
+			moves = append(moves, op)
+		case op.op == "remove":
+			removals = append(removals, op)
+		case op.op == "add" && (op.item || op.path.IsBareDocIndex()):
+			additions = append(additions, op)
+		default:
+			ordered = append(ordered, op)
+		}
+	}
+	slices.Reverse(removals)
+	structural := append(removals, additions...)
+	slices.SortStableFunc(structural, func(x, y patchOp) int {
+		return len(y.path) - len(x.path)
+	})
+	return append(append(moves, ordered...), structural...)
+}
+
+// patchPointerField decodes the JSON Pointer held in entry[field].
+func patchPointerField(entry map[string]json.RawMessage, field string) (string, DiffPath, error) {
+	raw, ok := entry[field]
+	if !ok {
+		return "", nil, fmt.Errorf("missing %q field", field)
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	path, err := parsePointer(text)
+	return text, path, err
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into path segments.
+func parsePointer(text string) (DiffPath, error) {
+	if text == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(text, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with /", text)
+	}
+	segs := strings.Split(text[1:], "/")
+	path := make(DiffPath, len(segs))
+	for i, seg := range segs {
+		path[i] = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+	}
+	return path, nil
+}
+
+// pointers returns the paths an operation addresses.
+func (op patchOp) pointers() []DiffPath {
+	if op.op == "move" || op.op == "copy" {
+		return []DiffPath{op.path, op.from}
+	}
+	return []DiffPath{op.path}
+}
+
+// pointersIndexDocuments reports whether the JSON Pointers of a patch for a
+// single-document source start with a document index anyway, as
+// buildJSONPatchPath writes them when the other file of the comparison had
+// several documents: every pointer starts with an index that the source's
+// root cannot resolve as a key or list index.
+func pointersIndexDocuments(ops []patchOp, doc *yaml.Node) bool {
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = resolveAlias(doc.Content[0])
+	}
+	if root != nil && root.Kind == yaml.SequenceNode {
+		return false
+	}
+	found := false
+	for _, op := range ops {
+		if !op.pointer {
+			continue
+		}
+		for _, path := range op.pointers() {
+			if len(path) == 0 {
+				return false
+			}
+			if path[0] != "-" && !isPatchIndex(path[0]) {
+				return false
+			}
+			if root != nil && root.Kind == yaml.MappingNode && mappingKeyIndex(root, path[0]) >= 0 {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// indexDocuments turns the first segment of every JSON Pointer into the
+// document index segment the rest of the applier expects.
+func indexDocuments(ops []patchOp) error {
+	for i := range ops {
+		if !ops[i].pointer {
+			continue
+		}
+		for _, path := range ops[i].pointers() {
+			if len(path) == 0 {
+				continue
+			}
+			if path[0] != "-" && !isPatchIndex(path[0]) {
+				return fmt.Errorf("invalid path %q: must start with a document index in a multi-document file", ops[i].text)
+			}
+			path[0] = "[" + path[0] + "]"
+		}
+	}
+	return nil
+}
+
+// parseDisplayPath reverses DiffPath.String: segments are dot-separated,
+// bracketed segments may contain dots, and a leading [N] is a document index.
+func parseDisplayPath(text string) DiffPath {
+	var path DiffPath
+	for i := 0; i < len(text); {
+		var seg string
+		if text[i] == '[' {
+			end := strings.IndexByte(text[i:], ']')
+			if end < 0 {
+				end = len(text) - i
+			}
+			seg = text[i+1 : i+end]
+			if len(path) == 0 && isPatchIndex(seg) {
+				seg = "[" + seg + "]"
+			}
+			i += end + 1
+		} else {
+			end := strings.IndexAny(text[i:], ".[")
+			if end < 0 {
+				end = len(text) - i
+			}
+			seg = text[i : i+end]
+			i += end
+		}
+		path = append(path, seg)
+		if i < len(text) && text[i] == '.' {
+			i++
+		}
+	}
+	return path
+}
+
+// isPatchIndex reports whether seg is a non-negative decimal array index.
+func isPatchIndex(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	for _, c := range seg {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonValueNode converts a JSON value to a YAML node. JSON is YAML, so the
+// value is parsed as such, which keeps object key order; the flow and
+// quoting styles JSON implies are cleared so the value is written in block
+// style, quoted only where needed.
+func jsonValueNode(raw json.RawMessage) (*yaml.Node, error) {
+	var doc yaml.Node
+	if len(raw) > 0 {
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	clearNodeStyle(doc.Content[0])
+	return doc.Content[0], nil
+}
+
+// clearNodeStyle resets the style of n and its descendants.
+func clearNodeStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearNodeStyle(c)
+	}
+}
+
+// apply performs one operation.
+func (a *applier) apply(op patchOp) error {
+	if op.diff {
+		return a.applyDiff(op)
+	}
+	switch op.op {
+	case "add":
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		return a.add(s, op.value, false)
+	case "remove":
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		_, err = a.remove(s)
+		return err
+	case "replace":
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		return a.replace(s, op.value)
+	case "move":
+		if len(op.from) < len(op.path) && slices.Equal(op.path[:len(op.from)], op.from) {
+			return errors.New("cannot move a value into itself")
+		}
+		from, err := a.slot(op.from, true)
+		if err != nil {
+			return err
+		}
+		val, err := a.remove(from)
+		if err != nil {
+			return err
+		}
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		return a.add(s, val, false)
+	case "copy":
+		from, err := a.slot(op.from, false)
+		if err != nil {
+			return err
+		}
+		val, err := a.get(from)
+		if err != nil {
+			return err
+		}
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		return a.add(s, detachNode(val), false)
+	default: // test
+		s, err := a.slot(op.path, false)
+		if err != nil {
+			return err
+		}
+		val, err := a.get(s)
+		if err != nil {
+			return err
+		}
+		if !a.equal(val, op.value) {
+			return errors.New("test failed: value differs")
+		}
+		return nil
+	}
+}
+
+// applyDiff replays a JSONFormatter difference. The comparator reports an
+// added or removed mapping entry at the mapping's path with a one-entry
+// mapping as the value, and an added or removed identifier-matched list item
+// at the list's path; both are recognized by the path naming an existing
+// collection. A whole item of an unidentified list is marked as such and
+// named by its own index. Otherwise the path names the value itself, and
+// indices past the end of a list append, since additions carry their index
+// in the new file. A moved subtree is moved as by RFC 6902.
+func (a *applier) applyDiff(op patchOp) error {
+	if op.op == "move" {
+		op.diff = false
+		return a.apply(op)
+	}
+	if op.op == "replace" {
+		s, err := a.slot(op.path, true)
+		if err != nil {
+			return err
+		}
+		return a.replace(s, op.value)
+	}
+
+	value := op.value
+	if op.op == "remove" {
+		value = op.old
+	}
+	s, err := a.slot(op.path, true)
+	if err != nil {
+		return err
+	}
+	if op.item && s.parent != nil && s.parent.Kind == yaml.SequenceNode {
+		if op.op == "add" {
+			return a.add(s, value, true)
+		}
+		_, err = a.remove(s)
+		return err
+	}
+	if target := a.diffContainer(s, value, op.op == "remove"); target != nil {
+		if target.Kind == yaml.SequenceNode {
+			if op.op == "add" {
+				target.Content = append(target.Content, value)
+				return nil
+			}
+			for i, item := range target.Content {
+				if a.sameItem(s, item, value) {
+					a.unshare(item, true)
+					target.Content = append(target.Content[:i], target.Content[i+1:]...)
+					return nil
+				}
+			}
+			return errors.New("removed list item not found")
+		}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			entry := patchSlot{parent: target, seg: value.Content[i].Value, at: s.path(), apiVersion: s.apiVersion, kind: s.kind}
+			if op.op == "add" {
+				err = a.add(entry, value.Content[i+1], true)
+			} else {
+				_, err = a.remove(entry)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if op.op == "add" {
+		return a.add(s, value, true)
+	}
+	_, err = a.remove(s)
+	return err
+}
+
+// diffContainer returns the collection an added or removed difference
+// value belongs in, or nil when the path names the value itself: a list
+// index past the end, a missing key, a positional list item removed as a
+// whole, or a whole document — a Kubernetes resource or an empty one.
+func (a *applier) diffContainer(s patchSlot, value *yaml.Node, removing bool) *yaml.Node {
+	if value == nil {
+		return nil
+	}
+	if s.docs && (s.doc >= len(a.docs) || len(value.Content) == 0 || IsKubernetesResource(nodeToInterface(value))) {
+		return nil
+	}
+	target := a.root(s.doc)
+	if s.parent != nil {
+		var err error
+		if target, err = a.child(s, true); err != nil {
+			return nil
+		}
+	} else {
+		a.unshare(target, false)
+	}
+	target, err := a.writable(target)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case target.Kind == yaml.SequenceNode:
+		return target
+	case target.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+		if removing && s.parent != nil && s.parent.Kind == yaml.SequenceNode && a.equal(target, value) {
+			// A whole list item removed from a positional list.
+			return nil
+		}
+		return target
+	}
+	return nil
+}
+
+// slot resolves path to the place it names. With write set, the path is
+// prepared for an edit: aliases and merge-key inherited values along it are
+// copied into place, and anchored values along it are unshared (see unshare).
+func (a *applier) slot(path DiffPath, write bool) (patchSlot, error) {
+	var s patchSlot
+	if idx, ok := path.DocIndex(); ok {
+		s.doc, s.docs, path = idx, true, path[1:]
+	} else if len(path) > 0 && path[0] == "[-]" {
+		s.doc, s.docs, path = len(a.docs), true, path[1:]
+	}
+	if len(path) == 0 {
+		return s, nil
+	}
+	if s.doc >= len(a.docs) {
+		return s, fmt.Errorf("document %d does not exist", s.doc)
+	}
+
+	node := a.root(s.doc)
+	if write {
+		a.unshare(node, false)
+	}
+	if a.opts.DetectKubernetes {
+		s.apiVersion, s.kind = k8sNodeResource(node)
+	}
+	for i, seg := range path[:len(path)-1] {
+		child, err := a.child(patchSlot{parent: node, seg: seg, at: path[:i], apiVersion: s.apiVersion, kind: s.kind}, write)
+		if err != nil {
+			return s, err
+		}
+		node = child
+	}
+	var err error
+	if write {
+		node, err = a.writable(node)
+	} else {
+		node, err = a.collection(node)
+	}
+	return patchSlot{parent: node, seg: path[len(path)-1], at: path[:len(path)-1], apiVersion: s.apiVersion, kind: s.kind, doc: s.doc}, err
+}
+
+// root returns the content of document i, or nil when it is empty.
+func (a *applier) root(i int) *yaml.Node {
+	if len(a.docs[i].Content) == 0 {
+		return nil
+	}
+	return a.docs[i].Content[0]
+}
+
+// unshare gives every alias of n — and, with deep set, of its anchored
+// descendants — its own copy of the current value before n is edited or
+// removed, so that the patch changes exactly the paths it names. Anchors that
+// no edit touches keep their aliases.
+func (a *applier) unshare(n *yaml.Node, deep bool) {
+	if n == nil {
+		return
+	}
+	if n.Anchor != "" && !a.unshared[n] {
+		a.unshared[n] = true
+		for _, doc := range a.docs {
+			a.expandAliasesOf(doc, n)
+		}
+	}
+	if deep {
+		for _, c := range n.Content {
+			a.unshare(c, true)
+		}
+	}
+}
+
+// expandAliasesOf replaces the aliases of target under n with copies.
+func (a *applier) expandAliasesOf(n, target *yaml.Node) {
+	for _, c := range n.Content {
+		switch {
+		case c.Kind != yaml.AliasNode:
+			a.expandAliasesOf(c, target)
+		case c.Alias == target:
+			a.expandAlias(c)
+		}
+	}
+}
+
+// expandAlias replaces an alias node with a copy of its target, keeping
+// its comments, and remembers the target for reshare.
+func (a *applier) expandAlias(n *yaml.Node) {
+	target := resolveAlias(n)
+	c := detachNode(n)
+	c.HeadComment, c.LineComment, c.FootComment = n.HeadComment, n.LineComment, n.FootComment
+	*n = *c
+	if target != nil {
+		a.expanded[n] = target
+	}
+}
+
+// reshare turns expanded aliases whose value ended up equal to their
+// anchored original back into aliases: a diff of the expanded files reports
+// an edit to an anchored value at every alias too, and replaying all of them
+// should leave the anchor in place.
+func (a *applier) reshare() {
+	for _, doc := range a.docs {
+		a.reshareIn(doc, make(map[*yaml.Node]bool))
+	}
+}
+
+// reshareIn walks n in document order; seen holds the anchored nodes
+// already written, which are the ones an alias may refer to.
+func (a *applier) reshareIn(n *yaml.Node, seen map[*yaml.Node]bool) {
+	if target, ok := a.expanded[n]; ok && seen[target] && target.Anchor != "" && a.equal(n, target) {
+		*n = yaml.Node{
+			Kind: yaml.AliasNode, Value: target.Anchor, Alias: target,
+			HeadComment: n.HeadComment, LineComment: n.LineComment, FootComment: n.FootComment,
+		}
+		return
+	}
+	if n.Kind == yaml.MappingNode {
+		a.reshareMergeSources(n, seen)
+	}
+	for _, c := range n.Content {
+		a.reshareIn(c, seen)
+	}
+	if n.Anchor != "" {
+		seen[n] = true
+	}
+}
+
+// reshareMergeSources restores an expanded merge key source whose anchored
+// original was edited when m overrides every edited key with the edited
+// value, which is what replaying a diff of the expanded files leaves behind.
+// The overrides are dropped again.
+func (a *applier) reshareMergeSources(m *yaml.Node, seen map[*yaml.Node]bool) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		src := m.Content[i+1]
+		target, ok := a.expanded[src]
+		if !ok || !isMergeKeyNode(m.Content[i]) || !seen[target] || target.Anchor == "" ||
+			src.Kind != yaml.MappingNode || target.Kind != yaml.MappingNode || len(src.Content) != len(target.Content) {
+			continue
+		}
+		overrides, ok := a.mergeOverrides(m, src, target)
+		if !ok {
+			continue
+		}
+		m.Content[i+1] = &yaml.Node{Kind: yaml.AliasNode, Value: target.Anchor, Alias: target, LineComment: src.LineComment}
+		slices.SortFunc(overrides, func(x, y int) int { return y - x })
+		for _, o := range overrides {
+			m.Content = append(m.Content[:o], m.Content[o+2:]...)
+			if o < i {
+				i -= 2
+			}
+		}
+	}
+}
+
+// mergeOverrides returns the Content indices of the keys in m that override
+// each entry where src differs from target with target's value, and false
+// when some differing entry is not overridden that way.
+func (a *applier) mergeOverrides(m, src, target *yaml.Node) ([]int, bool) {
+	var overrides []int
+	for j := 0; j+1 < len(src.Content); j += 2 {
+		t := mappingKeyIndex(target, src.Content[j].Value)
+		if t < 0 {
+			return nil, false
+		}
+		if a.equal(src.Content[j+1], target.Content[t+1]) {
+			continue
+		}
+		o := mappingKeyIndex(m, src.Content[j].Value)
+		if o < 0 || !a.equal(m.Content[o+1], target.Content[t+1]) {
+			return nil, false
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, true
+}
+
+// collection resolves aliases and checks that n can hold children.
+func (a *applier) collection(n *yaml.Node) (*yaml.Node, error) {
+	if n == nil {
+		return nil, errors.New("path not found")
+	}
+	n = resolveAlias(n)
+	if n == nil || (n.Kind != yaml.MappingNode && n.Kind != yaml.SequenceNode) {
+		return nil, errors.New("path descends into a scalar")
+	}
+	return n, nil
+}
+
+// writable is collection for nodes about to be edited: an alias is replaced
+// by a copy of its target, leaving the anchored original unchanged.
+func (a *applier) writable(n *yaml.Node) (*yaml.Node, error) {
+	if n != nil && n.Kind == yaml.AliasNode {
+		a.expandAlias(n)
+	}
+	return a.collection(n)
+}
+
+// child returns the value a slot names within its parent.
+func (a *applier) child(s patchSlot, write bool) (*yaml.Node, error) {
+	n, seg := s.parent, s.seg
+	var err error
+	if write {
+		n, err = a.writable(n)
+	} else {
+		n, err = a.collection(n)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n.Kind == yaml.SequenceNode {
+		i, err := a.itemIndex(n, s, false)
+		if err != nil {
+			return nil, err
+		}
+		if write {
+			a.unshare(n.Content[i], false)
+		}
+		return n.Content[i], nil
+	}
+	if i := mappingKeyIndex(n, seg); i >= 0 {
+		if write {
+			a.unshare(n.Content[i+1], false)
+		}
+		return n.Content[i+1], nil
+	}
+	inherited := inheritedValue(n, seg)
+	if inherited == nil {
+		return nil, fmt.Errorf("key %q not found", seg)
+	}
+	if !write {
+		return inherited, nil
+	}
+	// Override the inherited value so the edit stays in this mapping.
+	val := detachNode(inherited)
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, val)
+	return val, nil
+}
+
+// get returns the value a slot holds.
+func (a *applier) get(s patchSlot) (*yaml.Node, error) {
+	if s.parent == nil {
+		if s.doc >= len(a.docs) {
+			return nil, fmt.Errorf("document %d does not exist", s.doc)
+		}
+		if root := a.root(s.doc); root != nil {
+			return root, nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	return a.child(s, false)
+}
+
+// add inserts val at the slot: a new mapping key or list item, a new
+// document, or the whole content of a single-document file. An existing
+// mapping key is replaced, unless it holds a list and val does not: such an
+// add is meant for an item of the list, not to overwrite it. With clamp set,
+// list indices past the end append.
+func (a *applier) add(s patchSlot, val *yaml.Node, clamp bool) error {
+	switch {
+	case s.parent == nil && s.docs:
+		if s.doc > len(a.docs) {
+			if !clamp {
+				return fmt.Errorf("document index %d out of range", s.doc)
+			}
+			s.doc = len(a.docs)
+		}
+		doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{val}}
+		a.docs = append(a.docs[:s.doc], append([]*yaml.Node{doc}, a.docs[s.doc:]...)...)
+		return nil
+	case s.parent == nil:
+		return a.replace(s, val)
+	case s.parent.Kind == yaml.MappingNode:
+		if i := mappingKeyIndex(s.parent, s.seg); i >= 0 {
+			if old := resolveAlias(s.parent.Content[i+1]); old != nil && old.Kind == yaml.SequenceNode && val.Kind != yaml.SequenceNode {
+				return fmt.Errorf("key %q holds a list; add an item at %q", s.seg, s.seg+"/-")
+			}
+			a.unshare(s.parent.Content[i+1], true)
+			replaceNode(s.parent.Content[i+1], val)
+			return nil
+		}
+		s.parent.Content = append(s.parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.seg}, val)
+		return nil
+	}
+
+	items := s.parent.Content
+	i := len(items)
+	if s.seg != "-" {
+		n, err := strconv.Atoi(s.seg)
+		if err != nil || !isPatchIndex(s.seg) {
+			return fmt.Errorf("invalid list index %q", s.seg)
+		}
+		if n > len(items) && !clamp {
+			return fmt.Errorf("list index %d out of range", n)
+		}
+		i = min(n, len(items))
+	}
+	s.parent.Content = append(items[:i], append([]*yaml.Node{val}, items[i:]...)...)
+	return nil
+}
+
+// remove deletes the slot's value and returns it.
+func (a *applier) remove(s patchSlot) (*yaml.Node, error) {
+	switch {
+	case s.parent == nil && s.docs:
+		if s.doc >= len(a.docs) {
+			return nil, fmt.Errorf("document %d does not exist", s.doc)
+		}
+		val := a.root(s.doc)
+		a.unshare(val, true)
+		a.docs = append(a.docs[:s.doc], a.docs[s.doc+1:]...)
+		return val, nil
+	case s.parent == nil:
+		return nil, errors.New("cannot remove the document root")
+	case s.parent.Kind == yaml.MappingNode:
+		i := mappingKeyIndex(s.parent, s.seg)
+		if i < 0 {
+			if inheritedValue(s.parent, s.seg) != nil {
+				return nil, fmt.Errorf("key %q is inherited through a merge key", s.seg)
+			}
+			return nil, fmt.Errorf("key %q not found", s.seg)
+		}
+		val := s.parent.Content[i+1]
+		a.unshare(val, true)
+		s.parent.Content = append(s.parent.Content[:i], s.parent.Content[i+2:]...)
+		return val, nil
+	}
+	i, err := a.itemIndex(s.parent, s, false)
+	if err != nil {
+		return nil, err
+	}
+	val := s.parent.Content[i]
+	a.unshare(val, true)
+	s.parent.Content = append(s.parent.Content[:i], s.parent.Content[i+1:]...)
+	return val, nil
+}
+
+// replace swaps the slot's value for val, keeping the old value's comments,
+// anchor and style.
+func (a *applier) replace(s patchSlot, val *yaml.Node) error {
+	if s.parent == nil {
+		if s.doc >= len(a.docs) {
+			return fmt.Errorf("document %d does not exist", s.doc)
+		}
+		if root := a.root(s.doc); root != nil {
+			a.unshare(root, true)
+			replaceNode(root, val)
+		} else {
+			a.docs[s.doc].Content = []*yaml.Node{val}
+		}
+		return nil
+	}
+	if s.parent.Kind == yaml.MappingNode && mappingKeyIndex(s.parent, s.seg) < 0 {
+		if inheritedValue(s.parent, s.seg) == nil {
+			return fmt.Errorf("key %q not found", s.seg)
+		}
+		// Override the inherited value.
+		return a.add(s, val, false)
+	}
+	old, err := a.child(s, false)
+	if err != nil {
+		return err
+	}
+	a.unshare(old, true)
+	replaceNode(old, val)
+	return nil
+}
+
+// replaceNode overwrites old with val in place, so aliases of an anchored
+// value follow the edit. Comments and the anchor stay; the style stays when
+// the kind and, for scalars, the type are unchanged.
+func replaceNode(old, val *yaml.Node) {
+	n := *val
+	if old.Kind == n.Kind && (n.Kind != yaml.ScalarNode || old.ShortTag() == n.ShortTag()) {
+		n.Style = old.Style
+	}
+	n.Anchor = old.Anchor
+	n.HeadComment, n.LineComment, n.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	*old = n
+}
+
+// itemIndex resolves the segment of slot s within the list seq: an index,
+// "-" for the end when allowEnd is set, or the identifier of an item as in
+// comparator paths.
+func (a *applier) itemIndex(seq *yaml.Node, s patchSlot, allowEnd bool) (int, error) {
+	seg := s.seg
+	if seg == "-" && allowEnd {
+		return len(seq.Content), nil
+	}
+	if isPatchIndex(seg) {
+		i, err := strconv.Atoi(seg)
+		if err != nil || i >= len(seq.Content) {
+			return 0, fmt.Errorf("list index %s out of range", seg)
+		}
+		return i, nil
+	}
+	ids := identityAt(s.at, a.opts.forResource(s.apiVersion, s.kind))
+	for i, item := range seq.Content {
+		if id := ids.of(item); isComparableIdentifier(id) && sprintIdentifier(id) == seg {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no list item %q", seg)
+}
+
+// sameItem reports whether item of the list list names is the removed list
+// item old: the same identifier when old has one, otherwise an equal value.
+func (a *applier) sameItem(list patchSlot, item, old *yaml.Node) bool {
+	ids := identityAt(list.path(), a.opts.forResource(list.apiVersion, list.kind))
+	if id := ids.of(old); isComparableIdentifier(id) {
+		itemID := ids.of(item)
+		return isComparableIdentifier(itemID) && sprintIdentifier(itemID) == sprintIdentifier(id)
+	}
+	return a.equal(item, old)
+}
+
+// equal reports whether two values compare without differences.
+func (a *applier) equal(x, y *yaml.Node) bool {
+	return len(compareNodes(nil, x, y, &Options{AdditionalIdentifiers: a.opts.AdditionalIdentifiers, ListKeys: a.opts.ListKeys})) == 0
+}
+
+// mappingKeyIndex returns the Content index of key's last occurrence in a
+// mapping, matching lookupMappingValueNode, or -1.
+func mappingKeyIndex(n *yaml.Node, key string) int {
+	for i := len(n.Content) - 2; i >= 0; i -= 2 {
+		if n.Content[i].Value == key && !isMergeKeyNode(n.Content[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inheritedValue returns the value a mapping inherits for key through its
+// merge keys, or nil. Earlier merge sources take precedence, as in YAML.
+func inheritedValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !isMergeKeyNode(n.Content[i]) {
+			continue
+		}
+		sources := []*yaml.Node{n.Content[i+1]}
+		if src := resolveAlias(n.Content[i+1]); src != nil && src.Kind == yaml.SequenceNode {
+			sources = src.Content
+		}
+		for _, src := range sources {
+			src = resolveAlias(src)
+			if src == nil || src.Kind != yaml.MappingNode {
+				continue
+			}
+			if j := mappingKeyIndex(src, key); j >= 0 {
+				return src.Content[j+1]
+			}
+			if v := inheritedValue(src, key); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// isMergeKeyNode reports whether a mapping key is the YAML merge key,
+// recognized by value as in resolveMappingMergeKeys.
+func isMergeKeyNode(k *yaml.Node) bool {
+	return k.Kind == yaml.ScalarNode && k.Value == "<<"
+}
@@ -0,0 +1,319 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+func applyPatch(t *testing.T, source, patch string) string {
+	t.Helper()
+	out, err := ApplyPatch([]byte(source), []byte(patch), nil)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	return string(out)
+}
+
+// roundTrip diffs from against to with the given formatter and applies the
+// output to from.
+func roundTrip(t *testing.T, from, to, format string) string {
+	t.Helper()
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	f, err := FormatterByName(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return applyPatch(t, from, f.Format(diffs, &FormatOptions{}))
+}
+
+func TestApplyPatch_PreservesFormatting(t *testing.T) {
+	source := "# Service settings\n" +
+		"name: \"web\" # quoted\n" +
+		"ports: [80, 443]\n" +
+		"script: |\n" +
+		"  echo start\n" +
+		"env:\n" +
+		"- name: A\n" +
+		"  value: 'x'\n"
+	patch := `[
+		{"op": "replace", "path": "/name", "value": "api"},
+		{"op": "add", "path": "/ports/-", "value": 8080},
+		{"op": "replace", "path": "/env/A/value", "value": "y"},
+		{"op": "add", "path": "/env/-", "value": {"name": "B", "value": "true"}}
+	]`
+
+	want := "# Service settings\n" +
+		"name: \"api\" # quoted\n" +
+		"ports: [80, 443, 8080]\n" +
+		"script: |\n" +
+		"  echo start\n" +
+		"env:\n" +
+		"- name: A\n" +
+		"  value: 'y'\n" +
+		"- name: B\n" +
+		"  value: \"true\"\n"
+	if got := applyPatch(t, source, patch); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_Operations(t *testing.T) {
+	source := "a: 1\nb:\n  c: [1, 2]\n"
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"add key", `[{"op":"add","path":"/d","value":{"e":null}}]`, "a: 1\nb:\n  c: [1, 2]\nd:\n  e: null\n"},
+		{"add replaces existing key", `[{"op":"add","path":"/a","value":2}]`, "a: 2\nb:\n  c: [1, 2]\n"},
+		{"insert list item", `[{"op":"add","path":"/b/c/0","value":0}]`, "a: 1\nb:\n  c: [0, 1, 2]\n"},
+		{"remove", `[{"op":"remove","path":"/b/c/1"}]`, "a: 1\nb:\n  c: [1]\n"},
+		{"replace with another type", `[{"op":"replace","path":"/a","value":"1"}]`, "a: \"1\"\nb:\n  c: [1, 2]\n"},
+		{"move", `[{"op":"move","from":"/b/c","path":"/c"}]`, "a: 1\nb: {}\nc: [1, 2]\n"},
+		{"copy", `[{"op":"copy","from":"/a","path":"/b/a"}]`, "a: 1\nb:\n  c: [1, 2]\n  a: 1\n"},
+		{"test", `[{"op":"test","path":"/b/c","value":[1,2]},{"op":"remove","path":"/a"}]`, "b:\n  c: [1, 2]\n"},
+		{"escaped pointer", `[{"op":"add","path":"/x~1y~0z","value":1}]`, "a: 1\nb:\n  c: [1, 2]\nx/y~z: 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyPatch(t, source, tt.patch); got != tt.want {
+				t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	source := "a: 1\nl: [1]\n"
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"not json", `nope`, "failed to parse patch"},
+		{"unknown op", `[{"op":"merge","path":"/a"}]`, `unsupported op "merge"`},
+		{"missing value", `[{"op":"add","path":"/a"}]`, "requires a value"},
+		{"missing key", `[{"op":"remove","path":"/b"}]`, `key "b" not found`},
+		{"index out of range", `[{"op":"add","path":"/l/5","value":1}]`, "out of range"},
+		{"scalar parent", `[{"op":"add","path":"/a/b","value":1}]`, "scalar"},
+		{"failed test", `[{"op":"test","path":"/a","value":2}]`, "test failed"},
+		{"move into itself", `[{"op":"move","from":"/l","path":"/l/0"}]`, "into itself"},
+		{"bad pointer", `[{"op":"remove","path":"a"}]`, "must start with /"},
+		{"list replaced by an item", `[{"op":"add","path":"/l","value":{"a":1}}]`, "holds a list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyPatch([]byte(source), []byte(tt.patch), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_MultiDocument(t *testing.T) {
+	source := "a: 1\n---\n# second\nb: 2\n"
+	patch := `[
+		{"op": "replace", "path": "/1/b", "value": 3},
+		{"op": "add", "path": "/-", "value": {"c": 4}},
+		{"op": "remove", "path": "/0"}
+	]`
+	want := "# second\nb: 3\n---\nc: 4\n"
+	if got := applyPatch(t, source, patch); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := ApplyPatch([]byte(source), []byte(`[{"op":"remove","path":"/b"}]`), nil); err == nil {
+		t.Error("expected an error for a pointer without a document index")
+	}
+}
+
+func TestApplyPatch_DocumentIndexOnSingleDocument(t *testing.T) {
+	// Comparing against a multi-document file prefixes every path with the
+	// document index, even though the source has only one document.
+	got := applyPatch(t, "a: 1\n", `[{"op":"replace","path":"/0/a","value":2},{"op":"add","path":"/1","value":{"b":1}}]`)
+	if want := "a: 2\n---\nb: 1\n"; got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A key that looks like an index is still a key.
+	got = applyPatch(t, "\"0\": 1\n", `[{"op":"replace","path":"/0","value":2}]`)
+	if want := "\"0\": 2\n"; got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_Anchors(t *testing.T) {
+	source := "base: &base\n  timeout: 30\n  retries: 3\nsvc:\n  <<: *base\n  name: web\nother: *base\n"
+
+	// Editing through an alias leaves the anchored original alone.
+	got := applyPatch(t, source, `[{"op":"replace","path":"/other/retries","value":5}]`)
+	want := "base: &base\n  timeout: 30\n  retries: 3\nsvc:\n  <<: *base\n  name: web\nother:\n  timeout: 30\n  retries: 5\n"
+	if got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Editing the anchored value everywhere it is used keeps the anchor.
+	to := "base:\n  timeout: 30\n  retries: 5\nsvc:\n  timeout: 30\n  retries: 5\n  name: web\nother:\n  timeout: 30\n  retries: 5\n"
+	got = roundTrip(t, source, to, "json")
+	want = strings.Replace(source, "retries: 3", "retries: 5", 1)
+	if got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_MergeKeyOverride(t *testing.T) {
+	source := "base: &base\n  timeout: 30\nsvc:\n  <<: *base\n"
+	got := applyPatch(t, source, `[{"op":"replace","path":"/svc/timeout","value":60}]`)
+	if want := source + "  timeout: 60\n"; got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_JSONDiffs(t *testing.T) {
+	from := "# app\n" +
+		"meta:\n" +
+		"  team: x # owner\n" +
+		"  tier: 1\n" +
+		"env:\n" +
+		"  - name: A\n" +
+		"    value: \"1\"\n" +
+		"  - name: B\n" +
+		"ports: [80, 443, 8080]\n"
+	to := "meta:\n" +
+		"  team: y\n" +
+		"  zone: eu\n" +
+		"env:\n" +
+		"  - name: A\n" +
+		"    value: \"2\"\n" +
+		"  - name: C\n" +
+		"ports: [80]\n"
+	got := roundTrip(t, from, to, "json")
+
+	want := "# app\n" +
+		"meta:\n" +
+		"  team: y # owner\n" +
+		"  zone: eu\n" +
+		"env:\n" +
+		"  - name: A\n" +
+		"    value: \"2\"\n" +
+		"  - name: C\n" +
+		"ports: [80]\n"
+	if got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_JSONDiffsKubernetesDocuments(t *testing.T) {
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: old\n"
+	svc := "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n"
+	from := secret + "---\n" + cm
+	to := strings.Replace(cm, "k: a", "k: b", 1) + "---\n" + svc
+
+	got := roundTrip(t, from, to, "json")
+	rest, err := Compare([]byte(got), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("patched file still differs from target (%d differences):\n%s", len(rest), got)
+	}
+}
+
+func TestApplyPatch_JSONPatchOutput(t *testing.T) {
+	from := "app:\n  version: \"1.0\" # current\n  debug: true\n  labels:\n    helm.sh/chart: app-1\n"
+	to := "app:\n  version: \"2.0\"\n  labels:\n    helm.sh/chart: app-2\n  monitoring: true\n"
+	got := roundTrip(t, from, to, "json-patch")
+	want := "app:\n  version: \"2.0\" # current\n  labels:\n    helm.sh/chart: app-2\n  monitoring: true\n"
+	if got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestApplyPatch_JSONPatchNamedListItems(t *testing.T) {
+	deploy := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  template:\n    spec:\n      containers:\n"
+	from := deploy +
+		"        - name: app\n          image: x\n" +
+		"        - name: side\n          image: s\n" +
+		"        - name: gone\n"
+	to := deploy +
+		"        - name: app\n          image: y\n" +
+		"        - name: b\n          image: y\n" +
+		"        - name: c\n"
+	got := roundTrip(t, from, to, "json-patch")
+	rest, err := Compare([]byte(got), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("patched file still differs from target (%d differences):\n%s", len(rest), got)
+	}
+
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := (&JSONPatchFormatter{}).Format(diffs, &FormatOptions{})
+	for _, want := range []string{
+		`"path": "/spec/template/spec/containers/side"`,
+		`"path": "/spec/template/spec/containers/gone"`,
+		`"path": "/spec/template/spec/containers/-"`,
+	} {
+		if !strings.Contains(patch, want) {
+			t.Errorf("patch missing %s:\n%s", want, patch)
+		}
+	}
+}
+
+func TestApplyPatch_JSONDiffsPositionalListItems(t *testing.T) {
+	from := "rules:\n  - verbs: [get]\n  - verbs: [list]\n    extra: 1\n  - verbs: [watch]\n"
+	to := "rules:\n  - verbs: [get]\n    groups: [apps]\n  - verbs: [list]\n"
+	got := roundTrip(t, from, to, "json")
+	want := "rules:\n  - verbs: [get]\n    groups:\n      - apps\n  - verbs: [list]\n"
+	if got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseDisplayPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want DiffPath
+	}{
+		{"", nil},
+		{"a.b.0", DiffPath{"a", "b", "0"}},
+		{"[1].spec", DiffPath{"[1]", "spec"}},
+		{"metadata.labels[app.kubernetes.io/name]", DiffPath{"metadata", "labels", "app.kubernetes.io/name"}},
+		{"a[b.c].d", DiffPath{"a", "b.c", "d"}},
+	}
+	for _, tt := range tests {
+		got := parseDisplayPath(tt.in)
+		if got.String() != tt.want.String() || len(got) != len(tt.want) {
+			t.Errorf("parseDisplayPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if tt.in != "" && got.String() != tt.in {
+			t.Errorf("parseDisplayPath(%q).String() = %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestDetectCompactSequences(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"a:\n- 1\n", true},
+		{"a:\n  - 1\n", false},
+		{"a:\n  b:\n  - 1\n", true},
+		{"a: 1\n", false},
+	}
+	for _, tt := range tests {
+		if got := detectCompactSequences([]byte(tt.in)); got != tt.want {
+			t.Errorf("detectCompactSequences(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
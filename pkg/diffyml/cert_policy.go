@@ -0,0 +1,233 @@
+// cert_policy.go - Certificate policy checks (Options.CertPolicy).
+//
+// Every X.509 certificate in the to document — a PEM value, or a Secret's
+// base64-encoded one — is checked against a CertPolicy, whether or not it
+// changed: certificates that have expired or expire within the warning
+// window, that use a weak key or a SHA-1 or MD5 signature, or that are
+// self-signed where the from document's certificate at the same path was
+// not. Each finding is reported as a DiffWarning at the value's path.
+// Key types: CertPolicy. Key functions: certWarnings, ParseCertExpiryWarning.
+package diffyml
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CertPolicy configures the certificate checks of Options.CertPolicy.
+type CertPolicy struct {
+	// ExpiryWarning is the window before expiry in which a certificate is
+	// reported as expiring. Expired certificates are reported regardless.
+	ExpiryWarning time.Duration
+	// Now is the time certificates are checked at; the zero value means
+	// the current time.
+	Now time.Time
+}
+
+// Minimum key sizes below which a certificate's key is reported as weak.
+const (
+	minRSAKeyBits   = 2048
+	minECDSAKeyBits = 256
+)
+
+// base64PEMPrefix is "-----BEGIN" base64-encoded, the start of a PEM value
+// stored base64-encoded, as in a Secret's data or a webhook's caBundle.
+const base64PEMPrefix = "LS0tLS1CRUdJTi"
+
+// ParseCertExpiryWarning parses a certificate expiry warning window: a number
+// of days such as "30d", or a Go duration such as "72h".
+func ParseCertExpiryWarning(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid certificate expiry warning %q: want a number of days such as 30d, or a duration such as 72h", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid certificate expiry warning %q: want a number of days such as 30d, or a duration such as 72h", s)
+	}
+	return d, nil
+}
+
+// certWarnings checks the certificates in the to document toN against
+// opts.CertPolicy, returning a DiffWarning for each finding. fromN is the
+// matching from document, nil when toN was added; it is consulted only to
+// tell whether a self-signed certificate was self-signed before. No checks
+// run without a policy or in inverse mode.
+func certWarnings(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
+	if opts.CertPolicy == nil || opts.Unchanged {
+		return nil
+	}
+	now := opts.CertPolicy.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return certWarningsAt(path, fromN, toN, opts, now)
+}
+
+// certWarningsAt walks toN, naming map entries by key and list items as the
+// comparator does: by identifier when the list is matched by one, else by
+// index. Aliases are not followed; their anchor is checked where it is
+// defined.
+func certWarningsAt(path DiffPath, fromN, toN *yaml.Node, opts *Options, now time.Time) []Difference {
+	if toN != nil && toN.Kind == yaml.DocumentNode {
+		toN = resolveNode(toN)
+	}
+	if fromN != nil && fromN.Kind == yaml.DocumentNode {
+		fromN = resolveNode(fromN)
+	}
+	if toN == nil {
+		return nil
+	}
+	var diffs []Difference
+	switch toN.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(toN.Content); i += 2 {
+			key := toN.Content[i].Value
+			var fromChild *yaml.Node
+			if fromN != nil && fromN.Kind == yaml.MappingNode {
+				fromChild = lookupMappingValueNode(fromN, key)
+			}
+			diffs = append(diffs, certWarningsAt(path.Append(key), fromChild, toN.Content[i+1], opts, now)...)
+		}
+	case yaml.SequenceNode:
+		ids := identityAt(path, opts)
+		byID := ids.canMatch(toN.Content)
+		for i, item := range toN.Content {
+			seg := strconv.Itoa(i)
+			var fromItem *yaml.Node
+			if id := ids.of(item); byID && isComparableIdentifier(id) {
+				seg = sprintIdentifier(id)
+				if fromN != nil && fromN.Kind == yaml.SequenceNode {
+					for _, f := range fromN.Content {
+						if ids.of(f) == id {
+							fromItem = f
+							break
+						}
+					}
+				}
+			} else if fromN != nil && fromN.Kind == yaml.SequenceNode && i < len(fromN.Content) {
+				fromItem = fromN.Content[i]
+			}
+			diffs = append(diffs, certWarningsAt(path.Append(seg), fromItem, item, opts, now)...)
+		}
+	case yaml.ScalarNode:
+		certs := nodeCertificates(toN)
+		if certs == nil {
+			return nil
+		}
+		var fromLeaf *x509.Certificate
+		if fromCerts := nodeCertificates(fromN); fromCerts != nil {
+			fromLeaf = fromCerts[0]
+		}
+		for i, cert := range certs {
+			label := "certificate"
+			if len(certs) > 1 {
+				label += " " + strconv.Itoa(i)
+			}
+			if name := certName(cert.Subject.CommonName, cert.Subject.Organization, cert.DNSNames); name != "" {
+				label += " " + strconv.Quote(name)
+			}
+			for _, finding := range certFindings(cert, i == 0 && fromLeaf != nil && !isSelfSigned(fromLeaf), opts.CertPolicy.ExpiryWarning, now) {
+				diffs = append(diffs, Difference{
+					Path:    path,
+					Type:    DiffWarning,
+					ToPos:   nodePosition(toN),
+					Warning: label + " " + finding,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+// nodeCertificates returns the certificates held by a string scalar, as PEM
+// or as base64-encoded PEM, or nil when it holds none.
+func nodeCertificates(n *yaml.Node) []*x509.Certificate {
+	if n == nil || n.Kind != yaml.ScalarNode || n.ShortTag() != "!!str" {
+		return nil
+	}
+	value := strings.TrimSpace(n.Value)
+	if strings.HasPrefix(value, base64PEMPrefix) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		if err != nil {
+			return nil
+		}
+		value = string(decoded)
+	}
+	if !strings.HasPrefix(value, "-----BEGIN CERTIFICATE-----") {
+		return nil
+	}
+	return ParsePEMCertificates(value)
+}
+
+// certFindings returns what is wrong with cert, each as the rest of a
+// sentence naming it. checkSelfSigned reports a self-signed certificate.
+func certFindings(cert *x509.Certificate, checkSelfSigned bool, window time.Duration, now time.Time) []string {
+	var findings []string
+	notAfter := cert.NotAfter.UTC().Format("2006-01-02")
+	switch left := cert.NotAfter.Sub(now); {
+	case left < 0:
+		findings = append(findings, "expired on "+notAfter)
+	case left <= window:
+		findings = append(findings, fmt.Sprintf("expires on %s, in %d days", notAfter, int(left/(24*time.Hour))))
+	}
+	if key := weakKey(cert); key != "" {
+		findings = append(findings, "has a weak key: "+key)
+	}
+	if isWeakSignature(cert.SignatureAlgorithm) {
+		findings = append(findings, "has a weak signature: "+cert.SignatureAlgorithm.String())
+	}
+	if checkSelfSigned && isSelfSigned(cert) {
+		findings = append(findings, "is self-signed; the certificate it replaces was not")
+	}
+	return findings
+}
+
+// weakKey describes cert's public key when it is weak: RSA under 2048 bits,
+// ECDSA under 256, or DSA. Empty otherwise.
+func weakKey(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return fmt.Sprintf("RSA %d bits", key.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize < minECDSAKeyBits {
+			return "ECDSA " + key.Curve.Params().Name
+		}
+	}
+	if cert.PublicKeyAlgorithm == x509.DSA {
+		return "DSA"
+	}
+	return ""
+}
+
+// isWeakSignature reports whether alg hashes with SHA-1 or MD5.
+func isWeakSignature(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	}
+	return false
+}
+
+// isSelfSigned reports whether cert names itself as its issuer, and, when it
+// carries an authority key ID, that ID is its own.
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return len(cert.AuthorityKeyId) == 0 || bytes.Equal(cert.AuthorityKeyId, cert.SubjectKeyId)
+}
@@ -0,0 +1,254 @@
+package diffyml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var certPolicyNow = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// issuedCert returns template signed by ca's key as a PEM string.
+func issuedCert(t *testing.T, template, ca *x509.Certificate, key, caKey crypto.Signer) string {
+	t.Helper()
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}))
+}
+
+func rsaKey(t *testing.T, bits int) crypto.Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+// literalBlock renders s as a YAML literal block value at indent spaces.
+func literalBlock(s string, indent int) string {
+	pad := strings.Repeat(" ", indent)
+	return "|\n" + pad + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+pad)
+}
+
+// certWarningTexts runs Compare with a certificate policy and returns each
+// warning as "path: warning".
+func certWarningTexts(t *testing.T, from, to string, opts Options) []string {
+	t.Helper()
+	opts.CertPolicy = &CertPolicy{ExpiryWarning: 30 * 24 * time.Hour, Now: certPolicyNow}
+	diffs, err := Compare([]byte(from), []byte(to), &opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		if d.Type == DiffWarning {
+			got = append(got, d.Path.String()+": "+d.Warning)
+		}
+	}
+	return got
+}
+
+func TestCompare_CertPolicy(t *testing.T) {
+	caKey := ecdsaKey(t)
+	ca := certTemplate("Example CA", 1, "ca")
+	ca.IsCA = true
+	ca.BasicConstraintsValid = true
+	ca.KeyUsage = x509.KeyUsageCertSign
+
+	expired := certTemplate("expired.example.com", 2, "leaf")
+	expired.NotAfter = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	expiring := certTemplate("expiring.example.com", 3, "leaf")
+	expiring.NotAfter = time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	valid := certTemplate("valid.example.com", 4, "leaf")
+	issued := certTemplate("api.example.com", 5, "leaf")
+
+	rsaCAKey := rsaKey(t, 2048)
+	rsaCA := certTemplate("RSA CA", 6, "rsa-ca")
+	rsaCA.IsCA = true
+	rsaCA.BasicConstraintsValid = true
+	rsaCA.KeyUsage = x509.KeyUsageCertSign
+	sha1 := certTemplate("legacy.example.com", 7, "leaf")
+	sha1.SignatureAlgorithm = x509.SHA1WithRSA
+
+	selfSigned := certFromTemplate(t, issued, ecdsaKey(t))
+	issuedPEM := issuedCert(t, issued, ca, ecdsaKey(t), caKey)
+	caPEM := certFromTemplate(t, ca, caKey)
+
+	tests := []struct {
+		name     string
+		from, to string
+		opts     Options
+		want     []string
+	}{
+		{
+			name: "expired",
+			to:   "cert: " + literalBlock(certFromTemplate(t, expired, ecdsaKey(t)), 2),
+			want: []string{`cert: certificate "expired.example.com" expired on 2026-05-01`},
+		},
+		{
+			name: "expiring within the window",
+			to:   "cert: " + literalBlock(certFromTemplate(t, expiring, ecdsaKey(t)), 2),
+			want: []string{`cert: certificate "expiring.example.com" expires on 2026-06-15, in 14 days`},
+		},
+		{
+			name: "valid",
+			to:   "cert: " + literalBlock(certFromTemplate(t, valid, ecdsaKey(t)), 2),
+		},
+		{
+			name: "weak RSA key",
+			to:   "cert: " + literalBlock(certFromTemplate(t, valid, rsaKey(t, 1024)), 2),
+			want: []string{`cert: certificate "valid.example.com" has a weak key: RSA 1024 bits`},
+		},
+		{
+			name: "SHA-1 signature",
+			to:   "cert: " + literalBlock(issuedCert(t, sha1, rsaCA, ecdsaKey(t), rsaCAKey), 2),
+			want: []string{`cert: certificate "legacy.example.com" has a weak signature: SHA1-RSA`},
+		},
+		{
+			name: "self-signed SHA-1 signature",
+			to:   "cert: " + literalBlock(certFromTemplate(t, sha1, rsaKey(t, 2048)), 2),
+			want: []string{`cert: certificate "legacy.example.com" has a weak signature: SHA1-RSA`},
+		},
+		{
+			name: "newly self-signed",
+			from: "cert: " + literalBlock(issuedPEM, 2),
+			to:   "cert: " + literalBlock(selfSigned, 2),
+			want: []string{`cert: certificate "api.example.com" is self-signed; the certificate it replaces was not`},
+		},
+		{
+			name: "still self-signed",
+			from: "cert: " + literalBlock(certFromTemplate(t, issued, ecdsaKey(t)), 2),
+			to:   "cert: " + literalBlock(selfSigned, 2),
+		},
+		{
+			name: "bundle",
+			to:   "cert: " + literalBlock(issuedPEM+certFromTemplate(t, expired, ecdsaKey(t)), 2),
+			want: []string{`cert: certificate 1 "expired.example.com" expired on 2026-05-01`},
+		},
+		{
+			name: "CA bundle is not self-signed news",
+			from: "cert: " + literalBlock(issuedPEM, 2),
+			to:   "cert: " + literalBlock(issuedPEM+caPEM, 2),
+		},
+		{
+			name: "base64 Secret data",
+			to:   secretDoc("Secret", "data", "tls.crt", b64(certFromTemplate(t, expired, ecdsaKey(t)))),
+			opts: Options{DetectKubernetes: true},
+			want: []string{`data[tls.crt]: certificate "expired.example.com" expired on 2026-05-01`},
+		},
+		{
+			name: "list items named by identifier",
+			from: "listeners:\n- name: https\n  cert: " + literalBlock(issuedPEM, 4),
+			to:   "listeners:\n- name: https\n  cert: " + literalBlock(selfSigned, 4),
+			want: []string{`listeners.https.cert: certificate "api.example.com" is self-signed; the certificate it replaces was not`},
+		},
+		{
+			name: "not a certificate",
+			to:   "cert: " + literalBlock("-----BEGIN CERTIFICATE-----\nnot base64\n-----END CERTIFICATE-----", 2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := tt.from
+			if from == "" {
+				from = tt.to
+			}
+			got := certWarningTexts(t, from, tt.to, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_CertPolicyAddedDocument(t *testing.T) {
+	expired := certTemplate("expired.example.com", 2, "leaf")
+	expired.NotAfter = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	from := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n"
+	to := from + "---\n" + secretDoc("Secret", "data", "tls.crt", b64(certFromTemplate(t, expired, ecdsaKey(t))))
+	opts := Options{DetectKubernetes: true, CertPolicy: &CertPolicy{Now: certPolicyNow}}
+	diffs, err := Compare([]byte(from), []byte(to), &opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var warnings []Difference
+	for _, d := range diffs {
+		if d.Type == DiffWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("got %v, want one warning", setDiffs(diffs))
+	}
+	if w := warnings[0]; w.DocumentKind != "Secret" || !strings.HasSuffix(w.DocumentName, "/app") {
+		t.Errorf("warning document = %s %s, want the Secret app", w.DocumentKind, w.DocumentName)
+	}
+}
+
+func TestCompare_CertPolicyOff(t *testing.T) {
+	expired := certTemplate("expired.example.com", 2, "leaf")
+	expired.NotAfter = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	doc := []byte("cert: " + literalBlock(certFromTemplate(t, expired, ecdsaKey(t)), 2))
+	for name, opts := range map[string]*Options{
+		"no policy": {},
+		"unchanged": {Unchanged: true, CertPolicy: &CertPolicy{Now: certPolicyNow}},
+	} {
+		diffs, err := Compare(doc, doc, opts)
+		if err != nil {
+			t.Fatalf("%s: Compare failed: %v", name, err)
+		}
+		for _, d := range diffs {
+			if d.Type == DiffWarning {
+				t.Errorf("%s: unexpected warning %q", name, d.Warning)
+			}
+		}
+	}
+}
+
+func TestParseCertExpiryWarning(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "0d", want: 0},
+		{in: "72h", want: 72 * time.Hour},
+		{in: "", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "-5h", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCertExpiryWarning(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCertExpiryWarning(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCompactFormatter_Warning(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"spec", "replicas"}, Type: DiffModified, From: 1, To: 2},
+		{Path: DiffPath{"data", "tls.crt"}, Type: DiffWarning, Warning: `certificate "api.example.com" expired on 2026-05-01`},
+	}
+	out := (&CompactFormatter{}).Format(diffs, &FormatOptions{})
+	for _, want := range []string{
+		"Found 1 difference(s) (0 removed, 0 added, 1 modified, 1 warning(s))",
+		`⚠ data[tls.crt] : certificate "api.example.com" expired on 2026-05-01`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
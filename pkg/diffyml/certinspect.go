@@ -1,10 +1,20 @@
 package diffyml
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
 )
 
 // IsPEMCertificate checks if a string is a PEM-encoded X.509 certificate.
@@ -56,6 +66,201 @@ func FormatCertificate(s string) string {
 	)
 }
 
+// ParsePEMCertificates parses a PEM bundle of one or more X.509
+// certificates, such as a chain or a CA bundle, in order. Returns nil unless
+// the trimmed string holds only CERTIFICATE blocks that all parse.
+func ParsePEMCertificates(s string) []*x509.Certificate {
+	rest := []byte(strings.TrimSpace(s))
+	var certs []*x509.Certificate
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		certs = append(certs, cert)
+		rest = []byte(strings.TrimSpace(string(rest)))
+	}
+	return certs
+}
+
+// CompareCertificates compares two PEM certificates or bundles field by
+// field: subject, issuer, serial, validity, subject alternative names, key
+// algorithm and size, subject key ID and SHA-256 fingerprint. Differences
+// carry paths relative to the value, such as "notAfter", or "sans" for each
+// name added or removed. Two single certificates are compared with each
+// other; the certificates of a bundle are matched by subject key ID, then by
+// subject, and prefixed with their index in the bundle ("1.notAfter"), with
+// unmatched ones added or removed as a whole, as their one-line summary. ok is
+// false unless both sides parse (see ParsePEMCertificates) and differ in at
+// least one field.
+func CompareCertificates(from, to string) (diffs []Difference, ok bool) {
+	fromCerts, toCerts := ParsePEMCertificates(from), ParsePEMCertificates(to)
+	if fromCerts == nil || toCerts == nil {
+		return nil, false
+	}
+	if len(fromCerts) == 1 && len(toCerts) == 1 {
+		diffs = compareCertFields(nil, fromCerts[0], toCerts[0])
+		return diffs, len(diffs) > 0
+	}
+
+	pairs := matchCertificates(fromCerts, toCerts)
+	matched := make(map[int]bool, len(pairs))
+	for _, p := range pairs {
+		matched[p.from] = true
+	}
+	for i, cert := range fromCerts {
+		if !matched[i] {
+			diffs = append(diffs, Difference{Path: DiffPath{strconv.Itoa(i)}, Type: DiffRemoved, From: FormatCertificate(certPEM(cert))})
+		}
+	}
+	paired := make(map[int]int, len(pairs))
+	for _, p := range pairs {
+		paired[p.to] = p.from
+	}
+	for j, cert := range toCerts {
+		i, ok := paired[j]
+		if !ok {
+			diffs = append(diffs, Difference{Path: DiffPath{strconv.Itoa(j)}, Type: DiffAdded, To: FormatCertificate(certPEM(cert))})
+			continue
+		}
+		diffs = append(diffs, compareCertFields(DiffPath{strconv.Itoa(j)}, fromCerts[i], cert)...)
+	}
+	return diffs, len(diffs) > 0
+}
+
+// matchCertificates pairs the certificates of two bundles: first those with
+// the same subject key ID, then those left with the same subject, each in
+// bundle order.
+func matchCertificates(from, to []*x509.Certificate) []itemPair {
+	var pairs []itemPair
+	usedFrom := make([]bool, len(from))
+	usedTo := make([]bool, len(to))
+	for _, same := range []func(a, b *x509.Certificate) bool{
+		func(a, b *x509.Certificate) bool {
+			return len(a.SubjectKeyId) > 0 && slices.Equal(a.SubjectKeyId, b.SubjectKeyId)
+		},
+		func(a, b *x509.Certificate) bool { return a.Subject.String() == b.Subject.String() },
+	} {
+		for i, a := range from {
+			for j, b := range to {
+				if !usedFrom[i] && !usedTo[j] && same(a, b) {
+					pairs = append(pairs, itemPair{i, j})
+					usedFrom[i], usedTo[j] = true, true
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+// compareCertFields compares two certificates' fields below path. Subject
+// alternative names are compared as a set.
+func compareCertFields(path DiffPath, from, to *x509.Certificate) []Difference {
+	return compareNodes(path, certFieldsNode(from), certFieldsNode(to), &Options{SetLists: true})
+}
+
+// certFieldsNode returns the compared fields of cert as a mapping node.
+func certFieldsNode(cert *x509.Certificate) *yaml.Node {
+	algorithm, size := certKey(cert)
+	fingerprint := sha256.Sum256(cert.Raw)
+	fields := []struct {
+		key   string
+		value *yaml.Node
+	}{
+		{"subject", certScalar("!!str", cert.Subject.String())},
+		{"issuer", certScalar("!!str", cert.Issuer.String())},
+		{"serial", certScalar("!!str", cert.SerialNumber.Text(16))},
+		{"notBefore", certScalar("!!str", cert.NotBefore.UTC().Format(time.RFC3339))},
+		{"notAfter", certScalar("!!str", cert.NotAfter.UTC().Format(time.RFC3339))},
+		{"sans", certSANsNode(cert)},
+		{"keyAlgorithm", certScalar("!!str", algorithm)},
+		{"keySize", certScalar("!!int", strconv.Itoa(size))},
+		{"subjectKeyId", certScalar("!!str", hex.EncodeToString(cert.SubjectKeyId))},
+		{"fingerprint", certScalar("!!str", "sha256:"+hex.EncodeToString(fingerprint[:]))},
+	}
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, f := range fields {
+		n.Content = append(n.Content, certScalar("!!str", f.key), f.value)
+	}
+	return n
+}
+
+// certSANsNode returns the subject alternative names of cert as a sorted
+// list, each prefixed with its kind as OpenSSL prints them: "DNS:example.com",
+// "IP:10.0.0.1", "email:ops@example.com", "URI:spiffe://cluster/ns/app".
+func certSANsNode(cert *x509.Certificate) *yaml.Node {
+	var sans []string
+	for _, name := range cert.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+	slices.Sort(sans)
+	n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, san := range sans {
+		n.Content = append(n.Content, certScalar("!!str", san))
+	}
+	return n
+}
+
+// certKey returns the public key algorithm of cert and its size in bits.
+func certKey(cert *x509.Certificate) (algorithm string, size int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name, key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
+	}
+}
+
+func certScalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// certPEM re-encodes cert as a PEM block.
+func certPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// certChangeLine renders a difference from CompareCertificates on one line:
+// "± notAfter: 2026-01-01T00:00:00Z → 2027-01-01T00:00:00Z", "+ sans:
+// DNS:api.example.com".
+func certChangeLine(d Difference) string {
+	switch d.Type {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %v", d.Path, d.To)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %v", d.Path, d.From)
+	default:
+		return fmt.Sprintf("± %s: %v → %v", d.Path, d.From, d.To)
+	}
+}
+
+// certKind names what CompareCertificates compared: "certificate bundle"
+// when either side holds more than one certificate, else "certificate".
+func certKind(from, to string) string {
+	if len(ParsePEMCertificates(from)) > 1 || len(ParsePEMCertificates(to)) > 1 {
+		return "certificate bundle"
+	}
+	return "certificate"
+}
+
 // certName returns the common name, falling back to the first organization
 // or first DNS name if the common name is empty.
 func certName(commonName string, orgs []string, dnsNames []string) string {
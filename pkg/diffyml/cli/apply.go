@@ -0,0 +1,44 @@
+// apply.go - Patch mode ("diffyml apply <file> <patch>").
+//
+// Wraps diffyml.ApplyPatch for the command line, so that a JSON Patch or
+// the output of "-o json" can be replayed onto a YAML file without losing
+// its comments and formatting.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/szhekpisov/diffyml/pkg/diffyml"
+)
+
+// runApply applies PatchFile to SourceFile and writes the result to
+// ApplyOutput, or to stdout when unset. Either file may be "-" for stdin.
+func runApply(cfg *CLIConfig, rc *RunConfig) *ExitResult {
+	fail := func(err error) *ExitResult {
+		fmt.Fprintf(rc.Stderr, "Error: %v\n", err)
+		return NewExitResult(ExitCodeError, err)
+	}
+
+	source, err := loadSource(cfg.SourceFile, rc)
+	if err != nil {
+		return fail(err)
+	}
+	patch, err := loadSource(cfg.PatchFile, rc)
+	if err != nil {
+		return fail(err)
+	}
+
+	patched, err := diffyml.ApplyPatch(source, patch, &diffyml.Options{
+		DetectKubernetes:      cfg.DetectKubernetes,
+		AdditionalIdentifiers: cfg.AdditionalIdentifiers,
+		ListKeys:              cfg.ListKeys,
+		Schema:                cfg.schema,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	if err := writeYAMLOutput(cfg.ApplyOutput, patched, rc); err != nil {
+		return fail(fmt.Errorf("failed to write apply output: %w", err))
+	}
+	return NewExitResult(ExitCodeSuccess, nil)
+}
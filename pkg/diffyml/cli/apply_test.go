@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLIConfig_ParseArgs_Apply(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"apply", "--apply-output", "out.yaml", "app.yaml", "-"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Apply || cfg.SourceFile != "app.yaml" || cfg.PatchFile != "-" || cfg.ApplyOutput != "out.yaml" {
+		t.Errorf("unexpected apply config: %+v", cfg)
+	}
+}
+
+func TestCLIConfig_ParseArgs_ApplyErrors(t *testing.T) {
+	for _, args := range [][]string{{"apply", "app.yaml"}, {"apply", "-", "-"}} {
+		if err := NewCLIConfig().ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs(%q): expected an error", args)
+		}
+	}
+}
+
+func TestRunApply_PatchFromStdin(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "app.yaml", "# app\nreplicas: 1 # scaled by HPA\nimage: nginx\n")
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"apply", filepath.Join(dir, "app.yaml"), "-"}); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.Stdin = strings.NewReader(`[{"op": "replace", "path": "/replicas", "value": 3}]`)
+
+	if result := Run(cfg, rc); result.Code != ExitCodeSuccess {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", ExitCodeSuccess, result.Code, stderr.String())
+	}
+	if want := "# app\nreplicas: 3 # scaled by HPA\nimage: nginx\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+// TestRunApply_DiffOutputInPlace replays "diffyml -o json" output over the
+// source file itself.
+func TestRunApply_DiffOutputInPlace(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "old.yaml", "env:\n  - name: A # first\n    value: 1\n")
+	createFile(t, dir, "new.yaml", "env:\n  - name: A\n    value: 2\n  - name: B\n")
+
+	diffCfg := NewCLIConfig()
+	diffCfg.Output = "json"
+	if err := diffCfg.ParseArgs([]string{filepath.Join(dir, "old.yaml"), filepath.Join(dir, "new.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+	diffRC := NewRunConfig()
+	var patch, discard strings.Builder
+	diffRC.Stdout = &patch
+	diffRC.Stderr = &discard
+	Run(diffCfg, diffRC)
+	createFile(t, dir, "patch.json", patch.String())
+
+	old := filepath.Join(dir, "old.yaml")
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"apply", "--apply-output", old, old, filepath.Join(dir, "patch.json")}); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	if result := Run(cfg, rc); result.Code != ExitCodeSuccess {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", ExitCodeSuccess, result.Code, stderr.String())
+	}
+	data, err := os.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "env:\n  - name: A # first\n    value: 2\n  - name: B\n"; string(data) != want {
+		t.Errorf("patched file = %q, want %q", data, want)
+	}
+}
+
+func TestRunApply_FailedPatch(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "app.yaml", "a: 1\n")
+	createFile(t, dir, "patch.json", `[{"op": "remove", "path": "/b"}]`)
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"apply", filepath.Join(dir, "app.yaml"), filepath.Join(dir, "patch.json")}); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	if result := Run(cfg, rc); result.Code != ExitCodeError {
+		t.Errorf("expected exit code %d, got %d", ExitCodeError, result.Code)
+	}
+	if !containsSubstr(stderr.String(), `Error: remove "/b": key "b" not found`) {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output, got %q", stdout.String())
+	}
+}
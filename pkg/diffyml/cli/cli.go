@@ -2,7 +2,8 @@
 //
 // Key types: CLIConfig (all CLI options), RunConfig (runtime IO), ExitResult.
 // Key functions: Run() executes the full comparison flow.
-// Exit codes: 0=success, 1=differences (with -s), 255=error.
+// Exit codes: 0=success, 1=differences (with -s), 2=certificate warnings
+// (with -s), 255=error.
 package cli
 
 import (
@@ -26,7 +27,7 @@ type CLIConfig struct {
 	ToFile   string
 
 	// Output options
-	Output    string // compact, brief, github, gitlab, gitea, json, json-patch, detailed
+	Output    string // compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed
 	Color     string // always, never, auto
 	TrueColor string // always, never, auto
 
@@ -34,19 +35,33 @@ type CLIConfig struct {
 	OmitHeader            bool
 	UseGoPatchStyle       bool
 	MultiLineContextLines int
+	Width                 int
+	FoldUnchanged         int
 
 	// Comparison options
 	IgnoreOrderChanges      bool
 	IgnoreWhitespaceChanges bool
 	FormatStrings           bool
+	DescendEmbedded         bool
+	DecodeBase64            bool
+	CompareComments         bool
+	NormalizeQuantities     bool
+	QuantityPaths           []string
+	DurationPaths           []string
+	SetLists                bool
+	SetListPaths            []string
 	IgnoreValueChanges      bool
 	DetectKubernetes        bool
 	DetectRenames           bool
+	DetectMoves             bool
 	IgnoreApiVersion        bool
 	NoCertInspection        bool
+	CertExpiryWarning       string // --cert-expiry-warning: "30d" or a Go duration; "" disables the checks
 	Swap                    bool
 	Unchanged               bool
 	AdditionalIdentifiers   []string
+	ListKeys                []diffyml.ListKey
+	SchemaFiles             []string
 
 	// Filtering options
 	Filter        []string
@@ -74,11 +89,25 @@ type CLIConfig struct {
 	ChrootFrom            string
 	ChrootTo              string
 	ChrootListToDocuments bool
+	PathMappings          []diffyml.PathMapping
 
 	// AI Summary options
 	Summary      bool   // --summary / -S: enable AI summary
 	SummaryModel string // --summary-model: Anthropic model override
 
+	// Merge mode ("diffyml merge <base> <ours> <theirs>")
+	Merge       bool   // true when the first argument is "merge"
+	BaseFile    string // common ancestor of ours and theirs
+	OursFile    string
+	TheirsFile  string
+	MergeOutput string // --merge-output: file to write the merged YAML to
+
+	// Apply mode ("diffyml apply <file> <patch>")
+	Apply       bool   // true when the first argument is "apply"
+	SourceFile  string // YAML file the patch applies to
+	PatchFile   string // JSON Patch or diffyml JSON output
+	ApplyOutput string // --apply-output: file to write the patched YAML to
+
 	// Git external diff mode
 	GitExternalDiff bool   // true when 7-arg GIT_EXTERNAL_DIFF convention detected
 	GitDisplayPath  string // repo-relative path for display headers (rename-to when renamed)
@@ -96,6 +125,9 @@ type CLIConfig struct {
 
 	// Internal flagset
 	fs *flag.FlagSet
+
+	// schema is read from SchemaFiles when Run starts.
+	schema *diffyml.Schema
 }
 
 // NewCLIConfig creates a new CLI configuration with default values.
@@ -119,7 +151,7 @@ func (c *CLIConfig) initFlags() {
 
 	// Output options
 	c.fs.StringVar(&c.Output, "o", c.Output, "")
-	c.fs.StringVar(&c.Output, "output", c.Output, "specify the output style: compact, brief, github, gitlab, gitea, json, json-patch, detailed")
+	c.fs.StringVar(&c.Output, "output", c.Output, "specify the output style: compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed")
 	c.fs.StringVar(&c.Color, "c", c.Color, "")
 	c.fs.StringVar(&c.Color, "color", c.Color, "specify color usage: always, never, or auto")
 	c.fs.StringVar(&c.TrueColor, "t", c.TrueColor, "")
@@ -131,19 +163,40 @@ func (c *CLIConfig) initFlags() {
 	c.fs.BoolVar(&c.UseGoPatchStyle, "g", c.UseGoPatchStyle, "")
 	c.fs.BoolVar(&c.UseGoPatchStyle, "use-go-patch-style", c.UseGoPatchStyle, "use Go-Patch style paths in outputs")
 	c.fs.IntVar(&c.MultiLineContextLines, "multi-line-context-lines", c.MultiLineContextLines, "multi-line context lines")
+	c.fs.IntVar(&c.Width, "width", c.Width, "output width in columns for side-by-side output (default: terminal width)")
+	c.fs.IntVar(&c.FoldUnchanged, "fold-unchanged", c.FoldUnchanged, "fold unchanged subtrees longer than N lines in annotated output (0: never)")
 
 	// Comparison options
 	c.fs.BoolVar(&c.IgnoreOrderChanges, "i", c.IgnoreOrderChanges, "")
 	c.fs.BoolVar(&c.IgnoreOrderChanges, "ignore-order-changes", c.IgnoreOrderChanges, "ignore order changes in lists")
 	c.fs.BoolVar(&c.IgnoreWhitespaceChanges, "ignore-whitespace-changes", c.IgnoreWhitespaceChanges, "ignore leading or trailing whitespace changes")
 	c.fs.BoolVar(&c.FormatStrings, "format-strings", c.FormatStrings, "canonicalize embedded JSON strings before comparison")
+	c.fs.BoolVar(&c.DescendEmbedded, "descend-embedded", c.DescendEmbedded, "compare strings embedding YAML or JSON documents structurally")
+	c.fs.BoolVar(&c.DecodeBase64, "decode-base64", c.DecodeBase64, "compare Secret data, ConfigMap binaryData and !!binary values decoded")
+	c.fs.BoolVar(&c.CompareComments, "compare-comments", c.CompareComments, "report changed YAML comments")
+	c.fs.BoolVar(&c.NormalizeQuantities, "normalize-quantities", c.NormalizeQuantities, "compare Kubernetes quantities and durations by value")
+	c.fs.Func("quantity-path", "additional path holding Kubernetes quantities (dot-notation, prefix match)", func(s string) error {
+		c.QuantityPaths = append(c.QuantityPaths, s)
+		return nil
+	})
+	c.fs.Func("duration-path", "additional path holding durations (dot-notation, prefix match)", func(s string) error {
+		c.DurationPaths = append(c.DurationPaths, s)
+		return nil
+	})
+	c.fs.BoolVar(&c.SetLists, "set-lists", c.SetLists, "compare lists of scalars as sets, reporting added and removed elements")
+	c.fs.Func("set-list", "compare the scalar lists at PATH as sets (* matches any segment)", func(s string) error {
+		c.SetListPaths = append(c.SetListPaths, s)
+		return nil
+	})
 	c.fs.BoolVar(&c.IgnoreValueChanges, "v", c.IgnoreValueChanges, "")
 	c.fs.BoolVar(&c.IgnoreValueChanges, "ignore-value-changes", c.IgnoreValueChanges, "exclude changes in values")
 	c.fs.BoolVar(&c.DetectKubernetes, "detect-kubernetes", c.DetectKubernetes, "detect kubernetes entities")
 	c.fs.BoolVar(&c.DetectRenames, "detect-renames", c.DetectRenames, "enable detection for renames")
+	c.fs.BoolVar(&c.DetectMoves, "detect-moves", c.DetectMoves, "report subtrees moved or renamed within a document as moves")
 	c.fs.BoolVar(&c.IgnoreApiVersion, "ignore-api-version", c.IgnoreApiVersion, "ignore apiVersion when matching Kubernetes resources")
 	c.fs.BoolVar(&c.NoCertInspection, "x", c.NoCertInspection, "")
 	c.fs.BoolVar(&c.NoCertInspection, "no-cert-inspection", c.NoCertInspection, "disable x509 certificate inspection")
+	c.fs.StringVar(&c.CertExpiryWarning, "cert-expiry-warning", c.CertExpiryWarning, "warn about certificates expiring within this window (e.g. 30d), expired, weak or newly self-signed")
 	c.fs.BoolVar(&c.Swap, "swap", c.Swap, "swap 'from' and 'to' for comparison")
 	c.fs.BoolVar(&c.Unchanged, "u", c.Unchanged, "")
 	c.fs.BoolVar(&c.Unchanged, "unchanged", c.Unchanged, "report keys equal between both files (inverse diff)")
@@ -169,6 +222,18 @@ func (c *CLIConfig) initFlags() {
 		c.AdditionalIdentifiers = append(c.AdditionalIdentifiers, s)
 		return nil
 	})
+	c.fs.Func("list-key", "identify the items of lists at PATH by FIELDs ([KIND:]PATH=FIELD[,FIELD...])", func(s string) error {
+		key, err := diffyml.ParseListKey(s)
+		if err != nil {
+			return err
+		}
+		c.ListKeys = append(c.ListKeys, key)
+		return nil
+	})
+	c.fs.Func("schema", "read list types from CRD manifests or an OpenAPI v3 document", func(s string) error {
+		c.SchemaFiles = append(c.SchemaFiles, s)
+		return nil
+	})
 
 	// Neat options
 	c.fs.BoolVar(&c.Neat, "neat", c.Neat, "exclude well-known noisy K8s/Helm/ArgoCD/Flux paths")
@@ -199,6 +264,20 @@ func (c *CLIConfig) initFlags() {
 	c.fs.StringVar(&c.ChrootFrom, "chroot-of-from", c.ChrootFrom, "only change the root level of the from input file")
 	c.fs.StringVar(&c.ChrootTo, "chroot-of-to", c.ChrootTo, "only change the root level of the to input file")
 	c.fs.BoolVar(&c.ChrootListToDocuments, "chroot-list-to-documents", c.ChrootListToDocuments, "treat chroot list as set of documents")
+	c.fs.Func("map-path", "compare the from file's entry at OLD as if it were at NEW (OLD=NEW)", func(s string) error {
+		m, err := diffyml.ParsePathMapping(s)
+		if err != nil {
+			return err
+		}
+		c.PathMappings = append(c.PathMappings, m)
+		return nil
+	})
+
+	// Merge options
+	c.fs.StringVar(&c.MergeOutput, "merge-output", c.MergeOutput, "write the merged YAML to this file instead of stdout (merge mode)")
+
+	// Apply options
+	c.fs.StringVar(&c.ApplyOutput, "apply-output", c.ApplyOutput, "write the patched YAML to this file instead of stdout (apply mode)")
 
 	// AI Summary options
 	c.fs.BoolVar(&c.Summary, "S", c.Summary, "")
@@ -277,6 +356,29 @@ func (c *CLIConfig) ParseArgs(args []string) error {
 		return nil
 	}
 
+	// Merge mode: "merge <base> <ours> <theirs>".
+	if len(remaining) > 0 && remaining[0] == "merge" {
+		if len(remaining) != 4 {
+			return fmt.Errorf("merge requires three file arguments: <base> <ours> <theirs>")
+		}
+		c.Merge = true
+		c.BaseFile, c.OursFile, c.TheirsFile = remaining[1], remaining[2], remaining[3]
+		return nil
+	}
+
+	// Apply mode: "apply <file> <patch>".
+	if len(remaining) > 0 && remaining[0] == "apply" {
+		if len(remaining) != 3 {
+			return fmt.Errorf("apply requires two file arguments: <file> <patch>")
+		}
+		if remaining[1] == "-" && remaining[2] == "-" {
+			return fmt.Errorf("cannot read both <file> and <patch> from stdin")
+		}
+		c.Apply = true
+		c.SourceFile, c.PatchFile = remaining[1], remaining[2]
+		return nil
+	}
+
 	if len(remaining) < 2 {
 		return fmt.Errorf("requires two file arguments: <from> <to>")
 	}
@@ -347,19 +449,45 @@ func (c *CLIConfig) ToCompareOptions() *diffyml.Options {
 		IgnoreOrderChanges:      c.IgnoreOrderChanges,
 		IgnoreWhitespaceChanges: c.IgnoreWhitespaceChanges,
 		FormatStrings:           c.FormatStrings,
+		DescendEmbedded:         c.DescendEmbedded,
+		DecodeBase64:            c.DecodeBase64,
+		CompareComments:         c.CompareComments,
+		NormalizeQuantities:     c.NormalizeQuantities,
+		QuantityPaths:           c.QuantityPaths,
+		DurationPaths:           c.DurationPaths,
+		SetLists:                c.SetLists,
+		SetListPaths:            c.SetListPaths,
 		IgnoreValueChanges:      c.IgnoreValueChanges,
 		DetectKubernetes:        c.DetectKubernetes,
 		DetectRenames:           c.DetectRenames,
+		DetectMoves:             c.DetectMoves,
 		IgnoreApiVersion:        c.IgnoreApiVersion,
 		AdditionalIdentifiers:   c.AdditionalIdentifiers,
+		ListKeys:                c.ListKeys,
+		Schema:                  c.schema,
 		NoCertInspection:        c.NoCertInspection,
+		CertPolicy:              c.certPolicy(),
 		Swap:                    c.Swap,
 		Unchanged:               c.Unchanged,
 		Chroot:                  c.Chroot,
 		ChrootFrom:              c.ChrootFrom,
 		ChrootTo:                c.ChrootTo,
 		ChrootListToDocuments:   c.ChrootListToDocuments,
+		PathMappings:            c.PathMappings,
+	}
+}
+
+// certPolicy returns the certificate policy --cert-expiry-warning enables, or
+// nil without it. Validate has checked the window already.
+func (c *CLIConfig) certPolicy() *diffyml.CertPolicy {
+	if c.CertExpiryWarning == "" {
+		return nil
+	}
+	window, err := diffyml.ParseCertExpiryWarning(c.CertExpiryWarning)
+	if err != nil {
+		return nil
 	}
+	return &diffyml.CertPolicy{ExpiryWarning: window}
 }
 
 // ToFilterOptions converts CLI config to FilterOptions.
@@ -379,6 +507,8 @@ func (c *CLIConfig) ToFilterOptions() *diffyml.FilterOptions {
 		IncludeRegexp:         c.FilterRegexp,
 		ExcludeRegexp:         excludeRegexp,
 		AdditionalIdentifiers: c.AdditionalIdentifiers,
+		ListKeys:              c.ListKeys,
+		Schema:                c.schema,
 	}
 }
 
@@ -402,11 +532,36 @@ func (c *CLIConfig) ToMaskOptions() diffyml.MaskOptions {
 		MaskPathRegexp:        c.MaskPathRegexp,
 		Placeholder:           c.MaskPlaceholder,
 		AdditionalIdentifiers: c.AdditionalIdentifiers,
+		ListKeys:              c.ListKeys,
+		Schema:                c.schema,
+	}
+}
+
+// loadSchema reads the --schema files into the schema the comparison
+// options carry.
+func (c *CLIConfig) loadSchema() error {
+	if len(c.SchemaFiles) == 0 {
+		return nil
+	}
+	sources := make([][]byte, len(c.SchemaFiles))
+	for i, path := range c.SchemaFiles {
+		data, err := diffyml.LoadContent(path)
+		if err != nil {
+			return fmt.Errorf("failed to load schema: %w", err)
+		}
+		sources[i] = data
+	}
+	schema, err := diffyml.ParseSchema(sources...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(c.SchemaFiles, ", "), err)
 	}
+	c.schema = schema
+	return nil
 }
 
 // ToFormatOptions converts CLI config to FormatOptions.
 func (c *CLIConfig) ToFormatOptions() *diffyml.FormatOptions {
+	mask := c.ToMaskOptions()
 	return &diffyml.FormatOptions{
 		OmitHeader:       c.OmitHeader,
 		UseGoPatchStyle:  c.UseGoPatchStyle,
@@ -414,6 +569,10 @@ func (c *CLIConfig) ToFormatOptions() *diffyml.FormatOptions {
 		NoCertInspection: c.NoCertInspection,
 		Unchanged:        c.Unchanged,
 		Palette:          c.Palette,
+		Width:            c.Width,
+		Mask:             &mask,
+		Filter:           c.ToFilterOptions(),
+		FoldUnchanged:    c.FoldUnchanged,
 	}
 }
 
@@ -423,11 +582,14 @@ func (c *CLIConfig) Usage() string {
 
 	sb.WriteString("diffyml - A diff tool for YAML files\n\n")
 	sb.WriteString("Usage:\n")
-	sb.WriteString("  diffyml [flags] <from> <to>\n\n")
+	sb.WriteString("  diffyml [flags] <from> <to>\n")
+	sb.WriteString("  diffyml merge [flags] <base> <ours> <theirs>\n")
+	sb.WriteString("  diffyml apply [flags] <file> <patch>\n\n")
+	sb.WriteString("  <from> and <to> are files, directories, or http(s) URLs; use - for stdin (one side only).\n\n")
 	sb.WriteString("Flags:\n")
 
 	// Output options
-	sb.WriteString("  -o, --output string                 specify output style: compact, brief, github, gitlab, gitea, json, json-patch, detailed (default \"detailed\")\n")
+	sb.WriteString("  -o, --output string                 specify output style: compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed (default \"detailed\")\n")
 	sb.WriteString("  -c, --color string                  specify color usage: always, never, or auto (default \"auto\")\n")
 	sb.WriteString("  -t, --truecolor string              specify true color usage: always, never, or auto (default \"auto\")\n")
 	sb.WriteString("\n")
@@ -436,11 +598,21 @@ func (c *CLIConfig) Usage() string {
 	sb.WriteString("  -i, --ignore-order-changes          ignore order changes in lists\n")
 	sb.WriteString("      --ignore-whitespace-changes     ignore leading or trailing whitespace changes\n")
 	sb.WriteString("      --format-strings                canonicalize embedded JSON strings before comparison\n")
+	sb.WriteString("      --descend-embedded              compare strings embedding YAML or JSON documents structurally\n")
+	sb.WriteString("      --decode-base64                 compare Secret data, ConfigMap binaryData and !!binary values decoded\n")
+	sb.WriteString("      --compare-comments              report changed YAML comments\n")
+	sb.WriteString("      --normalize-quantities          compare Kubernetes quantities and durations by value\n")
+	sb.WriteString("      --quantity-path strings         additional path holding Kubernetes quantities\n")
+	sb.WriteString("      --duration-path strings         additional path holding durations\n")
+	sb.WriteString("      --set-lists                     compare lists of scalars as sets, reporting added and removed elements\n")
+	sb.WriteString("      --set-list strings              compare the scalar lists at PATH as sets (* matches any segment)\n")
 	sb.WriteString("  -v, --ignore-value-changes          exclude changes in values\n")
 	sb.WriteString("      --detect-kubernetes             detect kubernetes entities (default true)\n")
 	sb.WriteString("      --detect-renames                enable detection for renames (default true)\n")
+	sb.WriteString("      --detect-moves                  report subtrees moved or renamed within a document as moves\n")
 	sb.WriteString("      --ignore-api-version            ignore apiVersion when matching Kubernetes resources\n")
 	sb.WriteString("  -x, --no-cert-inspection            disable x509 certificate inspection\n")
+	sb.WriteString("      --cert-expiry-warning string    warn about certificates expiring within this window (e.g. 30d), expired, weak or newly self-signed\n")
 	sb.WriteString("      --swap                          swap 'from' and 'to' for comparison\n")
 	sb.WriteString("  -u, --unchanged                     report keys equal between both files (inverse diff)\n")
 	sb.WriteString("\n")
@@ -451,6 +623,8 @@ func (c *CLIConfig) Usage() string {
 	sb.WriteString("      --filter-regexp strings         filter reports using regular expressions\n")
 	sb.WriteString("      --exclude-regexp strings        exclude reports using regular expressions\n")
 	sb.WriteString("      --additional-identifier string  use additional identifier in named entry lists\n")
+	sb.WriteString("      --list-key strings              identify the items of lists at PATH by FIELDs ([KIND:]PATH=FIELD[,FIELD...])\n")
+	sb.WriteString("      --schema strings                read list types from CRD manifests or an OpenAPI v3 document\n")
 	sb.WriteString("\n")
 
 	// Neat mode
@@ -474,6 +648,8 @@ func (c *CLIConfig) Usage() string {
 	sb.WriteString("  -b, --omit-header                   omit the diffyml summary header\n")
 	sb.WriteString("  -g, --use-go-patch-style            use Go-Patch style paths in outputs\n")
 	sb.WriteString("      --multi-line-context-lines int  multi-line context lines (default 4)\n")
+	sb.WriteString("      --width int                     output width for side-by-side output (default: terminal width)\n")
+	sb.WriteString("      --fold-unchanged int            fold unchanged subtrees longer than N lines in annotated output\n")
 	sb.WriteString("\n")
 
 	// Chroot options
@@ -481,6 +657,15 @@ func (c *CLIConfig) Usage() string {
 	sb.WriteString("      --chroot-of-from string         only change the root level of the from input file\n")
 	sb.WriteString("      --chroot-of-to string           only change the root level of the to input file\n")
 	sb.WriteString("      --chroot-list-to-documents      treat chroot list as set of documents\n")
+	sb.WriteString("      --map-path strings              compare the from file's entry at OLD as if it were at NEW (OLD=NEW)\n")
+	sb.WriteString("\n")
+
+	// Merge options
+	sb.WriteString("      --merge-output string           write the merged YAML to this file instead of stdout (merge mode)\n")
+	sb.WriteString("\n")
+
+	// Apply options
+	sb.WriteString("      --apply-output string           write the patched YAML to this file instead of stdout (apply mode)\n")
 	sb.WriteString("\n")
 
 	// AI Summary options
@@ -529,6 +714,10 @@ func (c *CLIConfig) Usage() string {
 	sb.WriteString("    *.yaml diff=diffyml  (in .gitattributes)\n")
 	sb.WriteString("    *.yml  diff=diffyml  (in .gitattributes)\n")
 	sb.WriteString("    git config diff.diffyml.command diffyml\n")
+	sb.WriteString("\n")
+	sb.WriteString("  Merge driver (structural three-way merge; exits 1 when conflicts remain):\n")
+	sb.WriteString("    *.yaml merge=diffyml  (in .gitattributes)\n")
+	sb.WriteString("    git config merge.diffyml.driver 'diffyml merge --merge-output %A %O %A %B'\n")
 
 	return sb.String()
 }
@@ -543,6 +732,9 @@ func (c *CLIConfig) Validate() error {
 	if c.ToFile == "" {
 		return fmt.Errorf("missing 'to' file argument")
 	}
+	if diffyml.IsStdinSource(c.FromFile) && diffyml.IsStdinSource(c.ToFile) {
+		return fmt.Errorf("stdin (\"-\") can be used for only one of the 'from' and 'to' arguments")
+	}
 
 	// Validate output format
 	if err := ValidateOutputFormat(c.Output); err != nil {
@@ -559,6 +751,16 @@ func (c *CLIConfig) Validate() error {
 		return fmt.Errorf("invalid truecolor mode %q, valid modes: always, never, auto", c.TrueColor)
 	}
 
+	// Validate output width; 0 means the terminal width
+	if c.Width < 0 {
+		return fmt.Errorf("--width must not be negative, got %d", c.Width)
+	}
+
+	// Validate fold threshold; 0 means never fold
+	if c.FoldUnchanged < 0 {
+		return fmt.Errorf("--fold-unchanged must not be negative, got %d", c.FoldUnchanged)
+	}
+
 	// Validate regex patterns
 	if err := ValidateRegexPatterns(c.FilterRegexp, "filter-regexp"); err != nil {
 		return err
@@ -573,6 +775,13 @@ func (c *CLIConfig) Validate() error {
 		return err
 	}
 
+	// Validate certificate expiry window
+	if c.CertExpiryWarning != "" {
+		if _, err := diffyml.ParseCertExpiryWarning(c.CertExpiryWarning); err != nil {
+			return fmt.Errorf("--cert-expiry-warning: %w", err)
+		}
+	}
+
 	// Neat option constraints
 	if len(c.NeatStripPath) > 0 && !c.Neat {
 		return fmt.Errorf("--neat-strip-path requires --neat")
@@ -587,7 +796,7 @@ func (c *CLIConfig) Validate() error {
 }
 
 // validOutputFormats lists all valid output format names.
-var validOutputFormats = []string{"compact", "brief", "github", "gitlab", "gitea", "json", "json-patch", "detailed"}
+var validOutputFormats = []string{"compact", "brief", "github", "gitlab", "gitea", "sarif", "junit", "html", "markdown", "side-by-side", "annotated", "json", "json-patch", "detailed"}
 
 // ValidateOutputFormat checks if the output format name is valid.
 // Returns an error listing valid options if the format is invalid.
@@ -620,6 +829,11 @@ const (
 	ExitCodeSuccess = 0
 	// ExitCodeDifferences indicates differences were found (with -s flag).
 	ExitCodeDifferences = 1
+	// ExitCodeWarnings indicates a certificate failed a policy check but
+	// nothing differs (with -s flag and --cert-expiry-warning).
+	// ExitCodeDifferences takes precedence, so CI can tell drift from a
+	// certificate that only needs attention.
+	ExitCodeWarnings = 2
 	// ExitCodeError indicates a program error occurred.
 	ExitCodeError = 255
 )
@@ -652,6 +866,18 @@ func DetermineExitCode(setExitCode bool, diffCount int, err error) int {
 	return ExitCodeSuccess
 }
 
+// countWarnings returns how many of diffs are certificate warnings. When
+// they all are, --set-exit-code reports ExitCodeWarnings.
+func countWarnings(diffs []diffyml.Difference) int {
+	n := 0
+	for _, d := range diffs {
+		if d.Type == diffyml.DiffWarning {
+			n++
+		}
+	}
+	return n
+}
+
 // ExitResult encapsulates the result of program execution.
 type ExitResult struct {
 	Code int
@@ -683,6 +909,8 @@ func (r *ExitResult) String() string {
 		return "success: no differences found"
 	case ExitCodeDifferences:
 		return "differences detected"
+	case ExitCodeWarnings:
+		return "certificate warnings detected"
 	case ExitCodeError:
 		if r.Err != nil {
 			return fmt.Sprintf("error: %v", r.Err)
@@ -699,6 +927,8 @@ type RunConfig struct {
 	Stdout io.Writer
 	// Stderr is the writer for error output.
 	Stderr io.Writer
+	// Stdin is the reader for a "-" file argument.
+	Stdin io.Reader
 	// FromContent is optional pre-loaded content for 'from' file (for testing).
 	FromContent []byte
 	// ToContent is optional pre-loaded content for 'to' file (for testing).
@@ -717,6 +947,7 @@ func NewRunConfig() *RunConfig {
 	return &RunConfig{
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
 	}
 }
 
@@ -728,7 +959,7 @@ func loadContents(cfg *CLIConfig, rc *RunConfig) ([]byte, []byte, error) {
 	if rc.FromContent != nil {
 		fromContent = rc.FromContent
 	} else {
-		fromContent, err = diffyml.LoadContent(cfg.FromFile)
+		fromContent, err = loadSource(cfg.FromFile, rc)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -737,7 +968,7 @@ func loadContents(cfg *CLIConfig, rc *RunConfig) ([]byte, []byte, error) {
 	if rc.ToContent != nil {
 		toContent = rc.ToContent
 	} else {
-		toContent, err = diffyml.LoadContent(cfg.ToFile)
+		toContent, err = loadSource(cfg.ToFile, rc)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -746,6 +977,15 @@ func loadContents(cfg *CLIConfig, rc *RunConfig) ([]byte, []byte, error) {
 	return fromContent, toContent, nil
 }
 
+// loadSource loads a single file argument, reading "-" from rc.Stdin so tests
+// can supply standard input without touching the process's own.
+func loadSource(source string, rc *RunConfig) ([]byte, error) {
+	if diffyml.IsStdinSource(source) && rc.Stdin != nil {
+		return diffyml.ReadStdin(rc.Stdin)
+	}
+	return diffyml.LoadContent(source)
+}
+
 // writeNeatExplain prints which neat regexes fired and their hit counts to w.
 // Patterns with zero hits are suppressed. report.ExcludeHits[0:len(patterns)]
 // is positionally aligned with NeatPatterns(opts) because ToFilterOptions
@@ -782,11 +1022,33 @@ func writeNeatExplain(w io.Writer, cfg *CLIConfig, report *diffyml.FilterReport)
 	}
 }
 
+// warnUnmatchedPathMappings warns about each --map-path mapping that moved
+// nothing. unmatched are the mappings as Compare applied them, reversed under
+// --swap, where they move the to input's entries; the warning names them as
+// given.
+func warnUnmatchedPathMappings(w io.Writer, cfg *CLIConfig, unmatched []diffyml.PathMapping) {
+	for _, m := range unmatched {
+		if cfg.Swap {
+			fmt.Fprintf(w, "Warning: --map-path %s=%s matched nothing: no %s in the to input\n", m.To, m.From, m.From)
+			continue
+		}
+		fmt.Fprintf(w, "Warning: --map-path %s matched nothing: no %s in the from input\n", m, m.From)
+	}
+}
+
 // runComparison performs the compare, filter, format, and optional AI summary for a single file pair.
 func runComparison(cfg *CLIConfig, rc *RunConfig, fromContent, toContent []byte, formatter diffyml.Formatter, formatOpts *diffyml.FormatOptions) *ExitResult {
 	compareOpts := cfg.ToCompareOptions()
+	compareOpts.FromFile, compareOpts.ToFile = positionFileLabels(cfg, formatOpts)
 	filterOpts := cfg.ToFilterOptions()
 
+	// Formatters that render whole documents read the inputs in the order
+	// the comparison sees them.
+	formatOpts.FromSource, formatOpts.ToSource = fromContent, toContent
+	if cfg.Swap {
+		formatOpts.FromSource, formatOpts.ToSource = toContent, fromContent
+	}
+
 	// Compare files
 	diffs, err := diffyml.Compare(fromContent, toContent, compareOpts)
 	if err != nil {
@@ -796,6 +1058,9 @@ func runComparison(cfg *CLIConfig, rc *RunConfig, fromContent, toContent []byte,
 		}
 		return NewExitResult(ExitCodeError, err)
 	}
+	if unmatched, err := diffyml.UnmatchedPathMappings(fromContent, toContent, compareOpts); err == nil {
+		warnUnmatchedPathMappings(rc.Stderr, cfg, unmatched)
+	}
 
 	// Mask sensitive values (runs before filter so masked diffs can still be
 	// filtered if desired)
@@ -856,8 +1121,12 @@ func runComparison(cfg *CLIConfig, rc *RunConfig, fromContent, toContent []byte,
 		fmt.Fprint(rc.Stdout, formatter.Format(diffs, formatOpts))
 	}
 
-	// Determine exit code
-	exitCode := DetermineExitCode(cfg.SetExitCode, len(diffs), nil)
+	// Determine exit code; warnings alone exit with their own code
+	warnings := countWarnings(diffs)
+	if cfg.SetExitCode && warnings > 0 && warnings == len(diffs) {
+		return NewExitResult(ExitCodeWarnings, nil)
+	}
+	exitCode := DetermineExitCode(cfg.SetExitCode, len(diffs)-warnings, nil)
 	return NewExitResult(exitCode, nil)
 }
 
@@ -874,6 +1143,18 @@ func Run(cfg *CLIConfig, rc *RunConfig) *ExitResult {
 		return NewExitResult(ExitCodeSuccess, nil)
 	}
 
+	if err := cfg.loadSchema(); err != nil {
+		fmt.Fprintf(rc.Stderr, "Error: %v\n", err)
+		return NewExitResult(ExitCodeError, err)
+	}
+
+	if cfg.Merge {
+		return runMerge(cfg, rc)
+	}
+	if cfg.Apply {
+		return runApply(cfg, rc)
+	}
+
 	// In git external diff mode, skip non-YAML files with a warning
 	if cfg.GitExternalDiff && !isYAMLFile(cfg.GitDisplayPath) {
 		fmt.Fprintf(rc.Stderr, "Warning: skipping non-YAML file %s\n", cfg.GitDisplayPath)
@@ -962,6 +1243,21 @@ func Run(cfg *CLIConfig, rc *RunConfig) *ExitResult {
 	return gitExternalDiffGuard(cfg, rc, result)
 }
 
+// positionFileLabels returns the file labels recorded on difference
+// positions. The to side reuses the formatter's display path so positions and
+// annotations name the same file; in git external diff mode the from side is a
+// temp file, so it is labelled with git's original (pre-rename) path instead.
+func positionFileLabels(cfg *CLIConfig, formatOpts *diffyml.FormatOptions) (fromFile, toFile string) {
+	toFile = formatOpts.FilePath
+	if !cfg.GitExternalDiff {
+		return normalizeFilePath(cfg.FromFile), toFile
+	}
+	if cfg.GitOriginalPath != "" {
+		return cfg.GitOriginalPath, toFile
+	}
+	return cfg.GitDisplayPath, toFile
+}
+
 // gitExternalDiffGuard converts errors to warnings in git external diff mode.
 // Git aborts with "external diff died" on non-zero exit, so errors must be
 // non-fatal to let git continue to the next file.
@@ -996,10 +1292,15 @@ func isYAMLFile(path string) bool {
 // Strips "./" prefix, converts absolute paths to relative from CWD.
 // Falls back to the original path if relative conversion fails or
 // produces a parent-traversing path ("..").
+// Standard input ("-") has no path, so it normalizes to the label
+// diffyml.StdinLabel rather than to a file literally named "-".
 func normalizeFilePath(path string) string {
 	if path == "" {
 		return ""
 	}
+	if diffyml.IsStdinSource(path) {
+		return diffyml.StdinLabel
+	}
 
 	// /dev/ paths (process substitution, stdin) are inherently non-relative
 	if strings.HasPrefix(path, "/dev/") {
@@ -237,6 +237,50 @@ func TestCLI_FlagCombinations_SwapAndFilter(t *testing.T) {
 	}
 }
 
+func TestCLI_OutputFormat_SideBySideWithSwap(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.Output = "side-by-side"
+	cfg.Color = "never"
+	cfg.Width = 60
+	cfg.Swap = true
+
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.FromContent = []byte("key: old\n")
+	rc.ToContent = []byte("key: new\n")
+
+	Run(cfg, rc)
+	// With swap, the to input is shown in the left column.
+	if !containsSubstr(stdout.String(), "1 ~ key: new") || !containsSubstr(stdout.String(), "│ 1 ~ key: old") {
+		t.Errorf("expected swapped columns, got:\n%s", stdout.String())
+	}
+}
+
+func TestCLI_OutputFormat_AnnotatedFoldsExcludedPaths(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.Output = "annotated"
+	cfg.Color = "never"
+	cfg.Exclude = []string{"status"}
+
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.FromContent = []byte("key: old\nstatus:\n  phase: a\n  ready: false\n")
+	rc.ToContent = []byte("key: new\nstatus:\n  phase: b\n  ready: true\n")
+
+	Run(cfg, rc)
+	out := stdout.String()
+	if !containsSubstr(out, "~ key: new  # was: old") || !containsSubstr(out, "  # [3 lines filtered]") {
+		t.Errorf("expected annotated document with the excluded subtree folded, got:\n%s", out)
+	}
+	if containsSubstr(out, "phase") {
+		t.Errorf("excluded subtree should be folded, got:\n%s", out)
+	}
+}
+
 func TestCLI_OutputFormat_CompactWithColor(t *testing.T) {
 	yaml1 := "key: value1\n"
 	yaml2 := "key: value2\n"
@@ -89,6 +89,32 @@ func TestCLIConfig_ToFormatOptions(t *testing.T) {
 	}
 }
 
+func TestCLIConfig_ToFormatOptions_Width(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--width", "90", "a.yaml", "b.yaml"}); err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+
+	if opts := cfg.ToFormatOptions(); opts.Width != 90 {
+		t.Errorf("expected Width=90, got %d", opts.Width)
+	}
+}
+
+func TestCLIConfig_ToFormatOptions_AnnotatedOptions(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--fold-unchanged", "5", "--exclude", "status", "a.yaml", "b.yaml"}); err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+
+	opts := cfg.ToFormatOptions()
+	if opts.FoldUnchanged != 5 {
+		t.Errorf("expected FoldUnchanged=5, got %d", opts.FoldUnchanged)
+	}
+	if opts.Filter == nil || len(opts.Filter.ExcludePaths) != 1 || opts.Filter.ExcludePaths[0] != "status" {
+		t.Errorf("expected Filter to carry the exclude path, got %+v", opts.Filter)
+	}
+}
+
 func TestCLIConfig_ToFormatOptions_NoCertInspection(t *testing.T) {
 	cfg := NewCLIConfig()
 	cfg.NoCertInspection = true
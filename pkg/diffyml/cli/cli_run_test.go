@@ -1201,3 +1201,66 @@ func TestRun_TrueColorAuto_EnabledByCOLORTERM(t *testing.T) {
 		t.Errorf("expected 24-bit ANSI codes with COLORTERM=truecolor, got: %s", output)
 	}
 }
+
+func TestRun_PathMappings(t *testing.T) {
+	from := "spec:\n  tls: {mode: SIMPLE, secretName: old}\n"
+	to := "spec:\n  security:\n    tls: {mode: SIMPLE, secretName: new}\n"
+	tls := diffyml.PathMapping{From: "spec.tls", To: "spec.security.tls"}
+	typo := diffyml.PathMapping{From: "spec.tsl", To: "spec.security.tls"}
+	tests := []struct {
+		name       string
+		mappings   []diffyml.PathMapping
+		swap       bool
+		wantOut    string
+		wantStderr string
+	}{
+		{
+			name:     "matched",
+			mappings: []diffyml.PathMapping{tls},
+			wantOut:  "± spec.security.tls.secretName (mapped from spec.tls.secretName) : old → new",
+		},
+		{
+			name:     "matched with swap",
+			mappings: []diffyml.PathMapping{tls},
+			swap:     true,
+			wantOut:  "± spec.tls.secretName (mapped from spec.security.tls.secretName) : new → old",
+		},
+		{
+			name:       "old path missing",
+			mappings:   []diffyml.PathMapping{typo},
+			wantStderr: "Warning: --map-path spec.tsl=spec.security.tls matched nothing: no spec.tsl in the from input\n",
+		},
+		{
+			name:       "new path missing with swap",
+			mappings:   []diffyml.PathMapping{{From: "spec.tls", To: "spec.secure.tls"}},
+			swap:       true,
+			wantStderr: "Warning: --map-path spec.tls=spec.secure.tls matched nothing: no spec.secure.tls in the to input\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewCLIConfig()
+			cfg.Output = "compact"
+			cfg.Color = "never"
+			cfg.PathMappings = tt.mappings
+			cfg.Swap = tt.swap
+
+			rc := NewRunConfig()
+			var stdout, stderr strings.Builder
+			rc.Stdout = &stdout
+			rc.Stderr = &stderr
+			rc.FromContent = []byte(from)
+			rc.ToContent = []byte(to)
+
+			if result := Run(cfg, rc); result.Code != ExitCodeSuccess {
+				t.Fatalf("exit code = %d: %v", result.Code, result.Err)
+			}
+			if !strings.Contains(stdout.String(), tt.wantOut) {
+				t.Errorf("output missing %q:\n%s", tt.wantOut, stdout.String())
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs_StdinArgument(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"-o", "compact", "-", "desired.yaml"}); err != nil {
+		t.Fatalf("ParseArgs returned error: %v", err)
+	}
+	if cfg.FromFile != "-" || cfg.ToFile != "desired.yaml" {
+		t.Errorf("files = %q/%q, want -/desired.yaml", cfg.FromFile, cfg.ToFile)
+	}
+	if cfg.Output != "compact" {
+		t.Errorf("output = %q, want compact", cfg.Output)
+	}
+}
+
+func TestCLIConfig_Validate_StdinBothSides(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.FromFile = "-"
+	cfg.ToFile = "-"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error when both arguments are stdin")
+	}
+	if !strings.Contains(err.Error(), "only one") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.ToFile = "to.yaml"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("stdin on one side should validate, got: %v", err)
+	}
+}
+
+func TestNormalizeFilePath_Stdin(t *testing.T) {
+	if got := normalizeFilePath("-"); got != "<stdin>" {
+		t.Errorf(`normalizeFilePath("-") = %q, want "<stdin>"`, got)
+	}
+}
+
+func TestRun_StdinFrom(t *testing.T) {
+	dir := t.TempDir()
+	toPath := filepath.Join(dir, "desired.yaml")
+	if err := os.WriteFile(toPath, []byte("a: 1\n---\nb: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewCLIConfig()
+	cfg.FromFile = "-"
+	cfg.ToFile = toPath
+	cfg.Output = "compact"
+	cfg.Color = "never"
+	cfg.SetExitCode = true
+
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.Stdin = strings.NewReader("a: 1\n---\nb: 2\n")
+
+	result := Run(cfg, rc)
+	if result.Code != ExitCodeDifferences {
+		t.Fatalf("exit code = %d, want %d; stderr: %s", result.Code, ExitCodeDifferences, stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "[1].b") {
+		t.Errorf("expected second document of the stdin stream to be compared, got: %s", out)
+	}
+}
+
+func TestRun_StdinTo_FileLabel(t *testing.T) {
+	dir := t.TempDir()
+	fromPath := filepath.Join(dir, "live.yaml")
+	if err := os.WriteFile(fromPath, []byte("a: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"github", "::warning file=<stdin>,line=1,"},
+		{"junit", `<testsuite name="&lt;stdin&gt;"`},
+		{"sarif", `"uri": "%3Cstdin%3E"`},
+		// GitLab locations name a file in the repository, so stdin falls
+		// back to the YAML path.
+		{"gitlab", `"location": {"path": "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			cfg := NewCLIConfig()
+			cfg.FromFile = fromPath
+			cfg.ToFile = "-"
+			cfg.Output = tt.output
+
+			rc := NewRunConfig()
+			var stdout, stderr strings.Builder
+			rc.Stdout = &stdout
+			rc.Stderr = &stderr
+			rc.Stdin = strings.NewReader("a: 2\n")
+
+			result := Run(cfg, rc)
+			if result.Code != ExitCodeSuccess {
+				t.Fatalf("exit code = %d; stderr: %s", result.Code, stderr.String())
+			}
+			if out := stdout.String(); !strings.Contains(out, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
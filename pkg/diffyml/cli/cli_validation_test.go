@@ -169,6 +169,36 @@ func TestCLIConfig_Validate_InvalidNeatStripPath(t *testing.T) {
 	}
 }
 
+func TestCLIConfig_Validate_NegativeWidth(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.FromFile = "from.yaml"
+	cfg.ToFile = "to.yaml"
+	cfg.Width = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for negative --width")
+	}
+	if !containsSubstr(err.Error(), "--width") {
+		t.Errorf("error should mention --width, got: %v", err)
+	}
+}
+
+func TestCLIConfig_Validate_NegativeFoldUnchanged(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.FromFile = "from.yaml"
+	cfg.ToFile = "to.yaml"
+	cfg.FoldUnchanged = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for negative --fold-unchanged")
+	}
+	if !containsSubstr(err.Error(), "--fold-unchanged") {
+		t.Errorf("error should mention --fold-unchanged, got: %v", err)
+	}
+}
+
 func TestCLIConfig_Validate_MissingFromFile(t *testing.T) {
 	cfg := NewCLIConfig()
 	cfg.ToFile = "to.yaml"
@@ -24,23 +24,35 @@ type FileConfig struct {
 	TrueColor *string `yaml:"truecolor"`
 
 	// Comparison options
-	IgnoreOrderChanges      *bool `yaml:"ignore-order-changes"`
-	IgnoreWhitespaceChanges *bool `yaml:"ignore-whitespace-changes"`
-	FormatStrings           *bool `yaml:"format-strings"`
-	IgnoreValueChanges      *bool `yaml:"ignore-value-changes"`
-	DetectKubernetes        *bool `yaml:"detect-kubernetes"`
-	DetectRenames           *bool `yaml:"detect-renames"`
-	IgnoreApiVersion        *bool `yaml:"ignore-api-version"`
-	NoCertInspection        *bool `yaml:"no-cert-inspection"`
-	Swap                    *bool `yaml:"swap"`
-	Unchanged               *bool `yaml:"unchanged"`
+	IgnoreOrderChanges      *bool    `yaml:"ignore-order-changes"`
+	IgnoreWhitespaceChanges *bool    `yaml:"ignore-whitespace-changes"`
+	FormatStrings           *bool    `yaml:"format-strings"`
+	DescendEmbedded         *bool    `yaml:"descend-embedded"`
+	DecodeBase64            *bool    `yaml:"decode-base64"`
+	CompareComments         *bool    `yaml:"compare-comments"`
+	NormalizeQuantities     *bool    `yaml:"normalize-quantities"`
+	IgnoreValueChanges      *bool    `yaml:"ignore-value-changes"`
+	DetectKubernetes        *bool    `yaml:"detect-kubernetes"`
+	DetectRenames           *bool    `yaml:"detect-renames"`
+	DetectMoves             *bool    `yaml:"detect-moves"`
+	IgnoreApiVersion        *bool    `yaml:"ignore-api-version"`
+	NoCertInspection        *bool    `yaml:"no-cert-inspection"`
+	CertExpiryWarning       *string  `yaml:"cert-expiry-warning"`
+	Swap                    *bool    `yaml:"swap"`
+	Unchanged               *bool    `yaml:"unchanged"`
+	QuantityPaths           []string `yaml:"quantity-path"`
+	DurationPaths           []string `yaml:"duration-path"`
+	SetLists                *bool    `yaml:"set-lists"`
+	SetListPaths            []string `yaml:"set-list"`
 
 	// Filtering options
-	Filter                []string `yaml:"filter"`
-	Exclude               []string `yaml:"exclude"`
-	FilterRegexp          []string `yaml:"filter-regexp"`
-	ExcludeRegexp         []string `yaml:"exclude-regexp"`
-	AdditionalIdentifiers []string `yaml:"additional-identifier"`
+	Filter                []string          `yaml:"filter"`
+	Exclude               []string          `yaml:"exclude"`
+	FilterRegexp          []string          `yaml:"filter-regexp"`
+	ExcludeRegexp         []string          `yaml:"exclude-regexp"`
+	AdditionalIdentifiers []string          `yaml:"additional-identifier"`
+	ListKeys              []diffyml.ListKey `yaml:"list-key"`
+	Schema                []string          `yaml:"schema"`
 
 	// Neat options (curated K8s/Helm/ArgoCD/Flux noise filter)
 	Neat *NeatFileConfig `yaml:"neat"`
@@ -55,12 +67,15 @@ type FileConfig struct {
 	OmitHeader            *bool `yaml:"omit-header"`
 	UseGoPatchStyle       *bool `yaml:"use-go-patch-style"`
 	MultiLineContextLines *int  `yaml:"multi-line-context-lines"`
+	Width                 *int  `yaml:"width"`
+	FoldUnchanged         *int  `yaml:"fold-unchanged"`
 
 	// Chroot options
-	Chroot                *string `yaml:"chroot"`
-	ChrootFrom            *string `yaml:"chroot-of-from"`
-	ChrootTo              *string `yaml:"chroot-of-to"`
-	ChrootListToDocuments *bool   `yaml:"chroot-list-to-documents"`
+	Chroot                *string               `yaml:"chroot"`
+	ChrootFrom            *string               `yaml:"chroot-of-from"`
+	ChrootTo              *string               `yaml:"chroot-of-to"`
+	ChrootListToDocuments *bool                 `yaml:"chroot-list-to-documents"`
+	PathMappings          []diffyml.PathMapping `yaml:"map-path"`
 
 	// AI Summary options
 	Summary      *bool   `yaml:"summary"`
@@ -177,6 +192,30 @@ func (c *CLIConfig) applyFileConfig(fc *FileConfig, cliSet map[string]bool) {
 	if fc.FormatStrings != nil && notSet("format-strings") {
 		c.FormatStrings = *fc.FormatStrings
 	}
+	if fc.DescendEmbedded != nil && notSet("descend-embedded") {
+		c.DescendEmbedded = *fc.DescendEmbedded
+	}
+	if fc.DecodeBase64 != nil && notSet("decode-base64") {
+		c.DecodeBase64 = *fc.DecodeBase64
+	}
+	if fc.CompareComments != nil && notSet("compare-comments") {
+		c.CompareComments = *fc.CompareComments
+	}
+	if fc.NormalizeQuantities != nil && notSet("normalize-quantities") {
+		c.NormalizeQuantities = *fc.NormalizeQuantities
+	}
+	if len(fc.QuantityPaths) > 0 && notSet("quantity-path") {
+		c.QuantityPaths = fc.QuantityPaths
+	}
+	if len(fc.DurationPaths) > 0 && notSet("duration-path") {
+		c.DurationPaths = fc.DurationPaths
+	}
+	if fc.SetLists != nil && notSet("set-lists") {
+		c.SetLists = *fc.SetLists
+	}
+	if len(fc.SetListPaths) > 0 && notSet("set-list") {
+		c.SetListPaths = fc.SetListPaths
+	}
 	if fc.IgnoreValueChanges != nil && notSet("ignore-value-changes", "v") {
 		c.IgnoreValueChanges = *fc.IgnoreValueChanges
 	}
@@ -186,12 +225,18 @@ func (c *CLIConfig) applyFileConfig(fc *FileConfig, cliSet map[string]bool) {
 	if fc.DetectRenames != nil && notSet("detect-renames") {
 		c.DetectRenames = *fc.DetectRenames
 	}
+	if fc.DetectMoves != nil && notSet("detect-moves") {
+		c.DetectMoves = *fc.DetectMoves
+	}
 	if fc.IgnoreApiVersion != nil && notSet("ignore-api-version") {
 		c.IgnoreApiVersion = *fc.IgnoreApiVersion
 	}
 	if fc.NoCertInspection != nil && notSet("no-cert-inspection", "x") {
 		c.NoCertInspection = *fc.NoCertInspection
 	}
+	if fc.CertExpiryWarning != nil && notSet("cert-expiry-warning") {
+		c.CertExpiryWarning = *fc.CertExpiryWarning
+	}
 	if fc.Swap != nil && notSet("swap") {
 		c.Swap = *fc.Swap
 	}
@@ -215,6 +260,12 @@ func (c *CLIConfig) applyFileConfig(fc *FileConfig, cliSet map[string]bool) {
 	if len(fc.AdditionalIdentifiers) > 0 && notSet("additional-identifier") {
 		c.AdditionalIdentifiers = fc.AdditionalIdentifiers
 	}
+	if len(fc.ListKeys) > 0 && notSet("list-key") {
+		c.ListKeys = fc.ListKeys
+	}
+	if len(fc.Schema) > 0 && notSet("schema") {
+		c.SchemaFiles = fc.Schema
+	}
 
 	// Neat options. Config uses positive truth-table (helm: false ⇒ drop helm),
 	// CLI uses opt-out flags (--no-neat-helm), so polarity is inverted on apply.
@@ -263,6 +314,12 @@ func (c *CLIConfig) applyFileConfig(fc *FileConfig, cliSet map[string]bool) {
 	if fc.MultiLineContextLines != nil && notSet("multi-line-context-lines") {
 		c.MultiLineContextLines = *fc.MultiLineContextLines
 	}
+	if fc.Width != nil && notSet("width") {
+		c.Width = *fc.Width
+	}
+	if fc.FoldUnchanged != nil && notSet("fold-unchanged") {
+		c.FoldUnchanged = *fc.FoldUnchanged
+	}
 
 	// Chroot options
 	if fc.Chroot != nil && notSet("chroot") {
@@ -277,6 +334,9 @@ func (c *CLIConfig) applyFileConfig(fc *FileConfig, cliSet map[string]bool) {
 	if fc.ChrootListToDocuments != nil && notSet("chroot-list-to-documents") {
 		c.ChrootListToDocuments = *fc.ChrootListToDocuments
 	}
+	if len(fc.PathMappings) > 0 && notSet("map-path") {
+		c.PathMappings = fc.PathMappings
+	}
 
 	// AI Summary options
 	if fc.Summary != nil && notSet("summary", "S") {
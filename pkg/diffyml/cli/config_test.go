@@ -3,6 +3,7 @@ package cli
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -38,6 +39,8 @@ additional-identifier:
 omit-header: true
 use-go-patch-style: true
 multi-line-context-lines: 8
+width: 90
+fold-unchanged: 12
 chroot: "data"
 chroot-of-from: "from-root"
 chroot-of-to: "to-root"
@@ -131,6 +134,12 @@ colors:
 	if fc.MultiLineContextLines == nil || *fc.MultiLineContextLines != 8 {
 		t.Errorf("expected MultiLineContextLines=8, got %v", fc.MultiLineContextLines)
 	}
+	if fc.Width == nil || *fc.Width != 90 {
+		t.Errorf("expected Width=90, got %v", fc.Width)
+	}
+	if fc.FoldUnchanged == nil || *fc.FoldUnchanged != 12 {
+		t.Errorf("expected FoldUnchanged=12, got %v", fc.FoldUnchanged)
+	}
 
 	// Chroot options
 	if fc.Chroot == nil || *fc.Chroot != "data" {
@@ -1222,3 +1231,160 @@ func TestApplyFileConfig_Neat_NilLeavesDefaults(t *testing.T) {
 		t.Error("expected all neat fields to remain default false when config has no neat block")
 	}
 }
+
+func TestParseArgs_ListKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "custom-config.yml")
+	content := "list-key:\n  - tolerations=key,effect\n  - path: containers.*.ports\n    fields: [containerPort, protocol]\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ListKeys) != 2 || cfg.ListKeys[1].Path != "containers.*.ports" {
+		t.Fatalf("expected 2 list keys from config, got %+v", cfg.ListKeys)
+	}
+	opts := cfg.ToCompareOptions()
+	if len(opts.ListKeys) != 2 || len(cfg.ToFilterOptions().ListKeys) != 2 || len(cfg.ToMaskOptions().ListKeys) != 2 {
+		t.Errorf("list keys not propagated to compare, filter and mask options")
+	}
+
+	// The flag replaces the config entries.
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "--list-key", "env=name", "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ListKeys) != 1 || cfg.ListKeys[0].Path != "env" {
+		t.Errorf("expected --list-key to override config, got %+v", cfg.ListKeys)
+	}
+
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--list-key", "env", "from.yaml", "to.yaml"}); err == nil {
+		t.Error("expected error for --list-key without fields")
+	}
+
+	badPath := filepath.Join(dir, "bad-config.yml")
+	if err := os.WriteFile(badPath, []byte("list-key:\n  - path: env\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", badPath, "from.yaml", "to.yaml"}); err == nil {
+		t.Error("expected error for config list key without fields")
+	}
+}
+
+func TestRun_Schema(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "crd.yaml")
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: example.com
+  names: {kind: Gateway}
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          properties:
+            spec:
+              properties:
+                addresses: {type: array, x-kubernetes-list-type: atomic}
+`
+	if err := os.WriteFile(schemaPath, []byte(crd), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "custom-config.yml")
+	if err := os.WriteFile(configPath, []byte("schema:\n  - "+schemaPath+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	from := "apiVersion: example.com/v1\nkind: Gateway\nmetadata: {name: gw}\nspec:\n  addresses: [a, b]\n"
+	to := "apiVersion: example.com/v1\nkind: Gateway\nmetadata: {name: gw}\nspec:\n  addresses: [a, c]\n"
+	run := func(args ...string) (*ExitResult, string) {
+		cfg := NewCLIConfig()
+		if err := cfg.ParseArgs(append(args, "from.yaml", "to.yaml")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rc := NewRunConfig()
+		var stdout, stderr strings.Builder
+		rc.Stdout, rc.Stderr = &stdout, &stderr
+		rc.FromContent, rc.ToContent = []byte(from), []byte(to)
+		return Run(cfg, rc), stdout.String() + stderr.String()
+	}
+
+	for _, args := range [][]string{{"--schema", schemaPath}, {"--config", configPath}} {
+		_, out := run(append(args, "-o", "brief")...)
+		if !strings.Contains(out, "1 modified") || strings.Contains(out, "added") {
+			t.Errorf("%v: expected the atomic list reported as one modification, got: %s", args, out)
+		}
+	}
+
+	result, out := run("--schema", filepath.Join(dir, "missing.yaml"))
+	if result.Code != ExitCodeError || !strings.Contains(out, "failed to load schema") {
+		t.Errorf("missing schema: got code %d, output %q", result.Code, out)
+	}
+}
+
+func TestParseArgs_SetLists(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "custom-config.yml")
+	if err := os.WriteFile(configPath, []byte("set-lists: true\nset-list:\n  - rules.*.verbs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := cfg.ToCompareOptions()
+	if !opts.SetLists || len(opts.SetListPaths) != 1 || opts.SetListPaths[0] != "rules.*.verbs" {
+		t.Errorf("set list options not read from config: %v %v", opts.SetLists, opts.SetListPaths)
+	}
+
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "--set-lists=false", "--set-list", "args", "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SetLists || len(cfg.SetListPaths) != 1 || cfg.SetListPaths[0] != "args" {
+		t.Errorf("expected flags to override config, got %v %v", cfg.SetLists, cfg.SetListPaths)
+	}
+}
+
+func TestParseArgs_PathMappings(t *testing.T) {
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "custom-config.yml")
+	content := "map-path:\n  - spec.tls=spec.security.tls\n  - from: db\n    to: database\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.PathMappings) != 2 || cfg.PathMappings[1].To != "database" {
+		t.Fatalf("expected 2 path mappings from config, got %+v", cfg.PathMappings)
+	}
+	if len(cfg.ToCompareOptions().PathMappings) != 2 {
+		t.Errorf("path mappings not propagated to compare options")
+	}
+
+	// The flag replaces the config entries.
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--config", configPath, "--map-path", "a=b", "from.yaml", "to.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.PathMappings) != 1 || cfg.PathMappings[0].From != "a" {
+		t.Errorf("expected --map-path to override config, got %+v", cfg.PathMappings)
+	}
+
+	cfg = NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"--map-path", "spec.tls", "from.yaml", "to.yaml"}); err == nil {
+		t.Error("expected error for --map-path without a new path")
+	}
+}
@@ -7,6 +7,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -148,18 +149,24 @@ func buildFilePairsFromMap(m map[string][2][]byte) []diffyml.FilePair {
 	return pairs
 }
 
-// processDirPair processes a single file pair in directory mode.
+// processDirPair processes a single file pair in directory mode. Alongside
+// the differences it returns the pair's raw from and to content, which
+// formatters that render whole documents need.
 // Returns the diffs and an error if processing failed.
-func processDirPair(pair diffyml.FilePair, filePairs map[string][2][]byte, compareOpts *diffyml.Options, maskOpts diffyml.MaskOptions, filterOpts *diffyml.FilterOptions) ([]diffyml.Difference, error) {
+func processDirPair(pair diffyml.FilePair, filePairs map[string][2][]byte, compareOpts *diffyml.Options, maskOpts diffyml.MaskOptions, filterOpts *diffyml.FilterOptions) ([]diffyml.Difference, [2][]byte, error) {
 	fromContent, toContent, err := loadFilePairContent(pair, filePairs)
 	if err != nil {
-		return nil, fmt.Errorf("reading %s: %w", pair.Name, err)
+		return nil, [2][]byte{}, fmt.Errorf("reading %s: %w", pair.Name, err)
 	}
-	diffs, err := compareAndFilterPair(fromContent, toContent, compareOpts, maskOpts, filterOpts)
+	sources := [2][]byte{fromContent, toContent}
+	pairOpts := *compareOpts
+	pairOpts.FromFile = normalizeFilePath(pair.FromPath)
+	pairOpts.ToFile = normalizeFilePath(pair.ToPath)
+	diffs, err := compareAndFilterPair(fromContent, toContent, &pairOpts, maskOpts, filterOpts)
 	if err != nil {
-		return nil, fmt.Errorf("comparing %s: %w", pair.Name, err)
+		return nil, sources, fmt.Errorf("comparing %s: %w", pair.Name, err)
 	}
-	return diffs, nil
+	return diffs, sources, nil
 }
 
 // setupDirFormatting creates the formatter and format options for directory mode.
@@ -177,6 +184,9 @@ func setupDirFormatting(cfg *CLIConfig) (diffyml.Formatter, *diffyml.FormatOptio
 	colorCfg := diffyml.NewColorConfig(colorMode, useTrueColor)
 	colorCfg.DetectTerminal()
 	colorCfg.ToFormatOptions(formatOpts)
+	if formatOpts.Width <= 0 {
+		formatOpts.Width = diffyml.DetectTerminalWidth()
+	}
 
 	return formatter, formatOpts, nil
 }
@@ -203,12 +213,17 @@ type dirPairCollector struct {
 	groups         []diffyml.DiffGroup
 	summaryEntries []summaryEntry
 	hasDiffs       bool
+	hasWarnings    bool
 	hasErrors      bool
+	unmatched      []diffyml.PathMapping // path mappings no pair has matched yet
 }
 
 // collectPairResult records the diff results for a single file pair, emitting output as needed.
-func (c *dirPairCollector) collectPairResult(pair diffyml.FilePair, diffs []diffyml.Difference) {
-	c.hasDiffs = true
+// sources holds the pair's raw from and to content.
+func (c *dirPairCollector) collectPairResult(pair diffyml.FilePair, diffs []diffyml.Difference, sources [2][]byte) {
+	warnings := countWarnings(diffs)
+	c.hasDiffs = c.hasDiffs || warnings < len(diffs)
+	c.hasWarnings = c.hasWarnings || warnings > 0
 
 	if c.isStructured {
 		filePath := strings.TrimPrefix(pair.Name, "./")
@@ -227,7 +242,9 @@ func (c *dirPairCollector) collectPairResult(pair diffyml.FilePair, diffs []diff
 	}
 	if !c.isBriefSummary {
 		fmt.Fprint(c.rc.Stdout, diffyml.FormatFileHeader(pair.Name, pair.Type, c.formatOpts))
-		fmt.Fprint(c.rc.Stdout, c.formatter.Format(diffs, c.formatOpts))
+		pairOpts := *c.formatOpts
+		pairOpts.FromSource, pairOpts.ToSource = sources[0], sources[1]
+		fmt.Fprint(c.rc.Stdout, c.formatter.Format(diffs, &pairOpts))
 	}
 }
 
@@ -252,7 +269,10 @@ func runDirectory(cfg *CLIConfig, rc *RunConfig, fromDir, toDir string) *ExitRes
 
 	compareOpts := cfg.ToCompareOptions()
 	if cfg.Swap {
+		// The directories are swapped instead, so the mappings are reversed
+		// here as Compare would reverse them.
 		compareOpts.Swap = false
+		compareOpts.PathMappings = diffyml.ReversePathMappings(compareOpts.PathMappings)
 	}
 	filterOpts := cfg.ToFilterOptions()
 	maskOpts := cfg.ToMaskOptions()
@@ -266,23 +286,31 @@ func runDirectory(cfg *CLIConfig, rc *RunConfig, fromDir, toDir string) *ExitRes
 		isStructured:   isStructured,
 		isBriefSummary: cfg.Output == "brief" && cfg.Summary,
 		wantSummary:    cfg.Summary,
+		unmatched:      slices.Clone(compareOpts.PathMappings),
 	}
 	for _, pair := range pairs {
-		diffs, diffErr := processDirPair(pair, rc.FilePairs, compareOpts, maskOpts, filterOpts)
+		diffs, sources, diffErr := processDirPair(pair, rc.FilePairs, compareOpts, maskOpts, filterOpts)
 		if diffErr != nil {
 			fmt.Fprintf(rc.Stderr, "Error: %v\n", diffErr)
 			c.hasErrors = true
 			continue
 		}
+		if len(c.unmatched) > 0 {
+			if unmatched, err := diffyml.UnmatchedPathMappings(sources[0], sources[1], compareOpts); err == nil {
+				c.unmatched = slices.DeleteFunc(c.unmatched, func(m diffyml.PathMapping) bool {
+					return !slices.Contains(unmatched, m)
+				})
+			}
+		}
 		if len(diffs) > 0 {
-			c.collectPairResult(pair, diffs)
+			c.collectPairResult(pair, diffs, sources)
 		}
 	}
 
 	if isStructured {
 		fmt.Fprint(rc.Stdout, sf.FormatAll(c.groups, formatOpts))
-		c.hasDiffs = len(c.groups) > 0
 	}
+	warnUnmatchedPathMappings(rc.Stderr, cfg, c.unmatched)
 
 	if cfg.Summary {
 		emitDirectorySummary(cfg, rc, c.groups, c.summaryEntries, formatOpts, formatter, isStructured, c.isBriefSummary)
@@ -291,6 +319,9 @@ func runDirectory(cfg *CLIConfig, rc *RunConfig, fromDir, toDir string) *ExitRes
 	if cfg.SetExitCode && c.hasDiffs {
 		return NewExitResult(ExitCodeDifferences, nil)
 	}
+	if cfg.SetExitCode && c.hasWarnings {
+		return NewExitResult(ExitCodeWarnings, nil)
+	}
 	if c.hasErrors {
 		return NewExitResult(ExitCodeError, nil)
 	}
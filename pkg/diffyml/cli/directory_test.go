@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -35,6 +37,71 @@ func TestRunDirectory_IdenticalFiles_Exit0(t *testing.T) {
 	}
 }
 
+func TestRunDirectory_DifferencesTakePrecedenceOverWarnings(t *testing.T) {
+	expired, err := os.ReadFile(filepath.Join(fixturesDir, "153-cert-warnings-only", "file1.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		pairs map[string][2][]byte
+		want  int
+	}{
+		{
+			name:  "warnings only",
+			pairs: map[string][2][]byte{"tls.yaml": {expired, expired}},
+			want:  ExitCodeWarnings,
+		},
+		{
+			name: "warnings and differences",
+			pairs: map[string][2][]byte{
+				"tls.yaml":    {expired, expired},
+				"deploy.yaml": {[]byte("replicas: 1\n"), []byte("replicas: 2\n")},
+			},
+			want: ExitCodeDifferences,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewCLIConfig()
+			cfg.SetExitCode = true
+			cfg.Color = "never"
+			cfg.CertExpiryWarning = "30d"
+
+			rc := NewRunConfig()
+			var stdout, stderr strings.Builder
+			rc.Stdout = &stdout
+			rc.Stderr = &stderr
+			rc.FilePairs = tt.pairs
+
+			if result := runDirectory(cfg, rc, "", ""); result.Code != tt.want {
+				t.Errorf("exit code = %d, want %d\n%s", result.Code, tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunDirectory_UnmatchedPathMappings(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.Color = "never"
+	cfg.PathMappings = []diffyml.PathMapping{{From: "tls", To: "security.tls"}, {From: "db", To: "database"}}
+
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.FilePairs = map[string][2][]byte{
+		"gateway.yaml": {[]byte("tls: {mode: A}\n"), []byte("security:\n  tls: {mode: A}\n")},
+		"other.yaml":   {[]byte("name: x\n"), []byte("name: y\n")},
+	}
+
+	runDirectory(cfg, rc, "", "")
+	want := "Warning: --map-path db=database matched nothing: no db in the from input\n"
+	if stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
 func TestRunDirectory_UnchangedCollapsedValueHonorsMaskPath(t *testing.T) {
 	cfg := NewCLIConfig()
 	cfg.Color = "never"
@@ -62,6 +129,30 @@ func TestRunDirectory_UnchangedCollapsedValueHonorsMaskPath(t *testing.T) {
 	}
 }
 
+func TestRunDirectory_SideBySideUsesPairSources(t *testing.T) {
+	cfg := NewCLIConfig()
+	cfg.Output = "side-by-side"
+	cfg.Color = "never"
+	cfg.Width = 60
+
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	rc.FilePairs = map[string][2][]byte{
+		"a.yaml": {[]byte("a: 1\n"), []byte("a: 2\n")},
+		"b.yaml": {[]byte("b: x\n"), []byte("b: y\n")},
+	}
+
+	runDirectory(cfg, rc, "", "")
+	output := stdout.String()
+	for _, want := range []string{"1 ~ a: 1", "│ 1 ~ a: 2", "1 ~ b: x", "│ 1 ~ b: y"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
 func TestRunDirectory_ModifiedFile_Exit1(t *testing.T) {
 	cfg := NewCLIConfig()
 	cfg.SetExitCode = true
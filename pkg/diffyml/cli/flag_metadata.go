@@ -23,7 +23,7 @@ type FlagDoc struct {
 func FlagDocs() []FlagDoc {
 	return []FlagDoc{
 		// Output
-		{Long: "output", Short: "o", Type: "string", Default: "detailed", Category: "Output", Usage: "specify output style: compact, brief, github, gitlab, gitea, json, json-patch, detailed"},
+		{Long: "output", Short: "o", Type: "string", Default: "detailed", Category: "Output", Usage: "specify output style: compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed"},
 		{Long: "color", Short: "c", Type: "string", Default: "auto", Category: "Output", Usage: "specify color usage: always, never, or auto"},
 		{Long: "truecolor", Short: "t", Type: "string", Default: "auto", Category: "Output", Usage: "specify true color usage: always, never, or auto"},
 
@@ -31,11 +31,21 @@ func FlagDocs() []FlagDoc {
 		{Long: "ignore-order-changes", Short: "i", Type: "bool", Category: "Comparison", Usage: "ignore order changes in lists"},
 		{Long: "ignore-whitespace-changes", Type: "bool", Category: "Comparison", Usage: "ignore leading or trailing whitespace changes"},
 		{Long: "format-strings", Type: "bool", Category: "Comparison", Usage: "canonicalize embedded JSON strings before comparison"},
+		{Long: "descend-embedded", Type: "bool", Category: "Comparison", Usage: "compare strings embedding YAML or JSON documents structurally"},
+		{Long: "decode-base64", Type: "bool", Category: "Comparison", Usage: "compare Secret data, ConfigMap binaryData and !!binary values decoded"},
+		{Long: "compare-comments", Type: "bool", Category: "Comparison", Usage: "report changed YAML comments"},
+		{Long: "normalize-quantities", Type: "bool", Category: "Comparison", Usage: "compare Kubernetes quantities and durations by value"},
+		{Long: "quantity-path", Type: "list", Category: "Comparison", Usage: "additional path holding Kubernetes quantities (dot-notation, prefix match; repeatable)"},
+		{Long: "duration-path", Type: "list", Category: "Comparison", Usage: "additional path holding Go durations or seconds (dot-notation, prefix match; repeatable)"},
+		{Long: "set-lists", Type: "bool", Category: "Comparison", Usage: "compare lists of scalars as sets, reporting added and removed elements"},
+		{Long: "set-list", Type: "list", Category: "Comparison", Usage: "compare the scalar lists at PATH as sets (* matches any segment; repeatable)"},
 		{Long: "ignore-value-changes", Short: "v", Type: "bool", Category: "Comparison", Usage: "exclude changes in values"},
 		{Long: "detect-kubernetes", Type: "bool", Default: "true", Category: "Comparison", Usage: "detect kubernetes entities"},
 		{Long: "detect-renames", Type: "bool", Default: "true", Category: "Comparison", Usage: "enable detection for renames"},
+		{Long: "detect-moves", Type: "bool", Category: "Comparison", Usage: "report subtrees moved or renamed within a document as moves"},
 		{Long: "ignore-api-version", Type: "bool", Category: "Comparison", Usage: "ignore apiVersion when matching Kubernetes resources"},
 		{Long: "no-cert-inspection", Short: "x", Type: "bool", Category: "Comparison", Usage: "disable x509 certificate inspection"},
+		{Long: "cert-expiry-warning", Type: "string", Category: "Comparison", Usage: "warn about certificates expiring within this window (e.g. 30d), expired, weak or newly self-signed"},
 		{Long: "swap", Type: "bool", Category: "Comparison", Usage: "swap 'from' and 'to' for comparison"},
 		{Long: "unchanged", Short: "u", Type: "bool", Category: "Comparison", Usage: "report keys equal between both files (inverse diff)"},
 
@@ -45,6 +55,8 @@ func FlagDocs() []FlagDoc {
 		{Long: "filter-regexp", Type: "list", Category: "Filtering", Usage: "filter reports using regular expressions (repeatable)"},
 		{Long: "exclude-regexp", Type: "list", Category: "Filtering", Usage: "exclude reports using regular expressions (repeatable)"},
 		{Long: "additional-identifier", Type: "list", Category: "Filtering", Usage: "use additional identifier in named entry lists (repeatable)"},
+		{Long: "list-key", Type: "list", Category: "Filtering", Usage: "identify the items of lists at PATH by FIELDs, [KIND:]PATH=FIELD[,FIELD...] (repeatable)"},
+		{Long: "schema", Type: "list", Category: "Filtering", Usage: "read list types from CRD manifests or an OpenAPI v3 document (repeatable)"},
 
 		// Neat
 		{Long: "neat", Type: "bool", Category: "Neat", Usage: "exclude well-known noisy K8s/Helm/ArgoCD/Flux paths"},
@@ -65,12 +77,21 @@ func FlagDocs() []FlagDoc {
 		{Long: "omit-header", Short: "b", Type: "bool", Category: "Display", Usage: "omit the diffyml summary header"},
 		{Long: "use-go-patch-style", Short: "g", Type: "bool", Category: "Display", Usage: "use Go-Patch style paths in outputs"},
 		{Long: "multi-line-context-lines", Type: "int", Default: "4", Category: "Display", Usage: "context lines for multi-line strings"},
+		{Long: "width", Type: "int", Category: "Display", Usage: "output width in columns for side-by-side output (default: terminal width, else 120)"},
+		{Long: "fold-unchanged", Type: "int", Category: "Display", Usage: "fold unchanged subtrees longer than N lines in annotated output (0: never)"},
 
 		// Chroot
 		{Long: "chroot", Type: "string", Category: "Chroot", Usage: "change the root level of the input file"},
 		{Long: "chroot-of-from", Type: "string", Category: "Chroot", Usage: "only change the root level of the from input file"},
 		{Long: "chroot-of-to", Type: "string", Category: "Chroot", Usage: "only change the root level of the to input file"},
 		{Long: "chroot-list-to-documents", Type: "bool", Category: "Chroot", Usage: "treat chroot list as set of documents"},
+		{Long: "map-path", Type: "list", Category: "Chroot", Usage: "compare the from file's entry at OLD as if it were at NEW (OLD=NEW; repeatable)"},
+
+		// Merge
+		{Long: "merge-output", Type: "string", Category: "Merge", Usage: "write the merged YAML to this file instead of stdout (merge mode)"},
+
+		// Apply
+		{Long: "apply-output", Type: "string", Category: "Apply", Usage: "write the patched YAML to this file instead of stdout (apply mode)"},
 
 		// AI Summary
 		{Long: "summary", Short: "S", Type: "bool", Category: "AI Summary", Usage: "enable AI-powered summary of differences (requires ANTHROPIC_API_KEY)"},
@@ -0,0 +1,92 @@
+// merge.go - Three-way merge mode ("diffyml merge <base> <ours> <theirs>").
+//
+// Wraps diffyml.Merge for the command line and for use as a git merge
+// driver: git runs the driver with the ancestor, current and other versions
+// (%O %A %B), expects the result written over %A, and treats a non-zero exit
+// as "conflicts remain".
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/szhekpisov/diffyml/pkg/diffyml"
+)
+
+// runMerge merges OursFile and TheirsFile against BaseFile. The merged YAML
+// goes to MergeOutput, or to stdout when unset. Conflicts are listed on
+// stderr and exit with ExitCodeDifferences, which git reads as an unresolved
+// merge; the conflict markers are in the output either way.
+func runMerge(cfg *CLIConfig, rc *RunConfig) *ExitResult {
+	fail := func(err error) *ExitResult {
+		fmt.Fprintf(rc.Stderr, "Error: %v\n", err)
+		return NewExitResult(ExitCodeError, err)
+	}
+
+	var contents [3][]byte
+	for i, source := range []string{cfg.BaseFile, cfg.OursFile, cfg.TheirsFile} {
+		data, err := loadSource(source, rc)
+		if err != nil {
+			return fail(err)
+		}
+		contents[i] = data
+	}
+
+	// Only the options that pair documents and list items apply; ignoring
+	// changes would make one side's edits silently lose.
+	result, err := diffyml.Merge(contents[0], contents[1], contents[2], &diffyml.Options{
+		DetectKubernetes:      cfg.DetectKubernetes,
+		IgnoreApiVersion:      cfg.IgnoreApiVersion,
+		AdditionalIdentifiers: cfg.AdditionalIdentifiers,
+		ListKeys:              cfg.ListKeys,
+		Schema:                cfg.schema,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := writeYAMLOutput(cfg.MergeOutput, result.Merged, rc); err != nil {
+		return fail(fmt.Errorf("failed to write merge output: %w", err))
+	}
+
+	for _, c := range result.Conflicts {
+		path := c.Path.String()
+		if path == "" {
+			path = "(root level)"
+		}
+		if c.DocumentName != "" {
+			path += " (" + c.DocumentName + ")"
+		}
+		fmt.Fprintf(rc.Stderr, "CONFLICT: %s: %s in ours, %s in theirs\n", path, mergeChangeVerb(c.Ours), mergeChangeVerb(c.Theirs))
+	}
+	if len(result.Conflicts) > 0 {
+		return NewExitResult(ExitCodeDifferences, nil)
+	}
+	return NewExitResult(ExitCodeSuccess, nil)
+}
+
+// mergeChangeVerb describes how one side changed a conflicted value.
+func mergeChangeVerb(t diffyml.DiffType) string {
+	switch t {
+	case diffyml.DiffAdded:
+		return "added"
+	case diffyml.DiffRemoved:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// writeYAMLOutput writes data to path, keeping the permissions of a file it
+// replaces, or to stdout when path is empty.
+func writeYAMLOutput(path string, data []byte, rc *RunConfig) error {
+	if path == "" {
+		_, err := rc.Stdout.Write(data)
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, data, mode)
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLIConfig_ParseArgs_Merge(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"merge", "--merge-output", "out.yaml", "base.yaml", "ours.yaml", "theirs.yaml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Merge || cfg.BaseFile != "base.yaml" || cfg.OursFile != "ours.yaml" || cfg.TheirsFile != "theirs.yaml" {
+		t.Errorf("unexpected merge config: %+v", cfg)
+	}
+	if cfg.MergeOutput != "out.yaml" {
+		t.Errorf("expected MergeOutput='out.yaml', got %q", cfg.MergeOutput)
+	}
+}
+
+func TestCLIConfig_ParseArgs_MergeNeedsThreeFiles(t *testing.T) {
+	cfg := NewCLIConfig()
+	err := cfg.ParseArgs([]string{"merge", "base.yaml", "ours.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "three file arguments") {
+		t.Errorf("expected three-file error, got %v", err)
+	}
+}
+
+func runMergeFiles(t *testing.T, base, ours, theirs string, args ...string) (*ExitResult, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	createFile(t, dir, "base.yaml", base)
+	createFile(t, dir, "ours.yaml", ours)
+	createFile(t, dir, "theirs.yaml", theirs)
+
+	cfg := NewCLIConfig()
+	args = append(append([]string{"merge"}, args...), filepath.Join(dir, "base.yaml"), filepath.Join(dir, "ours.yaml"), filepath.Join(dir, "theirs.yaml"))
+	if err := cfg.ParseArgs(args); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+	return Run(cfg, rc), stdout.String(), stderr.String()
+}
+
+func TestRunMerge_Clean(t *testing.T) {
+	result, stdout, stderr := runMergeFiles(t, "a: 1\nb: 2\n", "a: 3\nb: 2\n", "a: 1\nb: 4\n")
+	if result.Code != ExitCodeSuccess {
+		t.Errorf("expected exit code %d, got %d (stderr: %s)", ExitCodeSuccess, result.Code, stderr)
+	}
+	if stdout != "a: 3\nb: 4\n" {
+		t.Errorf("unexpected merged output:\n%s", stdout)
+	}
+}
+
+func TestRunMerge_ConflictExitsWithDifferences(t *testing.T) {
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	result, stdout, stderr := runMergeFiles(t, cm, strings.Replace(cm, "k: a", "k: b", 1), strings.Replace(cm, "k: a", "k: c", 1))
+	if result.Code != ExitCodeDifferences {
+		t.Errorf("expected exit code %d, got %d", ExitCodeDifferences, result.Code)
+	}
+	if !containsSubstr(stdout, "<<<<<<< ours\n  k: b\n=======\n  k: c\n>>>>>>> theirs\n") {
+		t.Errorf("expected conflict markers, got:\n%s", stdout)
+	}
+	if want := "CONFLICT: data.k (v1/ConfigMap/cfg): modified in ours, modified in theirs\n"; stderr != want {
+		t.Errorf("stderr = %q, want %q", stderr, want)
+	}
+}
+
+// TestRunMerge_GitDriver mirrors "diffyml merge --merge-output %A %O %A %B":
+// the result replaces the ours file in place.
+func TestRunMerge_GitDriver(t *testing.T) {
+	dir := t.TempDir()
+	createFile(t, dir, "base", "a: 1\nb: 2\n")
+	createFile(t, dir, "ours", "a: 3\nb: 2\n")
+	createFile(t, dir, "theirs", "a: 1\nb: 4\n")
+	ours := filepath.Join(dir, "ours")
+
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"merge", "--merge-output", ours, filepath.Join(dir, "base"), ours, filepath.Join(dir, "theirs")}); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+
+	if result := Run(cfg, rc); result.Code != ExitCodeSuccess {
+		t.Fatalf("expected exit code %d, got %d (stderr: %s)", ExitCodeSuccess, result.Code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", stdout.String())
+	}
+	data, err := os.ReadFile(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a: 3\nb: 4\n" {
+		t.Errorf("unexpected merged file:\n%s", data)
+	}
+}
+
+func TestRunMerge_MissingFile(t *testing.T) {
+	cfg := NewCLIConfig()
+	if err := cfg.ParseArgs([]string{"merge", "/nonexistent/base", "/nonexistent/ours", "/nonexistent/theirs"}); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRunConfig()
+	var stdout, stderr strings.Builder
+	rc.Stdout = &stdout
+	rc.Stderr = &stderr
+
+	if result := Run(cfg, rc); result.Code != ExitCodeError {
+		t.Errorf("expected exit code %d, got %d", ExitCodeError, result.Code)
+	}
+	if !containsSubstr(stderr.String(), "Error:") {
+		t.Errorf("expected error on stderr, got %q", stderr.String())
+	}
+}
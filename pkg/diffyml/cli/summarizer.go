@@ -182,6 +182,20 @@ func checkHTTPError(statusCode int, result *messagesResponse) error {
 	return nil
 }
 
+// promptDiffLine renders one difference for the prompt: its old and new
+// value, for a move its old and new path, or for a warning its finding.
+func promptDiffLine(diff diffyml.Difference) string {
+	if diff.Type == diffyml.DiffWarning {
+		return fmt.Sprintf("- [%s] %s: %s\n", diffTypeLabel(diff.Type), diff.Path, diff.Warning)
+	}
+	from := diffyml.SerializeValue(diff.From)
+	to := diffyml.SerializeValue(diff.To)
+	if diff.Type == diffyml.DiffMoved {
+		from, to = diff.FromPath.String(), diff.Path.String()
+	}
+	return fmt.Sprintf("- [%s] %s: %q → %q\n", diffTypeLabel(diff.Type), diff.Path, from, to)
+}
+
 // diffTypeLabel returns the prompt label for a DiffType.
 func diffTypeLabel(dt diffyml.DiffType) string {
 	switch dt {
@@ -191,10 +205,18 @@ func diffTypeLabel(dt diffyml.DiffType) string {
 		return "REMOVED"
 	case diffyml.DiffModified:
 		return "MODIFIED"
+	case diffyml.DiffTypeChanged:
+		return "TYPE_CHANGED"
+	case diffyml.DiffMoved:
+		return "MOVED"
 	case diffyml.DiffOrderChanged:
 		return "ORDER_CHANGED"
 	case diffyml.DiffUnchanged:
 		return "UNCHANGED"
+	case diffyml.DiffCommentChanged:
+		return "COMMENT_CHANGED"
+	case diffyml.DiffWarning:
+		return "WARNING"
 	default:
 		return "UNKNOWN"
 	}
@@ -248,9 +270,7 @@ func buildTruncatedPrompt(groups []diffyml.DiffGroup) string {
 		sb.WriteString(header)
 
 		for diffIndex, diff := range group.Diffs {
-			from := diffyml.SerializeValue(diff.From)
-			to := diffyml.SerializeValue(diff.To)
-			line := fmt.Sprintf("- [%s] %s: %q → %q\n", diffTypeLabel(diff.Type), diff.Path, from, to)
+			line := promptDiffLine(diff)
 
 			changesAfter := remainingChanges - 1
 			filesAfter := remainingFiles
@@ -294,9 +314,7 @@ func buildPrompt(groups []diffyml.DiffGroup) string {
 		sb.WriteString(header)
 
 		for _, diff := range group.Diffs {
-			from := diffyml.SerializeValue(diff.From)
-			to := diffyml.SerializeValue(diff.To)
-			line := fmt.Sprintf("- [%s] %s: %q → %q\n", diffTypeLabel(diff.Type), diff.Path, from, to)
+			line := promptDiffLine(diff)
 			if sb.Len()+len(line) > maxPromptLen {
 				return buildTruncatedPrompt(groups)
 			}
@@ -9,6 +9,7 @@ package diffyml
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -69,6 +70,22 @@ func IsTerminal(fd uintptr) bool {
 	return (stat.Mode() & os.ModeCharDevice) != 0
 }
 
+// stdoutWidthFn is an injectable function reporting the column count of the
+// terminal on stdout, or 0 when stdout is not a terminal. The default queries
+// the terminal driver where the platform supports it (terminal_width_*.go).
+var stdoutWidthFn = terminalWidth
+
+// DetectTerminalWidth returns the output width in columns. A positive COLUMNS
+// environment variable wins, so users can pin the width for piped output;
+// otherwise the terminal on stdout is queried. Returns 0 when the width is
+// unknown, leaving the choice of a default to the caller.
+func DetectTerminalWidth() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil && n > 0 {
+		return n
+	}
+	return stdoutWidthFn()
+}
+
 // ColorConfig holds color and terminal configuration.
 type ColorConfig struct {
 	mode       ColorMode
@@ -167,7 +184,7 @@ func DetailedColorCode(diffType DiffType, useTrueColor bool) string {
 			return TrueColorCode(DetailedGreenR, DetailedGreenG, DetailedGreenB)
 		case DiffRemoved:
 			return TrueColorCode(DetailedRedR, DetailedRedG, DetailedRedB)
-		case DiffModified, DiffOrderChanged:
+		case DiffModified, DiffTypeChanged, DiffOrderChanged, DiffCommentChanged, DiffMoved, DiffWarning:
 			return TrueColorCode(DetailedYellowR, DetailedYellowG, DetailedYellowB)
 		}
 	}
@@ -177,7 +194,7 @@ func DetailedColorCode(diffType DiffType, useTrueColor bool) string {
 		return colorGreen
 	case DiffRemoved:
 		return colorRed
-	case DiffModified, DiffOrderChanged:
+	case DiffModified, DiffTypeChanged, DiffOrderChanged, DiffCommentChanged, DiffMoved, DiffWarning:
 		return colorYellow
 	}
 	return ""
@@ -298,7 +315,7 @@ func entryPalette(diffType DiffType, useTrueColor bool) *YAMLColorPalette {
 		return cachedFlatRed
 	default:
 		// Neutral palette — reached for DiffUnchanged entry batches (inverse mode).
-		// DiffModified and DiffOrderChanged route through formatChangeDescriptor,
+		// DiffModified, DiffTypeChanged, DiffOrderChanged, DiffCommentChanged, DiffMoved and DiffWarning route through formatChangeDescriptor,
 		// not renderEntryValue, so they never land here.
 		if useTrueColor {
 			return cachedNeutralPalette
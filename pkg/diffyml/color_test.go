@@ -66,6 +66,30 @@ func TestIsTerminal_StatError(t *testing.T) {
 	}
 }
 
+func TestDetectTerminalWidth(t *testing.T) {
+	orig := stdoutWidthFn
+	t.Cleanup(func() { stdoutWidthFn = orig })
+	stdoutWidthFn = func() int { return 132 }
+
+	tests := []struct {
+		columns string
+		want    int
+	}{
+		{"", 132},
+		{"80", 80},
+		{" 100 ", 100},
+		{"0", 132},
+		{"-5", 132},
+		{"wide", 132},
+	}
+	for _, tt := range tests {
+		t.Setenv("COLUMNS", tt.columns)
+		if got := DetectTerminalWidth(); got != tt.want {
+			t.Errorf("COLUMNS=%q: DetectTerminalWidth() = %d, want %d", tt.columns, got, tt.want)
+		}
+	}
+}
+
 func TestIsTerminal_Pipe(t *testing.T) {
 	// When piped (e.g. in tests), IsTerminal should return false for a pipe fd
 	r, w, err := os.Pipe()
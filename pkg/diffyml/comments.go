@@ -0,0 +1,126 @@
+// comments.go - Comment comparison (Options.CompareComments).
+//
+// yaml.v3 keeps every comment on a node as its head, line or foot comment.
+// Which node gets it depends on layout: "key: value # note" puts the line
+// comment on the value, while "key: # note" above a block collection puts it
+// on the key. A mapping entry's comments are therefore read from its key and
+// value together, so a comment that stays where it is written is not
+// reported when the value next to it changes shape. Each changed slot of a
+// matched entry, list item or document becomes one DiffCommentChanged.
+// Key types: CommentKind.
+// Key functions: compareComments, compareEqualComments, formatComment.
+package diffyml
+
+import (
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CommentKind names the comment slot a DiffCommentChanged difference reports.
+type CommentKind string
+
+const (
+	// CommentHead is the comment on the lines above an entry.
+	CommentHead CommentKind = "head"
+	// CommentLine is the comment at the end of an entry's line.
+	CommentLine CommentKind = "line"
+	// CommentFoot is the comment on the lines below an entry.
+	CommentFoot CommentKind = "foot"
+)
+
+// commentKinds orders the slots of entryComments.
+var commentKinds = [3]CommentKind{CommentHead, CommentLine, CommentFoot}
+
+// entryComments collects the head, line and foot comments of an entry from
+// its key (nil for list items and documents) and its value. A DocumentNode
+// also contributes the comments of its root node.
+func entryComments(key, val *yaml.Node) [3]string {
+	var slots [3]string
+	add := func(n *yaml.Node) {
+		for i, c := range [3]string{n.HeadComment, n.LineComment, n.FootComment} {
+			if c == "" {
+				continue
+			}
+			if slots[i] != "" {
+				slots[i] += "\n"
+			}
+			slots[i] += c
+		}
+	}
+	if key != nil {
+		add(key)
+	}
+	add(val)
+	if val.Kind == yaml.DocumentNode && len(val.Content) > 0 {
+		add(val.Content[0])
+	}
+	return slots
+}
+
+// compareComments reports the comment slots that differ between a matched
+// pair of entries. It is a no-op unless opts.CompareComments is set, and for
+// pairs missing a side: an added or removed entry takes its comments with it.
+// Positions point at the first line of the key, or of the value when there is
+// no key, which is the line a comment belongs to.
+func compareComments(path DiffPath, fromKey, fromN, toKey, toN *yaml.Node, opts *Options) []Difference {
+	if !opts.CompareComments || fromN == nil || toN == nil {
+		return nil
+	}
+	from := entryComments(fromKey, fromN)
+	to := entryComments(toKey, toN)
+	var diffs []Difference
+	for i, kind := range commentKinds {
+		if from[i] == to[i] {
+			continue
+		}
+		diffs = append(diffs, Difference{
+			Path:    path,
+			Type:    DiffCommentChanged,
+			From:    commentValue(from[i]),
+			To:      commentValue(to[i]),
+			FromPos: commentPosition(fromKey, fromN),
+			ToPos:   commentPosition(toKey, toN),
+			Comment: kind,
+		})
+	}
+	return diffs
+}
+
+// compareEqualComments reports the comment changes within a pair of list
+// items matched as deeply equal, which the comparator otherwise does not
+// descend into. Equal values yield no other difference, so the walk reports
+// comments only.
+func compareEqualComments(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
+	if !opts.CompareComments {
+		return nil
+	}
+	return append(compareComments(path, nil, fromN, nil, toN, opts), compareNodes(path, fromN, toN, opts)...)
+}
+
+// commentValue returns a comment as a difference value: nil when absent.
+func commentValue(c string) any {
+	if c == "" {
+		return nil
+	}
+	return c
+}
+
+// commentPosition returns the first line of key, or of val without a key.
+func commentPosition(key, val *yaml.Node) *Position {
+	if key != nil {
+		return containerPosition(key)
+	}
+	return containerPosition(val)
+}
+
+// formatComment renders one side of a DiffCommentChanged for single-line
+// output: the comment quoted, so a multiline comment stays on one line, or
+// "(none)" where there was no comment.
+func formatComment(val any) string {
+	c, ok := val.(string)
+	if !ok {
+		return "(none)"
+	}
+	return strconv.Quote(c)
+}
@@ -0,0 +1,205 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+// commentDiffs compares from and to with CompareComments set and returns only
+// the comment changes.
+func commentDiffs(t *testing.T, from, to string, opts *Options) []Difference {
+	t.Helper()
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.CompareComments = true
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	var out []Difference
+	for _, d := range diffs {
+		if d.Type == DiffCommentChanged {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func TestCompare_CommentsOffByDefault(t *testing.T) {
+	diffs, err := Compare([]byte("# a\nk: 1 # one\n"), []byte("# b\nk: 1 # two\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected no differences without CompareComments, got %+v", diffs)
+	}
+}
+
+func TestCompare_CommentSlots(t *testing.T) {
+	from := "app:\n  # keep in sync with the chart\n  replicas: 3 # tuned\n  # end of app\nother: 1\n"
+	to := "app:\n  # owned by team-a\n  replicas: 3 # tuned for prod\nother: 1\n"
+	diffs := commentDiffs(t, from, to, nil)
+
+	want := []struct {
+		kind     CommentKind
+		from, to any
+	}{
+		{CommentHead, "# keep in sync with the chart", "# owned by team-a"},
+		{CommentLine, "# tuned", "# tuned for prod"},
+		{CommentFoot, "# end of app", nil},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("expected %d comment changes, got %d: %+v", len(want), len(diffs), diffs)
+	}
+	for i, w := range want {
+		d := diffs[i]
+		if d.Path.String() != "app.replicas" || d.Comment != w.kind || d.From != w.from || d.To != w.to {
+			t.Errorf("diff %d = %s %s %v → %v, want app.replicas %s %v → %v", i, d.Path, d.Comment, d.From, d.To, w.kind, w.from, w.to)
+		}
+		if d.ToPos == nil || d.ToPos.Line != 3 || d.ToPos.EndLine != 3 {
+			t.Errorf("diff %d ToPos = %+v, want line 3", i, d.ToPos)
+		}
+	}
+}
+
+func TestCompare_CommentFollowsEntryNotNode(t *testing.T) {
+	// The line comment sits on the value node in the first file and on the
+	// key node in the second; the entry's comment is the same.
+	diffs := commentDiffs(t, "a: 1 # note\n", "a: # note\n  b: 1\n", nil)
+	if len(diffs) != 0 {
+		t.Errorf("expected no comment change, got %+v", diffs)
+	}
+}
+
+func TestCompare_CommentsOnListItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		opts     *Options
+		path     string
+	}{
+		{
+			name: "positional",
+			from: "ports:\n  - 80 # http\n  - 443\n",
+			to:   "ports:\n  - 80 # plain http\n  - 443\n",
+			path: "ports.0",
+		},
+		{
+			name: "identifier",
+			from: "env:\n  - name: A\n    value: x\n",
+			to:   "env:\n  # set by the operator\n  - name: A\n    value: x\n",
+			path: "env.A",
+		},
+		{
+			name: "unordered exact match",
+			from: "ports:\n  - 80 # http\n  - 443\n",
+			to:   "ports:\n  - 443\n  - 80 # web\n",
+			opts: &Options{IgnoreOrderChanges: true},
+			path: "ports.0",
+		},
+		{
+			name: "inside items matched as equal",
+			from: "env:\n  - name: A\n  - value: x # raw\n",
+			to:   "env:\n  - name: A\n  - value: x # cooked\n",
+			path: "env.1.value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := commentDiffs(t, tt.from, tt.to, tt.opts)
+			if len(diffs) != 1 || diffs[0].Path.String() != tt.path {
+				t.Fatalf("expected one comment change at %s, got %+v", tt.path, diffs)
+			}
+		})
+	}
+}
+
+func TestCompare_CommentsOnDocuments(t *testing.T) {
+	from := "# first\n\na: 1\n---\nb: 2\n"
+	to := "# first, revised\n\na: 1\n---\nb: 2\n"
+	diffs := commentDiffs(t, from, to, nil)
+	if len(diffs) != 1 {
+		t.Fatalf("expected one comment change, got %+v", diffs)
+	}
+	if d := diffs[0]; d.Path.String() != "[0]" || d.Comment != CommentHead || d.DocumentIndex != 0 {
+		t.Errorf("got %s %s in document %d, want [0] head in document 0", d.Path, d.Comment, d.DocumentIndex)
+	}
+}
+
+func TestCompare_CommentsOnKubernetesDocuments(t *testing.T) {
+	doc := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: v # %s\n"
+	from := strings.Replace(doc, "%s", "old", 1) + "---\n" + strings.Replace(strings.Replace(doc, "cfg", "two", 1), "%s", "x", 1)
+	to := strings.Replace(doc, "%s", "new", 1) + "---\n" + strings.Replace(strings.Replace(doc, "cfg", "two", 1), "%s", "x", 1)
+	diffs := commentDiffs(t, from, to, &Options{DetectKubernetes: true})
+	if len(diffs) != 1 {
+		t.Fatalf("expected one comment change, got %+v", diffs)
+	}
+	if d := diffs[0]; d.Path.String() != "[0].data.k" || d.DocumentName != "v1/ConfigMap/cfg" {
+		t.Errorf("got %s (%s), want [0].data.k (v1/ConfigMap/cfg)", d.Path, d.DocumentName)
+	}
+}
+
+func TestCompare_CommentsOfAddedEntriesNotReported(t *testing.T) {
+	diffs := commentDiffs(t, "a: 1\n", "a: 1\n# new key\nb: 2 # fresh\n", nil)
+	if len(diffs) != 0 {
+		t.Errorf("an added entry takes its comments with it, got %+v", diffs)
+	}
+}
+
+func TestFormatComment(t *testing.T) {
+	if got := formatComment(nil); got != "(none)" {
+		t.Errorf("formatComment(nil) = %q", got)
+	}
+	if got := formatComment("# a\n# b"); got != `"# a\n# b"` {
+		t.Errorf("formatComment(multiline) = %q", got)
+	}
+}
+
+func TestFormatters_CommentChanged(t *testing.T) {
+	diff := Difference{
+		Path:    DiffPath{"image", "tag"},
+		Type:    DiffCommentChanged,
+		From:    "# pinned",
+		To:      "# bumped\n# by renovate",
+		Comment: CommentLine,
+	}
+	noColor := &FormatOptions{OmitHeader: true}
+	tests := []struct {
+		name string
+		f    Formatter
+		want []string
+	}{
+		{"compact", &CompactFormatter{}, []string{`# image.tag (line comment) : "# pinned" → "# bumped\n# by renovate"`}},
+		{"brief", &BriefFormatter{}, []string{"1 comment changed\n"}},
+		{"detailed", &DetailedFormatter{}, []string{"  # line comment changed\n    - # pinned\n    + # bumped\n    + # by renovate\n"}},
+		{"github", &GitHubFormatter{}, []string{"::notice title=YAML Comment Changed::Comment changed: image.tag line comment changed from"}},
+		{"gitlab", &GitLabFormatter{}, []string{`"check_name": "diffyml/comment-changed"`, `"severity": "info"`}},
+		{"sarif", &SARIFFormatter{}, []string{`"ruleId": "diffyml/comment-changed"`, `"ruleIndex": 5`}},
+		{"json", &JSONFormatter{}, []string{`"type": "comment_changed"`, `"comment": "line"`}},
+		{"json-patch", &JSONPatchFormatter{}, []string{"[]\n"}},
+		{"markdown", &MarkdownFormatter{}, []string{"| Order changed | Comments |", "# image.tag: line comment changed\n- # pinned\n+ # bumped\n+ # by renovate\n"}},
+		{"side-by-side", &SideBySideFormatter{}, []string{"image.tag (line comment)", "# pinned", "# by renovate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.f.Format([]Difference{diff}, noColor)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output missing %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLFormatter_CommentColumn(t *testing.T) {
+	with := (&HTMLFormatter{}).Format([]Difference{{Path: DiffPath{"a"}, Type: DiffCommentChanged, To: "# x", Comment: CommentHead}}, nil)
+	if !strings.Contains(with, "<th class=\"modified\">Comments</th>") {
+		t.Errorf("comments column missing:\n%s", with)
+	}
+	without := (&HTMLFormatter{}).Format([]Difference{{Path: DiffPath{"a"}, Type: DiffModified, From: 1, To: 2}}, nil)
+	if strings.Contains(without, "Comments</th>") {
+		t.Errorf("comments column shown without comment changes:\n%s", without)
+	}
+}
@@ -46,7 +46,10 @@ func compareDocs(from, to []*yaml.Node, opts *Options) []Difference {
 			pathPrefix = DiffPath{"[" + strconv.Itoa(i) + "]"}
 		}
 
-		nodeDiffs := compareNodes(pathPrefix, fromN, toN, opts)
+		nodeDiffs := compareComments(pathPrefix, nil, fromN, nil, toN, opts)
+		nodeDiffs = append(nodeDiffs, compareNodes(pathPrefix, fromN, toN, opts)...)
+		nodeDiffs = detectMoves(nodeDiffs, opts)
+		nodeDiffs = append(nodeDiffs, certWarnings(pathPrefix, fromN, toN, opts)...)
 		for j := range nodeDiffs {
 			nodeDiffs[j].DocumentIndex = i
 		}
@@ -134,10 +137,12 @@ func compareNodeNils(path DiffPath, fromN, toN *yaml.Node, opts *Options) ([]Dif
 	}
 	if fromIsNull {
 		return []Difference{{
-			Path: path,
-			Type: DiffAdded,
-			From: nil,
-			To:   nodeToInterface(toN),
+			Path:    path,
+			Type:    DiffAdded,
+			From:    nil,
+			To:      nodeToInterface(toN),
+			FromPos: nodePosition(fromN),
+			ToPos:   nodePosition(toN),
 		}}, true
 	}
 	if toIsNull {
@@ -145,10 +150,12 @@ func compareNodeNils(path DiffPath, fromN, toN *yaml.Node, opts *Options) ([]Dif
 			return nil, true
 		}
 		return []Difference{{
-			Path: path,
-			Type: DiffModified,
-			From: nodeToInterface(fromN),
-			To:   nil,
+			Path:    path,
+			Type:    DiffModified,
+			From:    nodeToInterface(fromN),
+			To:      nil,
+			FromPos: nodePosition(fromN),
+			ToPos:   nodePosition(toN),
 		}}, true
 	}
 	return nil, false
@@ -170,10 +177,14 @@ func compareNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Differe
 			return nil
 		}
 		return []Difference{{
-			Path: path,
-			Type: DiffModified,
-			From: nodeToInterface(fromN),
-			To:   nodeToInterface(toN),
+			Path:     path,
+			Type:     DiffTypeChanged,
+			From:     nodeToInterface(fromN),
+			To:       nodeToInterface(toN),
+			FromPos:  nodePosition(fromN),
+			ToPos:    nodePosition(toN),
+			FromType: nodeTypeName(fromN),
+			ToType:   nodeTypeName(toN),
 		}}
 	}
 
@@ -192,18 +203,82 @@ func compareNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Differe
 // values and delegating equality logic to equalValues. equalValues correctly
 // reports unequal for values of different dynamic types (Go's == on `any`
 // requires both type and value match), so a type-mismatch fast path would be
-// behaviorally indistinguishable from this single equality check.
+// behaviorally indistinguishable from this single equality check. A pair
+// whose resolved tags differ is reported as DiffTypeChanged, even when a
+// custom tag such as !Ref leaves the value itself unchanged; int and float
+// count as one numeric type, so 1 and 1.0 are equal. Under
+// NormalizeQuantities, quantities and durations are compared by value
+// instead, and an int quantity changing to a string one is not a type change.
+// Under DecodeBase64, base64 payloads are compared decoded (see
+// compareDecoded); under DescendEmbedded, two strings embedding YAML or JSON
+// documents are compared structurally instead (see compareEmbedded).
 func compareScalarNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
 	fromVal := resolveScalar(fromN)
 	toVal := resolveScalar(toN)
 
-	if equalValues(fromVal, toVal, opts) {
+	delta, normalized := normalizedDelta(path, fromN, toN, opts)
+	if normalized && delta == "" {
 		return nil
 	}
+	if !normalized && equalValues(fromVal, toVal, opts) && !scalarTypeChanged(fromN, toN) {
+		return nil
+	}
+	if opts.DecodeBase64 && !normalized {
+		if diffs, ok := compareDecoded(path, fromN, toN, opts); ok {
+			return markEmbedded(diffs, path, toN)
+		}
+	}
+	if opts.DescendEmbedded && !normalized {
+		if diffs, ok := compareEmbedded(path, fromN, toN, opts); ok {
+			return markEmbedded(diffs, path, toN)
+		}
+	}
 	if opts.IgnoreValueChanges {
 		return nil
 	}
-	return []Difference{{Path: path, Type: DiffModified, From: fromVal, To: toVal}}
+	diff := Difference{
+		Path:    path,
+		Type:    DiffModified,
+		From:    fromVal,
+		To:      toVal,
+		FromPos: nodePosition(fromN),
+		ToPos:   nodePosition(toN),
+		Delta:   delta,
+	}
+	if !normalized && scalarTypeChanged(fromN, toN) {
+		diff.Type = DiffTypeChanged
+		diff.FromType, diff.ToType = nodeTypeName(fromN), nodeTypeName(toN)
+	}
+	return []Difference{diff}
+}
+
+// scalarTypeChanged reports whether two scalars have different resolved
+// tags, int and float counting as one.
+func scalarTypeChanged(fromN, toN *yaml.Node) bool {
+	from, to := fromN.ShortTag(), toN.ShortTag()
+	numeric := func(tag string) bool { return tag == "!!int" || tag == "!!float" }
+	return from != to && !(numeric(from) && numeric(to))
+}
+
+// nodeTypeName names the YAML type of a resolved node in the words the
+// detailed formatter uses for values: "map", "list", and for scalars their
+// resolved tag ("string", "int", "float", "bool", "timestamp", "null", or a
+// custom tag as written).
+func nodeTypeName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	switch tag := n.ShortTag(); tag {
+	case "!!str":
+		return "string"
+	case "!!int", "!!float", "!!bool", "!!timestamp", "!!null", "!!binary":
+		return strings.TrimPrefix(tag, "!!")
+	default:
+		return tag
+	}
 }
 
 // compareMappingNodes compares two MappingNodes preserving the from-side's
@@ -225,21 +300,27 @@ func compareMappingNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []
 
 	for i := 0; i+1 < len(fromN.Content); i += 2 {
 		key := fromN.Content[i].Value
+		fromKey := fromN.Content[fromIdx[key]]
 		fromVal := fromN.Content[fromIdx[key]+1]
-		toPos, inTo := toIdx[key]
+		toKeyIdx, inTo := toIdx[key]
 
 		if !inTo {
 			diffs = append(diffs, Difference{
-				Path: path,
-				Type: DiffRemoved,
-				From: mapEntryWrapper(key, fromVal),
-				To:   nil,
+				Path:    path,
+				Type:    DiffRemoved,
+				From:    mapEntryWrapper(key, fromVal),
+				To:      nil,
+				FromPos: entryPosition(fromKey, fromVal),
+				ToPos:   containerPosition(toN),
+				entry:   moveCandidate(fromVal, opts),
 			})
 			continue
 		}
 
-		toVal := toN.Content[toPos+1]
+		toKey := toN.Content[toKeyIdx]
+		toVal := toN.Content[toKeyIdx+1]
 		childPath := path.Append(key)
+		diffs = append(diffs, compareComments(childPath, fromKey, fromVal, toKey, toVal, opts)...)
 		diffs = append(diffs, compareNodes(childPath, fromVal, toVal, opts)...)
 	}
 
@@ -252,12 +333,16 @@ func compareMappingNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []
 		}
 		// last-write-wins on duplicate to-side keys: pull the value from the
 		// recorded last position rather than the current i+1.
+		toKey := toN.Content[toIdx[key]]
 		toVal := toN.Content[toIdx[key]+1]
 		diffs = append(diffs, Difference{
-			Path: path,
-			Type: DiffAdded,
-			From: nil,
-			To:   mapEntryWrapper(key, toVal),
+			Path:    path,
+			Type:    DiffAdded,
+			From:    nil,
+			To:      mapEntryWrapper(key, toVal),
+			FromPos: containerPosition(fromN),
+			ToPos:   entryPosition(toKey, toVal),
+			entry:   moveCandidate(toVal, opts),
 		})
 	}
 
@@ -289,7 +374,15 @@ func indexMappingValues(n *yaml.Node) map[string]int {
 // matched, unordered, positional, or heterogeneous-unordered strategy with
 // the same semantics as the legacy compareLists.
 func compareSequenceNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
-	if canMatchByIdentifierNodes(fromN.Content, opts) && canMatchByIdentifierNodes(toN.Content, opts) {
+	ids := identityAt(path, opts)
+	switch {
+	case ids.listType == listTypeAtomic:
+		return compareAtomicSequenceNodes(path, fromN, toN, opts)
+	case isScalarSet(path, ids, fromN, toN, opts):
+		return compareScalarSetNodes(path, fromN, toN, opts)
+	case ids.listType == listTypeSet:
+		return compareSequenceNodesUnordered(path, fromN, toN, opts)
+	case ids.canMatch(fromN.Content) && ids.canMatch(toN.Content):
 		return compareSequenceNodesByIdentifier(path, fromN, toN, opts)
 	}
 
@@ -304,6 +397,23 @@ func compareSequenceNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) [
 	return compareSequenceNodesPositional(path, fromN, toN, opts)
 }
 
+// compareAtomicSequenceNodes compares a list its schema declares atomic: the
+// API server replaces such a list as a whole, so any change is reported as one
+// DiffModified of the entire list.
+func compareAtomicSequenceNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
+	if opts.IgnoreValueChanges || deepEqualSequenceNodes(fromN, toN, opts) {
+		return nil
+	}
+	return []Difference{{
+		Path:    path,
+		Type:    DiffModified,
+		From:    nodeToInterface(fromN),
+		To:      nodeToInterface(toN),
+		FromPos: nodePosition(fromN),
+		ToPos:   nodePosition(toN),
+	}}
+}
+
 // areSequenceItemsHeterogeneous mirrors areListItemsHeterogeneous on nodes:
 // single-key map items with distinct keys across the two lists indicate a
 // heterogeneous shape (e.g. {namespaceSelector: ...} vs {ipBlock: ...}).
@@ -353,32 +463,42 @@ func singleKeyMappingFirstKeys(items []*yaml.Node) (map[string]bool, bool) {
 	return keys, true
 }
 
-// compareSequenceNodesPositional compares sequences by index.
+// compareSequenceNodesPositional compares unidentified sequences item by
+// item, as paired by alignSequence.
 func compareSequenceNodesPositional(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
 	var diffs []Difference
 	from := fromN.Content
 	to := toN.Content
-	minLen := min(len(from), len(to))
 
-	for i := range minLen {
-		childPath := path.Append(strconv.Itoa(i))
-		diffs = append(diffs, compareNodes(childPath, from[i], to[i], opts)...)
-	}
-	for i := minLen; i < len(to); i++ {
-		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(i)),
-			Type: DiffAdded,
-			From: nil,
-			To:   nodeToInterface(to[i]),
-		})
-	}
-	for i := minLen; i < len(from); i++ {
-		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(i)),
-			Type: DiffRemoved,
-			From: nodeToInterface(from[i]),
-			To:   nil,
-		})
+	// Paired and removed items are named by their index in the old list,
+	// added ones by their index in the new list (see orderDiffOps).
+	for _, p := range alignSequence(from, to) {
+		switch {
+		case p.from < 0:
+			diffs = append(diffs, Difference{
+				Path:      path.Append(strconv.Itoa(p.to)),
+				Type:      DiffAdded,
+				From:      nil,
+				To:        nodeToInterface(to[p.to]),
+				FromPos:   containerPosition(fromN),
+				ToPos:     nodePosition(to[p.to]),
+				listEntry: true,
+			})
+		case p.to < 0:
+			diffs = append(diffs, Difference{
+				Path:      path.Append(strconv.Itoa(p.from)),
+				Type:      DiffRemoved,
+				From:      nodeToInterface(from[p.from]),
+				To:        nil,
+				FromPos:   nodePosition(from[p.from]),
+				ToPos:     containerPosition(toN),
+				listEntry: true,
+			})
+		default:
+			childPath := path.Append(strconv.Itoa(p.from))
+			diffs = append(diffs, compareComments(childPath, nil, from[p.from], nil, to[p.to], opts)...)
+			diffs = append(diffs, compareNodes(childPath, from[p.from], to[p.to], opts)...)
+		}
 	}
 
 	return diffs
@@ -393,6 +513,7 @@ func compareSequenceNodesUnordered(path DiffPath, fromN, toN *yaml.Node, opts *O
 	to := toN.Content
 	fromMatched := make([]bool, len(from))
 	toMatched := make([]bool, len(to))
+	var diffs []Difference
 
 	// Materialize once per item for the deepEqual scan — slight up-front cost
 	// but avoids repeated nodeToInterface walks inside the O(N*M) loop. The
@@ -418,12 +539,12 @@ func compareSequenceNodesUnordered(path DiffPath, fromN, toN *yaml.Node, opts *O
 			if deepEqual(fromValues[i], toValues[j], opts) {
 				fromMatched[i] = true
 				toMatched[j] = true
+				diffs = append(diffs, compareEqualComments(path.Append(strconv.Itoa(i)), from[i], to[j], opts)...)
 				break
 			}
 		}
 	}
 
-	var diffs []Difference
 	fi, tj := 0, 0
 	for fi < len(from) && tj < len(to) {
 		if fromMatched[fi] {
@@ -434,6 +555,7 @@ func compareSequenceNodesUnordered(path DiffPath, fromN, toN *yaml.Node, opts *O
 			tj++
 			continue
 		}
+		diffs = append(diffs, compareComments(path.Append(strconv.Itoa(fi)), nil, from[fi], nil, to[tj], opts)...)
 		diffs = append(diffs, compareNodes(path.Append(strconv.Itoa(fi)), from[fi], to[tj], opts)...)
 		fi++
 		tj++
@@ -443,9 +565,11 @@ func compareSequenceNodesUnordered(path DiffPath, fromN, toN *yaml.Node, opts *O
 			continue
 		}
 		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(fi)),
-			Type: DiffRemoved,
-			From: fromValues[fi],
+			Path:    path.Append(strconv.Itoa(fi)),
+			Type:    DiffRemoved,
+			From:    fromValues[fi],
+			FromPos: nodePosition(from[fi]),
+			ToPos:   containerPosition(toN),
 		})
 	}
 	for ; tj < len(to); tj++ {
@@ -453,9 +577,11 @@ func compareSequenceNodesUnordered(path DiffPath, fromN, toN *yaml.Node, opts *O
 			continue
 		}
 		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(tj)),
-			Type: DiffAdded,
-			To:   toValues[tj],
+			Path:    path.Append(strconv.Itoa(tj)),
+			Type:    DiffAdded,
+			To:      toValues[tj],
+			FromPos: containerPosition(fromN),
+			ToPos:   nodePosition(to[tj]),
 		})
 	}
 
@@ -501,9 +627,12 @@ func detectListOrderChanges(path DiffPath, fromIDs []any, fromIndex, toIndex map
 // compareUnidentifiedItems handles items in an identifier-matched list that
 // don't have a usable identifier. Falls back to unordered (deepEqual) match
 // then positional pairing on the remainder.
-func compareUnidentifiedItems(path DiffPath, from, to []*yaml.Node, fromNoID, toNoID []int, opts *Options) []Difference {
+func compareUnidentifiedItems(path DiffPath, fromN, toN *yaml.Node, fromNoID, toNoID []int, opts *Options) []Difference {
+	from := fromN.Content
+	to := toN.Content
 	fromNoIDMatched := make([]bool, len(fromNoID))
 	toNoIDMatched := make([]bool, len(toNoID))
+	var diffs []Difference
 
 	// Materialize only the unidentified items once for deepEqual reuse.
 	fromVals := make(map[int]any, len(fromNoID))
@@ -523,12 +652,12 @@ func compareUnidentifiedItems(path DiffPath, from, to []*yaml.Node, fromNoID, to
 			if deepEqual(fromVals[fromIdx], toVals[toIdx], opts) {
 				fromNoIDMatched[fi] = true
 				toNoIDMatched[tj] = true
+				diffs = append(diffs, compareEqualComments(path.Append(strconv.Itoa(fromIdx)), from[fromIdx], to[toIdx], opts)...)
 				break
 			}
 		}
 	}
 
-	var diffs []Difference
 	fi, tj := 0, 0
 	for fi < len(fromNoID) && tj < len(toNoID) {
 		if fromNoIDMatched[fi] {
@@ -539,7 +668,9 @@ func compareUnidentifiedItems(path DiffPath, from, to []*yaml.Node, fromNoID, to
 			tj++
 			continue
 		}
-		diffs = append(diffs, compareNodes(path.Append(strconv.Itoa(fromNoID[fi])), from[fromNoID[fi]], to[toNoID[tj]], opts)...)
+		itemPath := path.Append(strconv.Itoa(fromNoID[fi]))
+		diffs = append(diffs, compareComments(itemPath, nil, from[fromNoID[fi]], nil, to[toNoID[tj]], opts)...)
+		diffs = append(diffs, compareNodes(itemPath, from[fromNoID[fi]], to[toNoID[tj]], opts)...)
 		fi++
 		tj++
 	}
@@ -548,9 +679,11 @@ func compareUnidentifiedItems(path DiffPath, from, to []*yaml.Node, fromNoID, to
 			continue
 		}
 		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(fromNoID[fi])),
-			Type: DiffRemoved,
-			From: fromVals[fromNoID[fi]],
+			Path:    path.Append(strconv.Itoa(fromNoID[fi])),
+			Type:    DiffRemoved,
+			From:    fromVals[fromNoID[fi]],
+			FromPos: nodePosition(from[fromNoID[fi]]),
+			ToPos:   containerPosition(toN),
 		})
 	}
 	for ; tj < len(toNoID); tj++ {
@@ -558,28 +691,31 @@ func compareUnidentifiedItems(path DiffPath, from, to []*yaml.Node, fromNoID, to
 			continue
 		}
 		diffs = append(diffs, Difference{
-			Path: path.Append(strconv.Itoa(toNoID[tj])),
-			Type: DiffAdded,
-			To:   toVals[toNoID[tj]],
+			Path:    path.Append(strconv.Itoa(toNoID[tj])),
+			Type:    DiffAdded,
+			To:      toVals[toNoID[tj]],
+			FromPos: containerPosition(fromN),
+			ToPos:   nodePosition(to[toNoID[tj]]),
 		})
 	}
 	return diffs
 }
 
 // compareSequenceNodesByIdentifier compares sequences by their identifier
-// field (typically "name"/"id" or AdditionalIdentifiers). Items with matching
-// identifiers are diffed at the child path (dyff-style); unmatched items are
-// reported at the parent path.
+// field (typically "name"/"id" or AdditionalIdentifiers, or the fields of the
+// ListKey matching path). Items with matching identifiers are diffed at the
+// child path (dyff-style); unmatched items are reported at the parent path.
 func compareSequenceNodesByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
 	from := fromN.Content
 	to := toN.Content
+	ids := identityAt(path, opts)
 	var diffs []Difference
 
 	fromIndex := make(map[any]int, len(from))
 	fromIDs := make([]any, 0, len(from))
 	var fromNoID []int
 	for i, item := range from {
-		id := getIdentifierNode(item, opts)
+		id := ids.of(item)
 		if isComparableIdentifier(id) {
 			fromIndex[id] = i
 			fromIDs = append(fromIDs, id)
@@ -591,12 +727,12 @@ func compareSequenceNodesByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts
 	toIndex := make(map[any]int, len(to))
 	// toIDs[i] caches the comparable identifier for to[i] when present (nil
 	// otherwise), so the addition loop below can preserve to-side source
-	// order without re-running getIdentifierNode on every item.
+	// order without re-running ids.of on every item.
 	toIDs := make([]any, len(to))
 	var toNoID []int
 	toIDCount := 0
 	for i, item := range to {
-		id := getIdentifierNode(item, opts)
+		id := ids.of(item)
 		if isComparableIdentifier(id) {
 			toIDCount++
 			toIndex[id] = i
@@ -608,6 +744,8 @@ func compareSequenceNodesByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts
 
 	if !opts.IgnoreOrderChanges {
 		if orderDiff := detectListOrderChanges(path, fromIDs, fromIndex, toIndex, toIDCount); orderDiff != nil {
+			orderDiff.FromPos = nodePosition(fromN)
+			orderDiff.ToPos = nodePosition(toN)
 			diffs = append(diffs, *orderDiff)
 		}
 	}
@@ -619,16 +757,20 @@ func compareSequenceNodesByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts
 		toIdx, ok := toIndex[id]
 		if !ok {
 			diffs = append(diffs, Difference{
-				Path: path,
-				Type: DiffRemoved,
-				From: nodeToInterface(fromItem),
-				To:   nil,
+				Path:        path,
+				Type:        DiffRemoved,
+				From:        nodeToInterface(fromItem),
+				To:          nil,
+				FromPos:     nodePosition(fromItem),
+				ToPos:       containerPosition(toN),
+				pointerItem: sprintIdentifier(id),
 			})
 			continue
 		}
 		toItem := to[toIdx]
 		idStr := sprintIdentifier(id)
 		childPath := path.Append(idStr)
+		diffs = append(diffs, compareComments(childPath, nil, fromItem, nil, toItem, opts)...)
 		diffs = append(diffs, compareNodes(childPath, fromItem, toItem, opts)...)
 	}
 
@@ -643,16 +785,19 @@ func compareSequenceNodesByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts
 		}
 		if _, inFrom := fromIndex[id]; !inFrom {
 			diffs = append(diffs, Difference{
-				Path: path,
-				Type: DiffAdded,
-				From: nil,
-				To:   nodeToInterface(toItem),
+				Path:        path,
+				Type:        DiffAdded,
+				From:        nil,
+				To:          nodeToInterface(toItem),
+				FromPos:     containerPosition(fromN),
+				ToPos:       nodePosition(toItem),
+				pointerItem: "-",
 			})
 		}
 	}
 
 	// Fallback for items without usable identifiers.
-	diffs = append(diffs, compareUnidentifiedItems(path, from, to, fromNoID, toNoID, opts)...)
+	diffs = append(diffs, compareUnidentifiedItems(path, fromN, toN, fromNoID, toNoID, opts)...)
 
 	return diffs
 }
@@ -687,6 +832,7 @@ func getIdentifier(val any, opts *Options) any {
 
 // equalValues compares two scalar values for equality, honoring the relevant
 // Options flags (FormatStrings JSON-canonical compare, IgnoreWhitespaceChanges).
+// Ints and floats are equal when their numeric values are.
 func equalValues(from, to any, opts *Options) bool {
 	if opts != nil {
 		if fromStr, ok := from.(string); ok {
@@ -710,6 +856,17 @@ func equalValues(from, to any, opts *Options) bool {
 		}
 	}
 
+	// An int and a float are compared as numbers, so 1 equals 1.0.
+	switch f := from.(type) {
+	case int:
+		if t, ok := to.(float64); ok {
+			return float64(f) == t
+		}
+	case float64:
+		if t, ok := to.(int); ok {
+			return f == float64(t)
+		}
+	}
 	return from == to
 }
 
@@ -294,8 +294,8 @@ func TestCompare_NullAndTypeChanges(t *testing.T) {
 				if len(diffs) < 1 {
 					t.Fatal("expected at least 1 diff")
 				}
-				if !hasDiffType(diffs, diffyml.DiffModified) {
-					t.Error("expected type change to be reported as modification")
+				if !hasDiffType(diffs, diffyml.DiffTypeChanged) {
+					t.Error("expected type change to be reported as type change")
 				}
 			},
 		},
@@ -2539,8 +2539,8 @@ foo:
 	if len(diffs) == 0 {
 		t.Fatal("expected diffs for map-to-list type change, got 0")
 	}
-	if !hasDiffType(diffs, diffyml.DiffModified) {
-		t.Error("expected DiffModified for type change from map to list")
+	if !hasDiffType(diffs, diffyml.DiffTypeChanged) {
+		t.Error("expected DiffTypeChanged for type change from map to list")
 	}
 }
 
@@ -2562,6 +2562,63 @@ bar:`)
 	}
 }
 
+func TestCompare_TypeChanged(t *testing.T) {
+	tests := []struct {
+		name             string
+		from, to         string
+		fromType, toType string
+	}{
+		{"quoted string to int", `port: "8080"`, `port: 8080`, "string", "int"},
+		{"timestamp to string", `at: 2026-01-01`, `at: "2026-01-01"`, "timestamp", "string"},
+		{"bool to string", `enabled: true`, `enabled: "yes"`, "bool", "string"},
+		{"scalar to map", `value: hello`, "value:\n  nested: data", "string", "map"},
+		{"list to scalar", "value:\n  - a", `value: a`, "list", "string"},
+		{"custom tag", `ref: !Ref bucket`, `ref: bucket`, "!Ref", "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := compare(yml(tt.from), yml(tt.to), nil)
+			if err != nil {
+				t.Fatalf("compare() failed: %v", err)
+			}
+			if len(diffs) != 1 {
+				t.Fatalf("expected 1 diff, got %d: %+v", len(diffs), diffs)
+			}
+			d := diffs[0]
+			if d.Type != diffyml.DiffTypeChanged || d.FromType != tt.fromType || d.ToType != tt.toType {
+				t.Errorf("got type %v %q → %q, want DiffTypeChanged %q → %q", d.Type, d.FromType, d.ToType, tt.fromType, tt.toType)
+			}
+		})
+	}
+}
+
+func TestCompare_SameTypeStaysModified(t *testing.T) {
+	diffs, err := compare(yml(`port: 8080`), yml(`port: 9090`), nil)
+	if err != nil {
+		t.Fatalf("compare() failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Type != diffyml.DiffModified || diffs[0].FromType != "" || diffs[0].ToType != "" {
+		t.Errorf("expected one DiffModified without types, got %+v", diffs)
+	}
+}
+
+func TestCompare_IntAndFloatAreOneType(t *testing.T) {
+	diffs, err := compare(yml(`ratio: 1`), yml(`ratio: 1.0`), nil)
+	if err != nil {
+		t.Fatalf("compare() failed: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected 1 and 1.0 to be equal, got %+v", diffs)
+	}
+	diffs, err = compare(yml(`ratio: 1`), yml(`ratio: 1.5`), nil)
+	if err != nil {
+		t.Fatalf("compare() failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Type != diffyml.DiffModified {
+		t.Errorf("expected one DiffModified, got %+v", diffs)
+	}
+}
+
 func TestCompare_EmptyDocumentsIgnored(t *testing.T) {
 	// Empty documents (extra --- separators) are filtered by the CLI pipeline.
 	// At the Compare level, they cause positional mismatches.
@@ -0,0 +1,131 @@
+// decode.go - Decoded comparison of base64 payloads (Options.DecodeBase64).
+//
+// Kubernetes stores Secret data and ConfigMap binaryData base64-encoded, so a
+// changed password or registry credential shows as two unreadable blobs.
+// Under DecodeBase64, two differing values of a Secret's data, a ConfigMap's
+// binaryData, or a !!binary scalar are decoded before comparison: text is
+// compared as text, a YAML or JSON payload such as .dockerconfigjson
+// structurally below the value's path (see parseEmbedded), and anything else
+// is summarized by its size and SHA-256. Masking runs on the decoded
+// differences like on any other, so MaskSecrets still hides Secret data.
+// Patches never write the decoded text: they replace the encoded value whole
+// (see markEmbedded).
+// Key functions: compareDecoded, decodeBase64Node, decodedValue.
+package diffyml
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// compareDecoded compares the base64 values fromN and toN decoded, when
+// DecodeBase64 applies to path (see decodesAt) and both decode; ok is false
+// otherwise. Values that decode to the same bytes, differing only in line
+// wrapping, are equal.
+func compareDecoded(path DiffPath, fromN, toN *yaml.Node, opts *Options) (diffs []Difference, ok bool) {
+	if !decodesAt(path, fromN, toN, opts) {
+		return nil, false
+	}
+	from, ok := decodeBase64Node(fromN)
+	if !ok {
+		return nil, false
+	}
+	to, ok := decodeBase64Node(toN)
+	if !ok {
+		return nil, false
+	}
+	if bytes.Equal(from, to) {
+		return nil, true
+	}
+	fromVal, toVal := decodedValue(from), decodedValue(to)
+	if fromText, ok := fromVal.(string); ok {
+		if toText, ok := toVal.(string); ok {
+			fromDoc := parseEmbedded(fromText, fromN, false)
+			toDoc := parseEmbedded(toText, toN, false)
+			if fromDoc != nil && toDoc != nil {
+				return compareNodes(path, fromDoc, toDoc, opts), true
+			}
+		}
+	}
+	if opts.IgnoreValueChanges {
+		return nil, true
+	}
+	return []Difference{{
+		Path:    path,
+		Type:    DiffModified,
+		From:    fromVal,
+		To:      toVal,
+		FromPos: nodePosition(fromN),
+		ToPos:   nodePosition(toN),
+	}}, true
+}
+
+// decodesAt reports whether the values at path are base64 payloads to decode:
+// a !!binary scalar on either side, or an entry of a Secret's data or a
+// ConfigMap's binaryData. The resource kind is known only under
+// DetectKubernetes.
+func decodesAt(path DiffPath, fromN, toN *yaml.Node, opts *Options) bool {
+	if !opts.DecodeBase64 {
+		return false
+	}
+	if fromN.ShortTag() == "!!binary" || toN.ShortTag() == "!!binary" {
+		return true
+	}
+	if _, rest, ok := path.DocIndexPrefix(); ok {
+		path = rest
+	}
+	if len(path) != 2 {
+		return false
+	}
+	switch opts.k8sKind {
+	case "Secret":
+		return path[0] == "data"
+	case "ConfigMap":
+		return path[0] == "binaryData"
+	}
+	return false
+}
+
+// decodeBase64Node decodes a string or !!binary scalar holding standard
+// base64, which may be wrapped across lines.
+func decodeBase64Node(n *yaml.Node) ([]byte, bool) {
+	if n.Kind != yaml.ScalarNode || (n.ShortTag() != "!!str" && n.ShortTag() != "!!binary") {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(n.Value), ""))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// decodedValue returns a decoded payload as the value of a difference: the
+// text itself when it is printable UTF-8, else a summary naming its size and
+// SHA-256 in hex: "<binary: N bytes, sha256:HEX>".
+func decodedValue(b []byte) any {
+	if isPrintableText(b) {
+		return string(b)
+	}
+	return fmt.Sprintf("<binary: %d bytes, sha256:%x>", len(b), sha256.Sum256(b))
+}
+
+// isPrintableText reports whether b is UTF-8 text without control characters
+// other than tabs and line breaks.
+func isPrintableText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package diffyml
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func secretDoc(kind, field string, entries ...string) string {
+	doc := "apiVersion: v1\nkind: " + kind + "\nmetadata:\n  name: app\n" + field + ":\n"
+	for i := 0; i+1 < len(entries); i += 2 {
+		doc += "  " + entries[i] + ": " + entries[i+1] + "\n"
+	}
+	return doc
+}
+
+func TestCompare_DecodeBase64(t *testing.T) {
+	from := secretDoc("Secret", "data",
+		"password", b64("hunter2"),
+		".dockerconfigjson", b64(`{"auths":{"registry.example.com":{"username":"ci"}}}`),
+		"keystore", b64("\x00\x01old"),
+		"wrapped", b64("same"))
+	to := secretDoc("Secret", "data",
+		"password", b64("correct-horse"),
+		".dockerconfigjson", b64(`{"auths":{"registry.example.com":{"username":"deploy"}}}`),
+		"keystore", b64("\x00\x01new"),
+		"wrapped", "|\n    "+b64("same"))
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, DecodeBase64: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	got := make(map[string]any)
+	for _, d := range diffs {
+		got[d.Path.String()] = d.To
+	}
+	want := map[string]any{
+		"data.password": "correct-horse",
+		"data[.dockerconfigjson].auths[registry.example.com].username": "deploy",
+		"data.keystore": fmt.Sprintf("<binary: 5 bytes, sha256:%x>", sha256.Sum256([]byte("\x00\x01new"))),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompare_DecodeBase64Scope(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		opts     Options
+		want     any
+	}{
+		{
+			name: "ConfigMap binaryData",
+			from: secretDoc("ConfigMap", "binaryData", "logo.txt", b64("v1")),
+			to:   secretDoc("ConfigMap", "binaryData", "logo.txt", b64("v2")),
+			opts: Options{DetectKubernetes: true, DecodeBase64: true},
+			want: "v2",
+		},
+		{
+			name: "ConfigMap data is text",
+			from: secretDoc("ConfigMap", "data", "key", b64("v1")),
+			to:   secretDoc("ConfigMap", "data", "key", b64("v2")),
+			opts: Options{DetectKubernetes: true, DecodeBase64: true},
+			want: b64("v2"),
+		},
+		{
+			name: "binary tag",
+			from: "blob: !!binary " + b64("v1") + "\n",
+			to:   "blob: !!binary " + b64("v2") + "\n",
+			opts: Options{DecodeBase64: true},
+			want: "v2",
+		},
+		{
+			name: "invalid base64",
+			from: secretDoc("Secret", "data", "key", "not-base64!"),
+			to:   secretDoc("Secret", "data", "key", b64("v2")),
+			opts: Options{DetectKubernetes: true, DecodeBase64: true},
+			want: b64("v2"),
+		},
+		{
+			name: "off",
+			from: secretDoc("Secret", "data", "key", b64("v1")),
+			to:   secretDoc("Secret", "data", "key", b64("v2")),
+			opts: Options{DetectKubernetes: true},
+			want: b64("v2"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare([]byte(tt.from), []byte(tt.to), &tt.opts)
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			if len(diffs) != 1 || !reflect.DeepEqual(diffs[0].To, tt.want) {
+				t.Errorf("got %v, want one change to %v", setDiffs(diffs), tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskDifferences_DecodedSecretData(t *testing.T) {
+	from := secretDoc("Secret", "data",
+		"password", b64("hunter2"),
+		".dockerconfigjson", b64(`{"auths":{"r":{"username":"ci"}}}`))
+	to := secretDoc("Secret", "data",
+		"password", b64("correct-horse"),
+		".dockerconfigjson", b64(`{"auths":{"r":{"username":"deploy"}}}`))
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, DecodeBase64: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	masked, err := MaskDifferences(diffs, MaskOptions{MaskSecrets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(masked) != 2 {
+		t.Fatalf("got %v, want two differences", setDiffs(masked))
+	}
+	for _, d := range masked {
+		if d.From != DefaultMaskPlaceholder || d.To != DefaultMaskPlaceholder {
+			t.Errorf("%s = %v → %v, want it masked", d.Path, d.From, d.To)
+		}
+	}
+}
+
+func TestApplyPatch_DecodeBase64RoundTrip(t *testing.T) {
+	from := secretDoc("Secret", "data",
+		"password", b64("hunter2"),
+		".dockerconfigjson", b64(`{"auths":{"registry.example.com":{"username":"ci"}}}`),
+		"keystore", b64("\x00\x01old"))
+	to := secretDoc("Secret", "data",
+		"password", b64("correct-horse"),
+		".dockerconfigjson", b64(`{"auths":{"registry.example.com":{"username":"deploy"}}}`),
+		"keystore", b64("\x00\x01new"))
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, DecodeBase64: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, format := range []string{"json", "json-patch"} {
+		t.Run(format, func(t *testing.T) {
+			f, err := FormatterByName(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch := f.Format(diffs, &FormatOptions{})
+			if format == "json-patch" && (strings.Contains(patch, "correct-horse") || strings.Contains(patch, "/auths")) {
+				t.Errorf("decoded values in the patch:\n%s", patch)
+			}
+			got := applyPatch(t, from, patch)
+			rest, err := Compare([]byte(got), []byte(to), &Options{DetectKubernetes: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("patched file still differs from target: %v\n%s", setDiffs(rest), got)
+			}
+		})
+	}
+}
@@ -44,6 +44,8 @@ func (f *DetailedFormatter) Format(diffs []Difference, opts *FormatOptions) stri
 	groups := f.groupByPath(diffs)
 	for _, group := range groups {
 		f.formatPathHeading(&sb, group.Path, group.Diffs[0].DocumentName, isMultiDoc, opts)
+		sb.WriteString(mappedSuffix(group.Diffs[0], opts.UseGoPatchStyle))
+		sb.WriteString("\n")
 		f.formatGroupDiffs(&sb, group, opts)
 	}
 
@@ -60,9 +62,17 @@ func (f *DetailedFormatter) formatHeader(sb *strings.Builder, diffs []Difference
 			formatCount(len(diffs)),
 			pluralize(len(diffs), "unchanged value", "unchanged values"))
 	} else {
-		fmt.Fprintf(sb, "Found %s %s",
-			formatCount(len(diffs)),
-			pluralize(len(diffs), "difference", "differences"))
+		warnings := 0
+		for _, d := range diffs {
+			if d.Type == DiffWarning {
+				warnings++
+			}
+		}
+		n := len(diffs) - warnings
+		fmt.Fprintf(sb, "Found %s %s", formatCount(n), pluralize(n, "difference", "differences"))
+		if warnings > 0 {
+			fmt.Fprintf(sb, ", %s %s", formatCount(warnings), pluralize(warnings, "warning", "warnings"))
+		}
 	}
 	sb.WriteString(colorEnd(opts))
 	sb.WriteString("\n\n")
@@ -106,7 +116,8 @@ func pathString(path DiffPath, goPatch bool) string {
 	return path.String()
 }
 
-// formatPathHeading renders the path line for a group of diffs.
+// formatPathHeading renders the path line for a group of diffs, without its
+// line break.
 func (f *DetailedFormatter) formatPathHeading(sb *strings.Builder, path DiffPath, docName string, isMultiDoc bool, opts *FormatOptions) {
 	if path.IsEmpty() {
 		if opts.UseGoPatchStyle {
@@ -134,7 +145,6 @@ func (f *DetailedFormatter) formatPathHeading(sb *strings.Builder, path DiffPath
 			f.writeDocLabel(sb, docName, opts)
 		}
 	}
-	sb.WriteString("\n")
 }
 
 // writeBold writes text in bold style.
@@ -232,8 +242,10 @@ func (f *DetailedFormatter) formatEntryBatch(sb *strings.Builder, diffs []Differ
 			val = diff.From
 		}
 		if !opts.NoCertInspection {
-			if s, ok := val.(string); ok && IsPEMCertificate(s) {
-				val = FormatCertificate(s)
+			if s, ok := val.(string); ok {
+				if summary, ok := SummarizePEM(s); ok {
+					val = summary
+				}
 			}
 		}
 		if isDocLevel {
@@ -250,6 +262,8 @@ func (f *DetailedFormatter) formatChangeDescriptor(sb *strings.Builder, diff Dif
 	switch diff.Type {
 	case DiffModified:
 		f.formatModified(sb, diff, opts)
+	case DiffTypeChanged:
+		f.formatTypeChange(sb, diff.FromType, diff.ToType, diff, opts)
 	case DiffOrderChanged:
 		f.writeDescriptorLine(sb, "  ⇆ order changed", f.colorModified, opts)
 		if diff.From != nil {
@@ -259,26 +273,47 @@ func (f *DetailedFormatter) formatChangeDescriptor(sb *strings.Builder, diff Dif
 			f.writeColoredLine(sb, fmt.Sprintf("    + %s", formatCommaSeparated(diff.To)), f.colorAdded(opts), opts)
 		}
 		sb.WriteString("\n")
+	case DiffCommentChanged:
+		f.writeDescriptorLine(sb, fmt.Sprintf("  # %s comment changed", diff.Comment), f.colorModified, opts)
+		f.writeCommentLines(sb, diff.From, "-", f.colorRemoved(opts), opts)
+		f.writeCommentLines(sb, diff.To, "+", f.colorAdded(opts), opts)
+		sb.WriteString("\n")
+	case DiffMoved:
+		f.writeDescriptorLine(sb, "  → moved from "+sectionPathLabel(diff.FromPath, opts), f.colorModified, opts)
+		sb.WriteString("\n")
+	case DiffWarning:
+		f.writeDescriptorLine(sb, "  ⚠ "+diff.Warning, f.colorModified, opts)
+		sb.WriteString("\n")
+	}
+}
+
+// writeCommentLines writes one side of a comment change line by line. A side
+// without a comment writes nothing, like an absent order-change list.
+func (f *DetailedFormatter) writeCommentLines(sb *strings.Builder, val any, symbol, color string, opts *FormatOptions) {
+	c, ok := val.(string)
+	if !ok {
+		return
+	}
+	for _, line := range strings.Split(c, "\n") {
+		f.writeColoredLine(sb, fmt.Sprintf("    %s %s", symbol, line), color, opts)
 	}
 }
 
 // formatModified renders a modification descriptor with type change, multiline, and whitespace detection.
 func (f *DetailedFormatter) formatModified(sb *strings.Builder, diff Difference, opts *FormatOptions) {
+	// A normalized quantity or duration: the values may be of different
+	// YAML types ("1" and "1500m"), but the change is in amount.
+	if diff.Delta != "" {
+		f.writeValueChange(sb, formatDetailedValue(diff.From), formatDetailedValue(diff.To), diff.Delta, opts)
+		return
+	}
+
 	fromType := yamlTypeName(diff.From)
 	toType := yamlTypeName(diff.To)
 
 	// Type change detection
 	if fromType != toType {
-		if opts.Color {
-			f.writeDescriptorLine(sb, fmt.Sprintf("  ± type change from %s%s%s to %s%s%s",
-				styleItalic, fromType, styleItalicOff,
-				styleItalic, toType, styleItalicOff), f.colorModified, opts)
-		} else {
-			f.writeDescriptorLine(sb, fmt.Sprintf("  ± type change from %s to %s", fromType, toType), f.colorModified, opts)
-		}
-		f.writeTypeChangeValue(sb, diff.From, "-", f.colorRemoved(opts), opts)
-		f.writeTypeChangeValue(sb, diff.To, "+", f.colorAdded(opts), opts)
-		sb.WriteString("\n")
+		f.formatTypeChange(sb, fromType, toType, diff, opts)
 		return
 	}
 
@@ -287,10 +322,21 @@ func (f *DetailedFormatter) formatModified(sb *strings.Builder, diff Difference,
 	toStr, toOk := diff.To.(string)
 
 	if fromOk && toOk {
-		// Certificate inspection: transform PEM certs to single-line summaries
-		if !opts.NoCertInspection && IsPEMCertificate(fromStr) && IsPEMCertificate(toStr) {
-			fromStr = FormatCertificate(fromStr)
-			toStr = FormatCertificate(toStr)
+		// Certificate inspection: the changed fields of two certificates or
+		// bundles, else single-line summaries of two certificates whose
+		// fields all match, or of two keys or certificate requests
+		if !opts.NoCertInspection {
+			if changes, ok := CompareCertificates(fromStr, toStr); ok {
+				f.formatCertChanges(sb, certKind(fromStr, toStr), changes, opts)
+				return
+			}
+		}
+		if !opts.NoCertInspection {
+			fromSummary, fromPEM := SummarizePEM(fromStr)
+			toSummary, toPEM := SummarizePEM(toStr)
+			if fromPEM && toPEM {
+				fromStr, toStr = fromSummary, toSummary
+			}
 		}
 
 		// Multiline detection (before whitespace-only check, so multiline
@@ -311,12 +357,26 @@ func (f *DetailedFormatter) formatModified(sb *strings.Builder, diff Difference,
 		}
 
 		// Scalar string value change (may be cert-transformed)
-		f.writeValueChange(sb, fromStr, toStr, opts)
+		f.writeValueChange(sb, fromStr, toStr, "", opts)
 		return
 	}
 
 	// Default: non-string scalar value change
-	f.writeValueChange(sb, formatDetailedValue(diff.From), formatDetailedValue(diff.To), opts)
+	f.writeValueChange(sb, formatDetailedValue(diff.From), formatDetailedValue(diff.To), "", opts)
+}
+
+// formatTypeChange renders a type change descriptor followed by both values.
+func (f *DetailedFormatter) formatTypeChange(sb *strings.Builder, fromType, toType string, diff Difference, opts *FormatOptions) {
+	if opts.Color {
+		f.writeDescriptorLine(sb, fmt.Sprintf("  ± type change from %s%s%s to %s%s%s",
+			styleItalic, fromType, styleItalicOff,
+			styleItalic, toType, styleItalicOff), f.colorModified, opts)
+	} else {
+		f.writeDescriptorLine(sb, fmt.Sprintf("  ± type change from %s to %s", fromType, toType), f.colorModified, opts)
+	}
+	f.writeTypeChangeValue(sb, diff.From, "-", f.colorRemoved(opts), opts)
+	f.writeTypeChangeValue(sb, diff.To, "+", f.colorAdded(opts), opts)
+	sb.WriteString("\n")
 }
 
 // detectMultiDoc checks if diffs span multiple documents by examining DocumentIndex values.
@@ -334,9 +394,13 @@ func (f *DetailedFormatter) detectMultiDoc(diffs []Difference) bool {
 
 // writeValueChange writes a "± value change" block with inline diff highlighting
 // when color is enabled and the values are similar enough, otherwise falls back
-// to plain colored lines.
-func (f *DetailedFormatter) writeValueChange(sb *strings.Builder, from, to string, opts *FormatOptions) {
-	f.writeDescriptorLine(sb, "  ± value change", f.colorModified, opts)
+// to plain colored lines. A non-empty delta is named in the descriptor.
+func (f *DetailedFormatter) writeValueChange(sb *strings.Builder, from, to, delta string, opts *FormatOptions) {
+	descriptor := "  ± value change"
+	if delta != "" {
+		descriptor += " by " + delta
+	}
+	f.writeDescriptorLine(sb, descriptor, f.colorModified, opts)
 	if opts.Color {
 		if fromSegs, toSegs := computeInlineDiff(from, to); fromSegs != nil {
 			f.writeInlineDiffLine(sb, "    - ", fromSegs, ColorRoleRemoved, opts)
@@ -350,6 +414,23 @@ func (f *DetailedFormatter) writeValueChange(sb *strings.Builder, from, to strin
 	sb.WriteString("\n")
 }
 
+// formatCertChanges renders the field changes between two certificates or
+// bundles (see CompareCertificates), one per line.
+func (f *DetailedFormatter) formatCertChanges(sb *strings.Builder, kind string, changes []Difference, opts *FormatOptions) {
+	f.writeDescriptorLine(sb, "  ± "+kind+" change", f.colorModified, opts)
+	for _, c := range changes {
+		color := f.colorModified(opts)
+		switch c.Type {
+		case DiffAdded:
+			color = f.colorAdded(opts)
+		case DiffRemoved:
+			color = f.colorRemoved(opts)
+		}
+		f.writeColoredLine(sb, "    "+certChangeLine(c), color, opts)
+	}
+	sb.WriteString("\n")
+}
+
 // writeColoredLine writes a line with color code and newline.
 func (f *DetailedFormatter) writeColoredLine(sb *strings.Builder, text string, code string, opts *FormatOptions) {
 	sb.WriteString(colorStart(opts, code))
@@ -53,18 +53,22 @@ func TestDetailedFormatter_CertInspection_ModifiedCerts(t *testing.T) {
 	}
 	output := f.Format(diffs, opts)
 
-	// Should show Certificate(...) summaries, not raw PEM
-	if !strings.Contains(output, "Certificate(") {
-		t.Errorf("expected Certificate() summary in output, got:\n%s", output)
-	}
+	// Should show the changed fields, not raw PEM
 	if strings.Contains(output, "-----BEGIN CERTIFICATE-----") {
 		t.Errorf("expected no raw PEM in output, got:\n%s", output)
 	}
-	if !strings.Contains(output, "old.example.com") {
-		t.Errorf("expected old cert CN in output, got:\n%s", output)
-	}
-	if !strings.Contains(output, "new.example.com") {
-		t.Errorf("expected new cert CN in output, got:\n%s", output)
+	for _, want := range []string{
+		"± certificate change",
+		"± subject: CN=old.example.com → CN=new.example.com",
+		"± notAfter: 2025-12-31T00:00:00Z → 2026-12-31T00:00:00Z",
+		"± serial: aabb → ccdd",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "keySize") {
+		t.Errorf("expected unchanged fields to be left out, got:\n%s", output)
 	}
 }
 
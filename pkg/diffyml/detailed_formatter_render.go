@@ -202,7 +202,7 @@ func (f *DetailedFormatter) writeTypeChangeValue(sb *strings.Builder, val any, s
 			f.writeColoredLine(sb, fmt.Sprintf("    %s %s", symbol, line), colorCode, opts)
 		}
 	} else {
-		f.writeColoredLine(sb, fmt.Sprintf("    %s %v", symbol, formatDetailedValue(val)), colorCode, opts)
+		f.writeColoredLine(sb, fmt.Sprintf("    %s %s", symbol, formatDetailedValue(typeChangeOperand(val))), colorCode, opts)
 	}
 }
 
@@ -31,7 +31,7 @@ func TestDetailedFormatter_Snapshot_TypeChange(t *testing.T) {
 	}
 
 	output := f.Format(diffs, opts)
-	expected := "config.port\n  ± type change from int to string\n    - 8080\n    + 8080\n\n"
+	expected := "config.port\n  ± type change from int to string\n    - 8080\n    + \"8080\"\n\n"
 	if output != expected {
 		t.Errorf("snapshot mismatch for type change.\nExpected:\n%s\nGot:\n%s", expected, output)
 	}
@@ -259,7 +259,7 @@ func TestDetailedFormatter_Integration_NoRegressionSnapshots(t *testing.T) {
 		{
 			name:     "type change",
 			diffs:    []Difference{{Path: DiffPath{"port"}, Type: DiffModified, From: 8080, To: "8080"}},
-			expected: "port\n  ± type change from int to string\n    - 8080\n    + 8080\n\n",
+			expected: "port\n  ± type change from int to string\n    - 8080\n    + \"8080\"\n\n",
 		},
 		{
 			name:     "list entry added",
@@ -338,7 +338,7 @@ func TestDetailedFormatter_Snapshot_FullComparison(t *testing.T) {
 	expected := "config.timeout\n  ± value change\n    - 30\n    + 60\n\n" +
 		"config.verbose\n  + one map entry added:\n    verbose: true\n\n" +
 		"services.0\n  + one list entry added:\n    - name: nginx\n      port: 80\n\n" +
-		"config.port\n  ± type change from int to string\n    - 8080\n    + 8080\n\n" +
+		"config.port\n  ± type change from int to string\n    - 8080\n    + \"8080\"\n\n" +
 		"items\n  ⇆ order changed\n    - a, b\n    + b, a\n\n"
 	if output != expected {
 		t.Errorf("full comparison snapshot mismatch.\nExpected:\n%s\nGot:\n%s", expected, output)
@@ -1039,7 +1039,7 @@ func TestDetailedFormatter_TrailingSeparator_TypeChange(t *testing.T) {
 	}
 
 	output := f.Format(diffs, opts)
-	expected := "port\n  ± type change from int to string\n    - 8080\n    + 8080\n\n"
+	expected := "port\n  ± type change from int to string\n    - 8080\n    + \"8080\"\n\n"
 	if output != expected {
 		t.Errorf("type change should end with blank line separator.\nExpected:\n%s\nGot:\n%s", expected, output)
 	}
@@ -24,13 +24,31 @@ const (
 	// DiffUnchanged indicates a value equal between both documents. Only emitted
 	// in inverse mode (Options.Unchanged); the normal comparison never reports it.
 	DiffUnchanged
+	// DiffCommentChanged indicates a YAML comment changed while the value it
+	// belongs to matched. Only emitted with Options.CompareComments; From and To
+	// hold the comment text, nil where there was none, and Comment names the slot.
+	DiffCommentChanged
+	// DiffTypeChanged indicates a value was changed to a value of another YAML
+	// type: a scalar became a map, or the string "8080" the integer 8080.
+	// FromType and ToType name the two types.
+	DiffTypeChanged
+	// DiffMoved indicates a subtree moved or renamed within a document: Path
+	// is its new path and FromPath its old one. Only emitted with
+	// Options.DetectMoves; the changes inside the subtree follow as
+	// differences below Path.
+	DiffMoved
+	// DiffWarning reports a finding about a value of the target document
+	// rather than a change: Warning describes it, and From and To are nil.
+	// Only emitted with Options.CertPolicy.
+	DiffWarning
 )
 
 // Difference represents a single change between two YAML documents.
 type Difference struct {
 	// Path is the structured path to the changed value (e.g., DiffPath{"some", "yaml", "structure", "name"}).
 	Path DiffPath
-	// Type indicates the kind of change (added, removed, modified, order changed).
+	// Type indicates the kind of change (added, removed, modified, type changed,
+	// order changed, comment changed).
 	Type DiffType
 	// From is the original value (nil for additions).
 	From any
@@ -46,12 +64,54 @@ type Difference struct {
 	// masking to identify Secret resources without parsing DocumentName, since apiVersion
 	// can itself contain "/" (e.g., "apps/v1").
 	DocumentKind string
+	// FromPos locates the change in the from document: the original value,
+	// or for an addition the container the entry was added to. Nil when that
+	// side has no source node (e.g. a whole document added to the stream).
+	FromPos *Position
+	// ToPos locates the change in the to document: the new value, or for a
+	// removal the container the entry was removed from. Nil when that side
+	// has no source node.
+	ToPos *Position
+	// Comment names the comment slot of a DiffCommentChanged difference (head,
+	// line or foot). Empty for every other type.
+	Comment CommentKind
+	// Delta is the signed, normalized difference between a quantity or
+	// duration compared under Options.NormalizeQuantities, such as "+500m" or
+	// "-512Mi". Empty for every other difference.
+	Delta string
+	// FromType and ToType name the YAML types of a DiffTypeChanged difference,
+	// such as "string", "int" or "map" (see nodeTypeName). Empty for every
+	// other type.
+	FromType, ToType string
+	// FromPath is the path in the from document where it differs from Path:
+	// the old path of a DiffMoved difference, or the from-side path of a
+	// difference inside a subtree moved by Options.PathMappings. Nil
+	// otherwise.
+	FromPath DiffPath
+	// Warning describes the finding of a DiffWarning, such as `certificate
+	// "api.example.com" expired on 2026-01-31`. Empty for every other type.
+	Warning string
 	// listEntry marks a collapsed DiffUnchanged entry whose immediate container
-	// is a sequence (inverse mode only). The normal added/removed path infers
-	// list-vs-map from the value shape via hasIdentifierField, but inverse mode
-	// emits raw collapsed values, so the walk records the container kind directly
-	// for isListEntryDiff. Unexported: only the inverse walk sets it.
+	// is a sequence (inverse mode), a set element added or removed at its
+	// list's path, or a whole item added to or removed from an unidentified
+	// list (JSON output reports it as list_item). The normal added/removed path infers list-vs-map from the
+	// value shape via hasIdentifierField, but these carry raw values, so the
+	// comparator records the container kind directly for isListEntryDiff.
 	listEntry bool
+	// pointerItem is the JSON Pointer segment json-patch output appends to the
+	// list path of an item added or removed at its list's path — a set
+	// element (Options.SetLists) or an item of a list matched by identifier:
+	// the element's index in the from list or the item's identifier when
+	// removed, "-" when added. Empty otherwise.
+	pointerItem string
+	// entry holds the value node of an added or removed mapping entry under
+	// Options.DetectMoves, the candidates detectMoves pairs. Cleared once
+	// detectMoves has run.
+	entry *yaml.Node
+	// within is the string the difference lies within under
+	// Options.DescendEmbedded or Options.DecodeBase64, which patches replace
+	// whole. Nil otherwise.
+	within *embeddedString
 }
 
 // Options configures the comparison behavior.
@@ -63,18 +123,71 @@ type Options struct {
 	// FormatStrings canonicalizes embedded JSON strings before comparison.
 	// When true, if both values parse as valid JSON, formatting-only differences are ignored.
 	FormatStrings bool
+	// DescendEmbedded compares two differing strings that both embed a YAML
+	// or JSON map or list, such as a ConfigMap's config.yaml, structurally,
+	// reporting the changes inside at paths below the string's.
+	DescendEmbedded bool
+	// DecodeBase64 compares the base64 values of Secret data, ConfigMap
+	// binaryData and !!binary scalars decoded: as text, structurally when they
+	// hold YAML or JSON, or by size and SHA-256 when they are binary. Secret
+	// and ConfigMap values are recognized under DetectKubernetes only.
+	DecodeBase64 bool
 	// IgnoreValueChanges excludes value changes from the report when true.
 	IgnoreValueChanges bool
 	// DetectKubernetes enables Kubernetes resource structure detection.
 	DetectKubernetes bool
 	// DetectRenames enables document-level rename detection for Kubernetes resources.
 	DetectRenames bool
+	// DetectMoves reports a map or list removed at one key and added at
+	// another within the same document, with similar content, as one
+	// DiffMoved followed by the changes inside it.
+	DetectMoves bool
 	// IgnoreApiVersion omits apiVersion from K8s resource identifiers when matching.
 	IgnoreApiVersion bool
 	// AdditionalIdentifiers specifies additional fields to use as identifiers in named entry lists.
 	AdditionalIdentifiers []string
+	// ListKeys declares the identifier fields of lists by path pattern,
+	// overriding AdditionalIdentifiers and the name/id default for the lists
+	// they match, and, in Kubernetes resources, the built-in list keys. The
+	// first matching key applies.
+	ListKeys []ListKey
+	// SetLists compares every list of scalars as a multiset: elements present
+	// on one side only are reported as DiffAdded or DiffRemoved at the list's
+	// path, one difference per surplus occurrence, and order is ignored.
+	SetLists bool
+	// SetListPaths applies SetLists to the lists whose path matches one of
+	// these patterns only, matched like ListKey.Path.
+	SetListPaths []string
+	// Schema supplies the list types that CRD and OpenAPI v3 schemas declare
+	// for Kubernetes resources (see ParseSchema): "map" lists are matched by
+	// their list-map keys, "set" lists regardless of order, and changes to
+	// "atomic" lists are reported as a replacement of the whole list. It
+	// applies under DetectKubernetes, after ListKeys and before the built-in
+	// list keys.
+	Schema *Schema
+	// NormalizeQuantities compares Kubernetes resource quantities ("500m" and
+	// "0.5", "1Gi" and "1024Mi") and durations ("60s" and "1m") by value at
+	// well-known paths such as resources.requests and limits, probe timings
+	// and terminationGracePeriodSeconds, and at QuantityPaths and
+	// DurationPaths. Values that still differ carry Difference.Delta.
+	NormalizeQuantities bool
+	// QuantityPaths declares additional dot-notation paths holding Kubernetes
+	// quantities under NormalizeQuantities. Paths are matched with any leading
+	// document index stripped; prefix matches are honored.
+	QuantityPaths []string
+	// DurationPaths declares additional paths holding Go durations or bare
+	// numbers of seconds, matched like QuantityPaths.
+	DurationPaths []string
+	// CompareComments reports changed head, line and foot comments as
+	// DiffCommentChanged differences. Off by default: comments carry no value.
+	CompareComments bool
 	// NoCertInspection disables x509 certificate inspection, comparing as raw text.
 	NoCertInspection bool
+	// CertPolicy checks every certificate of the target document, changed or
+	// not, reporting one that has expired or expires within the policy's
+	// window, has a weak key or a SHA-1 signature, or is newly self-signed as
+	// a DiffWarning (see CertPolicy). Nil disables the checks.
+	CertPolicy *CertPolicy
 	// Swap reverses the from/to comparison.
 	Swap bool
 	// Unchanged inverts the report: instead of differences, emit the keys/values
@@ -89,6 +202,22 @@ type Options struct {
 	ChrootTo string
 	// ChrootListToDocuments treats list items as separate documents when chroot points to a list.
 	ChrootListToDocuments bool
+	// PathMappings move from-side entries to new paths before comparison,
+	// after any chroot, so that deliberately moved fields are compared in
+	// place (see PathMapping). Under Swap they are reversed and move the
+	// to input's entries instead, so From keeps naming a path of from.
+	PathMappings []PathMapping
+	// FromFile labels the from input; recorded as FromPos.File on every
+	// difference. Optional — positions carry lines and columns either way.
+	FromFile string
+	// ToFile labels the to input; recorded as ToPos.File on every difference.
+	ToFile string
+
+	// k8sAPIVersion and k8sKind identify the Kubernetes resource being
+	// compared, set by forResource; they select the schema list types and the
+	// built-in list keys.
+	k8sAPIVersion string
+	k8sKind       string
 }
 
 // Compare compares two YAML documents and returns the differences.
@@ -99,19 +228,52 @@ func Compare(from, to []byte, opts *Options) ([]Difference, error) {
 		opts = &Options{}
 	}
 
+	fromNodes, toNodes, err := compareInputs(from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	fromFile, toFile := opts.FromFile, opts.ToFile
+	if opts.Swap {
+		fromFile, toFile = toFile, fromFile
+	}
+
+	mapped, err := applyPathMappings(fromNodes, comparedPathMappings(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare documents and sort results. In inverse mode, collect equal
+	// values instead of differences; both paths feed the same sort/filter/
+	// format pipeline downstream.
+	pathOrder := extractPathOrder(fromNodes, toNodes, opts)
+	var diffs []Difference
+	if opts.Unchanged {
+		diffs = collectUnchangedDocs(fromNodes, toNodes, opts)
+	} else {
+		diffs = compareDocs(fromNodes, toNodes, opts)
+	}
+	stampMappedPaths(diffs, mapped)
+	sortDiffsWithOrder(diffs, pathOrder)
+	stampPositionFiles(diffs, fromFile, toFile)
+
+	return diffs, nil
+}
+
+// compareInputs parses from and to into per-document node trees, swapped
+// and chrooted as opts says: the two sides Compare compares.
+func compareInputs(from, to []byte, opts *Options) (fromNodes, toNodes []*yaml.Node, err error) {
 	// Parse both YAML inputs into per-document *yaml.Node trees. Nodes flow
 	// end-to-end through chroot, extractPathOrder, and compareDocs;
 	// nodeToInterface materialization is deferred to Difference.From/To
 	// emission sites.
-	fromNodes, err := parse(from)
+	fromNodes, err = parse(from)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	toNodes, err := parse(to)
+	toNodes, err = parse(to)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
 	if opts.Swap {
 		fromNodes, toNodes = toNodes, fromNodes
 	}
@@ -121,40 +283,28 @@ func Compare(from, to []byte, opts *Options) ([]Difference, error) {
 	if opts.Chroot != "" {
 		fromNodes, err = applyChrootToDocs(fromNodes, opts.Chroot, opts.ChrootListToDocuments)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		toNodes, err = applyChrootToDocs(toNodes, opts.Chroot, opts.ChrootListToDocuments)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	} else {
 		if opts.ChrootFrom != "" {
 			fromNodes, err = applyChrootToDocs(fromNodes, opts.ChrootFrom, opts.ChrootListToDocuments)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 		if opts.ChrootTo != "" {
 			toNodes, err = applyChrootToDocs(toNodes, opts.ChrootTo, opts.ChrootListToDocuments)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 	}
 
-	// Compare documents and sort results. In inverse mode, collect equal
-	// values instead of differences; both paths feed the same sort/filter/
-	// format pipeline downstream.
-	pathOrder := extractPathOrder(fromNodes, toNodes, opts)
-	var diffs []Difference
-	if opts.Unchanged {
-		diffs = collectUnchangedDocs(fromNodes, toNodes, opts)
-	} else {
-		diffs = compareDocs(fromNodes, toNodes, opts)
-	}
-	sortDiffsWithOrder(diffs, pathOrder)
-
-	return diffs, nil
+	return fromNodes, toNodes, nil
 }
 
 // pathWalker holds state for extractPathOrder to avoid per-node DiffPath and String allocations.
@@ -168,6 +318,8 @@ type pathWalker struct {
 	buf []byte
 	// lengths tracks buf length before each push for efficient pop.
 	lengths []int
+	// segs holds the pushed segments, the DiffPath that ListKeys match.
+	segs DiffPath
 	// aliasSeen tracks alias targets currently being walked to break cycles
 	// (e.g. an anchor whose subtree contains an alias back to itself).
 	// Lazily initialised on first encounter.
@@ -177,6 +329,7 @@ type pathWalker struct {
 // push appends a segment to the running path buffer.
 func (w *pathWalker) push(seg string) {
 	w.lengths = append(w.lengths, len(w.buf))
+	w.segs = append(w.segs, seg)
 	switch {
 	case strings.Contains(seg, "."):
 		w.buf = append(w.buf, '[')
@@ -195,6 +348,7 @@ func (w *pathWalker) pop() {
 	n := len(w.lengths) - 1
 	w.buf = w.buf[:w.lengths[n]]
 	w.lengths = w.lengths[:n]
+	w.segs = w.segs[:n]
 }
 
 // register registers the current path in pathOrder if not already present.
@@ -254,9 +408,10 @@ func (w *pathWalker) walk(n *yaml.Node) {
 		}
 	case yaml.SequenceNode:
 		w.register()
+		ids := identityAt(w.segs, w.opts)
 		for i, item := range n.Content {
 			var seg string
-			if id := getIdentifierNode(item, w.opts); isComparableIdentifier(id) {
+			if id := ids.of(item); isComparableIdentifier(id) {
 				seg = sprintIdentifier(id)
 			} else {
 				seg = strconv.Itoa(i)
@@ -279,12 +434,14 @@ func extractPathOrder(fromNodes, toNodes []*yaml.Node, opts *Options) map[string
 		opts:      opts,
 		buf:       make([]byte, 0, 256),
 		lengths:   make([]int, 0, 16),
+		segs:      make(DiffPath, 0, 16),
 	}
 
-	for _, n := range fromNodes {
-		w.walk(n)
-	}
-	for _, n := range toNodes {
+	for _, n := range slices.Concat(fromNodes, toNodes) {
+		w.opts = opts
+		if opts != nil && opts.DetectKubernetes {
+			w.opts = opts.forResource(k8sNodeResource(n))
+		}
 		w.walk(n)
 	}
 
@@ -325,7 +482,7 @@ func isListEntryDiff(diff Difference) bool {
 	// Inverse mode emits raw collapsed values, so the hasIdentifierField shape
 	// heuristic below would misfire on map subtrees whose value carries a
 	// name/id key. The inverse walk records the real container kind instead.
-	if diff.Type == DiffUnchanged {
+	if diff.Type == DiffUnchanged || diff.listEntry {
 		return diff.listEntry
 	}
 	// Check if the value is a map with identifier fields
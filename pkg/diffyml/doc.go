@@ -9,8 +9,11 @@
 // The central entry point is [Compare].  It accepts two byte slices of YAML
 // content (single- or multi-document) and returns a slice of [Difference]
 // values.  Each Difference carries the dot-notation path, the type of change
-// ([DiffAdded], [DiffRemoved], [DiffModified], [DiffOrderChanged]) and the
-// old/new values.
+// ([DiffAdded], [DiffRemoved], [DiffModified], [DiffTypeChanged],
+// [DiffOrderChanged]) and the old/new values.  A value that changes YAML type,
+// such as the string "8080" becoming the integer 8080, is a [DiffTypeChanged]
+// naming both types in Difference.FromType and Difference.ToType; ints and
+// floats are compared as numbers, so 1 and 1.0 are equal.
 //
 //	diffs, err := diffyml.Compare(oldYAML, newYAML, nil)
 //	for _, d := range diffs {
@@ -19,7 +22,35 @@
 //
 // Pass an [Options] struct to control comparison behaviour: ignore list order,
 // ignore whitespace, enable Kubernetes-aware matching, detect renames, navigate
-// to a subtree via chroot, and more.
+// to a subtree via chroot, and more.  With Options.CompareComments, changed
+// YAML comments are reported as [DiffCommentChanged], naming the
+// [CommentKind] in Difference.Comment.  Options.NormalizeQuantities compares
+// Kubernetes resource quantities and durations by value, recording a real
+// change's normalized delta in Difference.Delta.  Options.ListKeys declares
+// the identifier fields of the lists at a path pattern ([ListKey]), such as
+// containerPort and protocol for container ports.  Options.SetLists and
+// Options.SetListPaths compare lists of scalars as multisets, reporting each
+// element present on one side only as added or removed at the list's path.
+// Other lists without identifiers are aligned on their equal items first, so
+// an item inserted in the middle is one [DiffAdded] at its new index.
+// Options.DetectMoves reports a map subtree moved or renamed within a
+// document as one [DiffMoved] at its new path, naming the old one in
+// Difference.FromPath, followed by the changes inside it.
+// Options.PathMappings ([PathMapping]) move from-side entries to new paths
+// before comparison, so that deliberately moved fields are compared in place;
+// the differences inside a mapped subtree name their from-side path in
+// Difference.FromPath. [UnmatchedPathMappings] finds the mappings that move
+// nothing.
+// Options.DescendEmbedded compares two strings that both hold a YAML or JSON
+// document structurally, reporting the differences inside them below the
+// string's path, such as data[config.yaml].server.port.
+// Options.DecodeBase64 compares the base64 values of Secret data, ConfigMap
+// binaryData and !!binary scalars decoded, summarizing binary payloads by
+// size and SHA-256.
+// Options.CertPolicy ([CertPolicy]) checks the certificates of the to
+// document, reporting one that has expired or expires soon, uses a weak key
+// or signature, or became self-signed as a [DiffWarning] with the finding in
+// Difference.Warning.
 //
 // # Loading content
 //
@@ -32,7 +63,7 @@
 //
 // # Formatting output
 //
-// Seven built-in formatters render differences for different audiences:
+// Thirteen built-in formatters render differences for different audiences:
 //
 //   - [DetailedFormatter] — full human-readable output with inline diffs
 //   - [CompactFormatter] — one line per change
@@ -41,6 +72,12 @@
 //     (also returned for the "gitea" name; Gitea Actions accepts the same
 //     workflow command syntax)
 //   - [GitLabFormatter] — GitLab CI Code Quality JSON
+//   - [SARIFFormatter] — SARIF 2.1.0 log for code-scanning dashboards
+//   - [JUnitFormatter] — JUnit XML report for CI test-report widgets
+//   - [HTMLFormatter] — self-contained HTML report for tickets and reviews
+//   - [MarkdownFormatter] — Markdown with collapsible diff blocks for PR comments
+//   - [SideBySideFormatter] — two aligned columns of from and to YAML
+//   - [AnnotatedFormatter] — the full to-side YAML with a change gutter
 //   - [JSONFormatter] — machine-readable JSON with typed values
 //   - [JSONPatchFormatter] — RFC 6902 JSON Patch operations
 //
@@ -51,6 +88,13 @@
 //	f, _ := diffyml.FormatterByName("compact")
 //	fmt.Print(f.Format(diffs, diffyml.DefaultFormatOptions()))
 //
+// Unless FormatOptions.NoCertInspection is set, the detailed, GitHub and JSON
+// formatters show a changed PEM certificate or bundle as its changed fields,
+// computed by [CompareCertificates], and other PEM values — a single
+// certificate, key or certificate request — as their one-line [SummarizePEM]
+// summary. [MaskDifferences] replaces PEM private keys by their summary
+// whatever its options.
+//
 // # Filtering
 //
 // [FilterDiffs] selects or excludes differences by exact path prefix.
@@ -60,7 +104,13 @@
 // # Kubernetes awareness
 //
 // When [Options].DetectKubernetes is set to true, multi-document YAML
-// files are matched by Kubernetes resource identity rather than position.
+// files are matched by Kubernetes resource identity rather than position,
+// and the items of their lists without a name by the strategic-merge keys of
+// the built-in types, such as container ports by containerPort and protocol;
+// [ListKey] entries with a Kind extend or override that table. A [Schema]
+// read by [ParseSchema] from CRD manifests or an OpenAPI v3 document adds the
+// list types of custom resources: map lists by their list-map keys, set lists
+// regardless of order, and atomic lists compared as a whole.
 // The CLI enables this by default; library callers must opt in explicitly.  [IsKubernetesResource] checks whether a parsed document looks
 // like a Kubernetes resource, and [K8sResourceIdentifier] returns its
 // canonical identifier string.
@@ -71,6 +121,19 @@
 // two directories of YAML files, making diffyml a drop-in
 // KUBECTL_EXTERNAL_DIFF provider.
 //
+// # Merging
+//
+// [Merge] performs a structural three-way merge of base, ours and theirs,
+// combining non-overlapping changes and reporting same-path edits as
+// [MergeConflict] entries, which are written to the merged YAML between
+// git-style conflict markers.
+//
+// # Applying patches
+//
+// [ApplyPatch] applies an RFC 6902 JSON Patch, or the difference array
+// [JSONFormatter] writes, to YAML source by editing its node tree, so
+// comments, key order, anchors and scalar styles survive.
+//
 // # Parsing helpers
 //
 // [OrderedMap] preserves YAML key order during parsing.
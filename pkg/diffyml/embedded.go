@@ -0,0 +1,112 @@
+// embedded.go - Structural comparison inside embedded YAML and JSON strings
+// (Options.DescendEmbedded).
+//
+// ConfigMaps and Helm values embed whole configuration files as strings, such
+// as data.config.yaml or application.json, which otherwise compare as one
+// multi-line value. Under DescendEmbedded, two differing strings that both
+// parse as YAML, which JSON is a subset of, to a map or a list are compared
+// with compareNodes below the string's own path: data[config.yaml].server.port.
+// Filters, masks and formatters then see the embedded structure like any
+// other value.
+// The differences found inside a string, or in a base64 payload compared
+// decoded, remember it (see embeddedString), so that patches replace the
+// whole string, as written, rather than address its inside.
+// Key functions: embeddedDocument, parseEmbedded, anchorEmbedded, markEmbedded.
+package diffyml
+
+import (
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// embeddedString is the string value a difference lies within when the
+// comparator looked inside it: an embedded document, or a base64 payload
+// compared decoded. Patches cannot address the inside of a string, so they
+// replace the whole value at path with to, the string as the to document
+// holds it. Every difference found inside one string shares it.
+type embeddedString struct {
+	path DiffPath
+	to   string
+}
+
+// markEmbedded records that diffs lie within the string toN at path. An
+// enclosing string marks them after an inner one, so the outermost wins.
+func markEmbedded(diffs []Difference, path DiffPath, toN *yaml.Node) []Difference {
+	within := &embeddedString{path: path, to: toN.Value}
+	for i := range diffs {
+		diffs[i].within = within
+	}
+	return diffs
+}
+
+// compareEmbedded compares the strings fromN and toN structurally when both
+// embed a YAML or JSON document (see embeddedDocument); ok is false when
+// either does not.
+func compareEmbedded(path DiffPath, fromN, toN *yaml.Node, opts *Options) (diffs []Difference, ok bool) {
+	fromDoc := embeddedDocument(fromN)
+	if fromDoc == nil {
+		return nil, false
+	}
+	toDoc := embeddedDocument(toN)
+	if toDoc == nil {
+		return nil, false
+	}
+	return compareNodes(path, fromDoc, toDoc, opts), true
+}
+
+// embeddedDocument returns the document embedded in the string n (see
+// parseEmbedded), or nil. A literal block scalar keeps its lines.
+func embeddedDocument(n *yaml.Node) *yaml.Node {
+	if n.ShortTag() != "!!str" {
+		return nil
+	}
+	return parseEmbedded(n.Value, n, n.Style&yaml.LiteralStyle != 0)
+}
+
+// parseEmbedded parses text as a single YAML document and returns its root
+// when that is a non-empty map or list, positioned within the file holding
+// the node host (see anchorEmbedded); otherwise nil. Only text spanning
+// several lines, or starting like a JSON object or array, is parsed: a
+// one-line "key: value" is text, not a document.
+func parseEmbedded(text string, host *yaml.Node, keepLines bool) *yaml.Node {
+	if !strings.Contains(strings.TrimSpace(text), "\n") && !couldBeJSON(text) {
+		return nil
+	}
+	docs, err := parse([]byte(text))
+	if err != nil || len(docs) != 1 {
+		return nil
+	}
+	root := resolveNode(docs[0])
+	if root == nil || (root.Kind != yaml.MappingNode && root.Kind != yaml.SequenceNode) || len(root.Content) == 0 {
+		return nil
+	}
+	anchorEmbedded(root, host, keepLines, make(map[*yaml.Node]bool))
+	return root
+}
+
+// anchorEmbedded moves the positions of an embedded document, which count
+// from the start of its text, into the file holding the node host. With
+// keepLines, as for a literal block scalar, each node keeps its line below
+// the host's; otherwise the text was folded, escaped or encoded, so every
+// node takes the host's line. Columns are the host's: the indentation of a
+// block scalar is not recorded. A host without a position leaves the
+// document without one.
+func anchorEmbedded(n, host *yaml.Node, keepLines bool, seen map[*yaml.Node]bool) {
+	if n == nil || seen[n] {
+		return
+	}
+	seen[n] = true
+	switch {
+	case host.Line == 0:
+		n.Line = 0
+	case keepLines:
+		n.Line += host.Line
+	default:
+		n.Line = host.Line
+	}
+	n.Column = host.Column
+	for _, c := range n.Content {
+		anchorEmbedded(c, host, keepLines, seen)
+	}
+}
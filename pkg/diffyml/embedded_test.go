@@ -0,0 +1,156 @@
+package diffyml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	embeddedFrom = "data:\n" +
+		"  config.yaml: |\n    server:\n      port: 8080\n      host: 0.0.0.0\n    logging:\n      level: info\n" +
+		"  app.json: '{\"feature\": {\"enabled\": false}, \"retries\": 3}'\n" +
+		"  motd: \"key: value\"\n"
+	embeddedTo = "data:\n" +
+		"  config.yaml: |\n    server:\n      port: 9090\n      host: 0.0.0.0\n    logging:\n      level: info\n" +
+		"  app.json: '{\"feature\": {\"enabled\": true}, \"retries\": 3}'\n" +
+		"  motd: \"key: other\"\n"
+)
+
+func TestCompare_DescendEmbedded(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), &Options{DescendEmbedded: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	// Paths inside strings are not in the document's path order, so they
+	// sort after the plain ones.
+	want := []setDiff{
+		{DiffModified, "data.motd", "key: other"},
+		{DiffModified, "data[config.yaml].server.port", 9090},
+		{DiffModified, "data[app.json].feature.enabled", true},
+	}
+	if got := setDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// A literal block keeps its lines: port is the block's second line.
+	if d := diffs[1]; d.FromPos == nil || d.FromPos.Line != 4 || d.ToPos == nil || d.ToPos.Line != 4 {
+		t.Errorf("positions = %v, %v, want line 4", d.FromPos, d.ToPos)
+	}
+	// A quoted string folds its lines onto the string's own.
+	if d := diffs[2]; d.ToPos == nil || d.ToPos.Line != 8 {
+		t.Errorf("position = %v, want line 8", d.ToPos)
+	}
+}
+
+func TestCompare_DescendEmbeddedOff(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), nil)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var paths []string
+	for _, d := range diffs {
+		paths = append(paths, d.Path.String())
+	}
+	want := []string{"data[config.yaml]", "data[app.json]", "data.motd"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestCompare_DescendEmbeddedFallsBackToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{"one side is not a document", "v: |\n  a: 1\n  b: 2\n", "v: |\n  plain text\n  [unclosed\n"},
+		{"scalar document", "v: |\n  just\n  text\n", "v: |\n  other\n  text\n"},
+		{"several documents", "v: |\n  a: 1\n  ---\n  a: 2\n", "v: |\n  a: 1\n  ---\n  a: 3\n"},
+		{"empty map", "v: '{}'\n", "v: '[]'\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare([]byte(tt.from), []byte(tt.to), &Options{DescendEmbedded: true})
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			if len(diffs) != 1 || diffs[0].Path.String() != "v" || diffs[0].Type != DiffModified {
+				t.Errorf("got %v, want one text change at v", setDiffs(diffs))
+			}
+		})
+	}
+}
+
+func TestFilterDiffs_EmbeddedPaths(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), &Options{DescendEmbedded: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	got := FilterDiffs(diffs, &FilterOptions{IncludePaths: []string{"data[config.yaml].server"}})
+	if len(got) != 1 || got[0].Path.String() != "data[config.yaml].server.port" {
+		t.Errorf("include = %v, want the embedded port", setDiffs(got))
+	}
+	got = FilterDiffs(diffs, &FilterOptions{ExcludePaths: []string{"data[app.json]"}})
+	if len(got) != 2 {
+		t.Errorf("exclude = %v, want two differences", setDiffs(got))
+	}
+}
+
+func TestFormat_DescendEmbedded(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), &Options{DescendEmbedded: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, format := range []string{"compact", "detailed", "github", "json"} {
+		t.Run(format, func(t *testing.T) {
+			f, err := FormatterByName(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := f.Format(diffs, DefaultFormatOptions()); !strings.Contains(out, "data[config.yaml].server.port") {
+				t.Errorf("output does not name the embedded path:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_DescendEmbeddedRoundTrip(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), &Options{DescendEmbedded: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, format := range []string{"json", "json-patch"} {
+		t.Run(format, func(t *testing.T) {
+			f, err := FormatterByName(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch := f.Format(diffs, &FormatOptions{})
+			if format == "json-patch" && (strings.Contains(patch, "/server") || strings.Count(patch, `"/data/config.yaml"`) != 1) {
+				t.Errorf("want one replacement of the whole string, got:\n%s", patch)
+			}
+			got := applyPatch(t, embeddedFrom, patch)
+			rest, err := Compare([]byte(got), []byte(embeddedTo), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("patched file still differs from target: %v\n%s", setDiffs(rest), got)
+			}
+		})
+	}
+}
+
+func TestMaskDifferences_EmbeddedString(t *testing.T) {
+	diffs, err := Compare([]byte(embeddedFrom), []byte(embeddedTo), &Options{DescendEmbedded: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	masked, err := MaskDifferences(diffs, MaskOptions{MaskPaths: []string{"data[config.yaml].server.port"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := (&JSONPatchFormatter{}).Format(masked, &FormatOptions{})
+	if strings.Contains(patch, "9090") {
+		t.Errorf("masked value in the patch:\n%s", patch)
+	}
+}
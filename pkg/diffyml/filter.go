@@ -26,6 +26,10 @@ type FilterOptions struct {
 	// AdditionalIdentifiers supplies non-default identifier fields used when
 	// deriving paths inside collapsed list values.
 	AdditionalIdentifiers []string
+	// ListKeys supplies per-path list identifiers, as in Options.ListKeys.
+	ListKeys []ListKey
+	// Schema supplies schema-declared list keys, as in Options.Schema.
+	Schema *Schema
 }
 
 // FilterDiffs filters the list of differences based on the provided options.
@@ -42,6 +46,8 @@ func FilterDiffs(diffs []Difference, opts *FilterOptions) []Difference {
 		IncludePaths:          opts.IncludePaths,
 		ExcludePaths:          opts.ExcludePaths,
 		AdditionalIdentifiers: opts.AdditionalIdentifiers,
+		ListKeys:              opts.ListKeys,
+		Schema:                opts.Schema,
 	}
 	// FilterDiffsWithRegexp can only fail on invalid regex; pathOnly has none.
 	result, _ := FilterDiffsWithRegexp(diffs, pathOnly)
@@ -150,15 +156,15 @@ func matchesAnyRegexWithNested(diffPath string, nestedPaths []string, patterns [
 // list item diffs and inverse-mode subtree collapses are atomic, so partial
 // filtering would not be meaningful. Without this, --filter/--exclude on a key
 // nested inside a collapsed unchanged subtree would silently never match.
-func nestedKeyPaths(diff Difference, additionalIdentifiers []string) []string {
-	return nestedKeyPathsFrom(diff.Path, diff, additionalIdentifiers)
+func nestedKeyPaths(diff Difference, ids *Options) []string {
+	return nestedKeyPathsFrom(diff.Path, diff, ids)
 }
 
 // nestedKeyPathsFrom behaves like nestedKeyPaths but appends the diff's
 // structured value to an explicit base path rather than diff.Path. This
 // lets callers build nested key paths for a document-index-stripped base so
 // document-index-agnostic filters can match multi-document diffs.
-func nestedKeyPathsFrom(base DiffPath, diff Difference, additionalIdentifiers []string) []string {
+func nestedKeyPathsFrom(base DiffPath, diff Difference, ids *Options) []string {
 	var value any
 	switch diff.Type {
 	case DiffRemoved:
@@ -172,32 +178,32 @@ func nestedKeyPathsFrom(base DiffPath, diff Difference, additionalIdentifiers []
 		// gomutants:disable-next-line BRANCH_CASE reason="defensive; value stays nil → next guard returns nil too, same outcome"
 		return nil
 	}
-	return appendValuePaths(nil, []DiffPath{base}, value, additionalIdentifiers)
+	return appendValuePaths(nil, []DiffPath{base}, value, ids)
 }
 
 // appendValuePaths appends the string form of every descendant path within
 // value (rooted at bases) to paths and returns the result. Nested maps and
 // lists are traversed so deep filters can match collapsed subtrees. List items
 // expose both bare numeric indices and identifier aliases when available.
-func appendValuePaths(paths []string, bases []DiffPath, value any, additionalIdentifiers []string) []string {
+func appendValuePaths(paths []string, bases []DiffPath, value any, ids *Options) []string {
 	switch v := value.(type) {
 	case *OrderedMap:
 		for _, key := range v.Keys {
 			aliases := mappingValuePathAliases(bases, key)
 			paths = appendAliasStrings(paths, aliases)
-			paths = appendValuePaths(paths, aliases, v.Values[key], additionalIdentifiers)
+			paths = appendValuePaths(paths, aliases, v.Values[key], ids)
 		}
 	case map[string]any:
 		for _, key := range sortedMapKeys(v) {
 			aliases := mappingValuePathAliases(bases, key)
 			paths = appendAliasStrings(paths, aliases)
-			paths = appendValuePaths(paths, aliases, v[key], additionalIdentifiers)
+			paths = appendValuePaths(paths, aliases, v[key], ids)
 		}
 	case []any:
 		for i, elem := range v {
-			aliases := sequenceValuePathAliases(bases, elem, i, additionalIdentifiers)
+			aliases := sequenceValuePathAliases(bases, elem, i, ids)
 			paths = appendAliasStrings(paths, aliases)
-			paths = appendValuePaths(paths, aliases, elem, additionalIdentifiers)
+			paths = appendValuePaths(paths, aliases, elem, ids)
 		}
 	}
 	return paths
@@ -250,17 +256,28 @@ func FilterDiffsWithRegexpReport(diffs []Difference, opts *FilterOptions, report
 	}
 
 	var result []Difference
+	ids := identifierOptions(opts.AdditionalIdentifiers, opts.ListKeys, opts.Schema)
 
 	for _, diff := range diffs {
 		pathStr := diff.Path.String()
-		nested := nestedKeyPaths(diff, opts.AdditionalIdentifiers)
+		docIDs := ids.forResource("", diff.DocumentKind)
+		nested := nestedKeyPaths(diff, docIDs)
 		// Document-index-agnostic filters (e.g. metadata.annotations) should match
 		// multi-document diffs whose paths are prefixed with [N]. Add the stripped
 		// path and its nested keys as additional match candidates. The raw pathStr
 		// is retained so document-scoped filters ([0].metadata) still work.
 		if _, rest, ok := diff.Path.DocIndexPrefix(); ok {
 			nested = append(nested, rest.String())
-			nested = append(nested, nestedKeyPathsFrom(rest, diff, opts.AdditionalIdentifiers)...)
+			nested = append(nested, nestedKeyPathsFrom(rest, diff, docIDs)...)
+		}
+		// A move, or a difference inside a mapped subtree, matches filters
+		// on its from-side path as well.
+		if diff.FromPath != nil {
+			nested = append(nested, diff.FromPath.String())
+			nested = append(nested, nestedKeyPathsFrom(diff.FromPath, diff, docIDs)...)
+			if _, rest, ok := diff.FromPath.DocIndexPrefix(); ok {
+				nested = append(nested, rest.String())
+			}
 		}
 		included := true
 
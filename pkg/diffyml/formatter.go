@@ -1,6 +1,6 @@
 // formatter.go - Output formatting for differences.
 //
-// Implements 8 output styles: compact, brief, github, gitlab, gitea, json, json-patch, detailed.
+// Implements 14 output styles: compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed.
 // Key types: Formatter interface, FormatOptions.
 // Each formatter implements Format(diffs, opts) string.
 package diffyml
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -46,6 +47,26 @@ type FormatOptions struct {
 	FilePath string
 	// Palette holds custom color overrides. Nil means use defaults.
 	Palette *CustomColorPalette
+	// FromSource and ToSource are the raw inputs the differences were
+	// computed from, set by the CLI layer for formatters that render whole
+	// documents (SideBySideFormatter). Positions in the differences refer to
+	// lines of these inputs. Nil when unknown.
+	FromSource []byte
+	ToSource   []byte
+	// Width is the output width in columns for layout-sensitive formatters.
+	// Zero means the formatter's default.
+	Width int
+	// Mask is the masking configuration the differences were redacted with.
+	// Formatters that print FromSource/ToSource apply it to the source text
+	// so masked values stay hidden there too. Nil masks nothing.
+	Mask *MaskOptions
+	// Filter is the path filtering the differences went through. Formatters
+	// that print whole documents (AnnotatedFormatter) fold the regions it
+	// hides. Nil filters nothing.
+	Filter *FilterOptions
+	// FoldUnchanged folds unchanged subtrees longer than this many lines in
+	// AnnotatedFormatter output. Zero never folds.
+	FoldUnchanged int
 }
 
 // DiffGroup pairs differences from a single file with its path.
@@ -86,10 +107,10 @@ func emptyResultMessage(opts *FormatOptions, suffix string) string {
 }
 
 // validFormatterNames lists all supported formatter names.
-var validFormatterNames = []string{"compact", "brief", "github", "gitlab", "gitea", "json", "json-patch", "detailed"}
+var validFormatterNames = []string{"compact", "brief", "github", "gitlab", "gitea", "sarif", "junit", "html", "markdown", "side-by-side", "annotated", "json", "json-patch", "detailed"}
 
 // FormatterByName returns a formatter by name.
-// Supported names: compact, brief, github, gitlab, gitea, json, json-patch, detailed.
+// Supported names: compact, brief, github, gitlab, gitea, sarif, junit, html, markdown, side-by-side, annotated, json, json-patch, detailed.
 // Returns error for invalid formatter names with list of valid options.
 func FormatterByName(name string) (Formatter, error) {
 	// Normalize to lowercase for case-insensitive matching
@@ -109,6 +130,18 @@ func FormatterByName(name string) (Formatter, error) {
 		// (gitea/gitea#23722). It silently ignores annotations in the UI,
 		// but the output is parseable in raw build logs.
 		return &GitHubFormatter{}, nil
+	case "sarif":
+		return &SARIFFormatter{}, nil
+	case "junit":
+		return &JUnitFormatter{}, nil
+	case "html":
+		return &HTMLFormatter{}, nil
+	case "markdown":
+		return &MarkdownFormatter{}, nil
+	case "side-by-side":
+		return &SideBySideFormatter{}, nil
+	case "annotated":
+		return &AnnotatedFormatter{}, nil
 	case "json":
 		return &JSONFormatter{}, nil
 	case "json-patch":
@@ -172,20 +205,26 @@ func (f *CompactFormatter) formatHeader(sb *strings.Builder, diffs []Difference,
 		return
 	}
 
-	var added, removed, modified int
+	var added, removed, modified, warnings int
 	for _, d := range diffs {
 		switch d.Type {
 		case DiffAdded:
 			added++
 		case DiffRemoved:
 			removed++
-		case DiffModified, DiffOrderChanged:
+		case DiffModified, DiffTypeChanged, DiffOrderChanged, DiffCommentChanged, DiffMoved:
 			modified++
+		case DiffWarning:
+			warnings++
 		}
 	}
-	fmt.Fprintf(sb, "Found %d difference(s)", len(diffs))
+	fmt.Fprintf(sb, "Found %d difference(s)", len(diffs)-warnings)
 	sb.WriteString(colorEnd(opts))
-	fmt.Fprintf(sb, " (%d removed, %d added, %d modified)\n\n", removed, added, modified)
+	fmt.Fprintf(sb, " (%d removed, %d added, %d modified", removed, added, modified)
+	if warnings > 0 {
+		fmt.Fprintf(sb, ", %d warning(s)", warnings)
+	}
+	sb.WriteString(")\n\n")
 }
 
 func (f *CompactFormatter) formatDiff(sb *strings.Builder, diff Difference, opts *FormatOptions) {
@@ -210,12 +249,24 @@ func (f *CompactFormatter) formatDiff(sb *strings.Builder, diff Difference, opts
 	case DiffModified:
 		indicator = "±"
 		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
+	case DiffTypeChanged:
+		indicator = "≠"
+		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
 	case DiffOrderChanged:
 		indicator = "⇆"
 		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
 	case DiffUnchanged:
 		indicator = "="
 		colorCode = p.ColorCode(ColorRoleContext, opts.TrueColor)
+	case DiffCommentChanged:
+		indicator = "#"
+		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
+	case DiffMoved:
+		indicator = "→"
+		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
+	case DiffWarning:
+		indicator = "⚠"
+		colorCode = p.ColorCode(ColorRoleModified, opts.TrueColor)
 	}
 
 	// Apply color for the indicator
@@ -233,6 +284,7 @@ func (f *CompactFormatter) formatDiff(sb *strings.Builder, diff Difference, opts
 		fmt.Fprintf(sb, "(%s)", diff.DocumentName)
 		sb.WriteString(colorEnd(opts))
 	}
+	sb.WriteString(mappedSuffix(diff, opts.UseGoPatchStyle))
 
 	f.formatValuesInline(sb, diff, opts)
 
@@ -248,6 +300,13 @@ func (f *CompactFormatter) formatValuesInline(sb *strings.Builder, diff Differen
 
 		sb.WriteString(" : ")
 		f.writeCompactValueChange(sb, fromStr, toStr, p, opts)
+		if diff.Delta != "" {
+			fmt.Fprintf(sb, " (%s)", diff.Delta)
+		}
+
+	case DiffTypeChanged:
+		fmt.Fprintf(sb, " (%s → %s) : ", diff.FromType, diff.ToType)
+		f.writeCompactValueChange(sb, formatValue(typeChangeOperand(diff.From)), formatValue(typeChangeOperand(diff.To)), p, opts)
 
 	case DiffAdded:
 		toStr := formatValue(diff.To)
@@ -263,12 +322,20 @@ func (f *CompactFormatter) formatValuesInline(sb *strings.Builder, diff Differen
 		sb.WriteString(colorEnd(opts))
 	case DiffOrderChanged:
 		sb.WriteString(" (order changed)")
+	case DiffMoved:
+		fmt.Fprintf(sb, " (moved from %s)", pathString(diff.FromPath, opts.UseGoPatchStyle))
+	case DiffWarning:
+		sb.WriteString(" : ")
+		sb.WriteString(diff.Warning)
 	case DiffUnchanged:
 		toStr := formatValue(diff.To)
 		sb.WriteString(" : ")
 		sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleContext, opts.TrueColor)))
 		sb.WriteString(toStr)
 		sb.WriteString(colorEnd(opts))
+	case DiffCommentChanged:
+		fmt.Fprintf(sb, " (%s comment) : ", diff.Comment)
+		f.writeCompactValueChange(sb, formatComment(diff.From), formatComment(diff.To), p, opts)
 	}
 }
 
@@ -313,6 +380,16 @@ func formatValue(val any) string {
 	return fmt.Sprintf("%v", val)
 }
 
+// typeChangeOperand returns a side of a type change for display: a string is
+// quoted, so that "8080" and 8080 read apart, and anything else is returned
+// as it is.
+func typeChangeOperand(val any) any {
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+	return val
+}
+
 // BriefFormatter renders a concise summary of changes.
 type BriefFormatter struct{}
 
@@ -325,8 +402,16 @@ func (f *BriefFormatter) FormatSingle(diff Difference, opts *FormatOptions) stri
 		return fmt.Sprintf("- %s\n", diff.Path)
 	case DiffModified:
 		return fmt.Sprintf("± %s\n", diff.Path)
+	case DiffTypeChanged:
+		return fmt.Sprintf("≠ %s\n", diff.Path)
 	case DiffUnchanged:
 		return fmt.Sprintf("= %s\n", diff.Path)
+	case DiffCommentChanged:
+		return fmt.Sprintf("# %s\n", diff.Path)
+	case DiffMoved:
+		return fmt.Sprintf("→ %s\n", diff.Path)
+	case DiffWarning:
+		return fmt.Sprintf("⚠ %s\n", diff.Path)
 	default: // DiffOrderChanged
 		return fmt.Sprintf("⇆ %s\n", diff.Path)
 	}
@@ -338,7 +423,7 @@ func (f *BriefFormatter) Format(diffs []Difference, opts *FormatOptions) string
 		return emptyResultMessage(opts, "")
 	}
 
-	var added, removed, modified, unchanged int
+	var added, removed, modified, typeChanged, moved, unchanged, comments, warnings int
 	for _, diff := range diffs {
 		switch diff.Type {
 		case DiffAdded:
@@ -347,8 +432,16 @@ func (f *BriefFormatter) Format(diffs []Difference, opts *FormatOptions) string
 			removed++
 		case DiffModified, DiffOrderChanged:
 			modified++
+		case DiffTypeChanged:
+			typeChanged++
+		case DiffMoved:
+			moved++
 		case DiffUnchanged:
 			unchanged++
+		case DiffCommentChanged:
+			comments++
+		case DiffWarning:
+			warnings++
 		}
 	}
 
@@ -362,9 +455,21 @@ func (f *BriefFormatter) Format(diffs []Difference, opts *FormatOptions) string
 	if modified > 0 {
 		parts = append(parts, fmt.Sprintf("%d modified", modified))
 	}
+	if typeChanged > 0 {
+		parts = append(parts, fmt.Sprintf("%d type changed", typeChanged))
+	}
+	if moved > 0 {
+		parts = append(parts, fmt.Sprintf("%d moved", moved))
+	}
 	if unchanged > 0 {
 		parts = append(parts, fmt.Sprintf("%d unchanged", unchanged))
 	}
+	if comments > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s changed", comments, pluralize(comments, "comment", "comments")))
+	}
+	if warnings > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", warnings, pluralize(warnings, "warning", "warnings")))
+	}
 
 	return strings.Join(parts, ", ") + "\n"
 }
@@ -382,8 +487,16 @@ func gitHubCommand(dt DiffType) (command, title string) {
 		return "error", "YAML Removed"
 	case DiffModified:
 		return "warning", "YAML Modified"
+	case DiffTypeChanged:
+		return "warning", "YAML Type Changed"
 	case DiffUnchanged:
 		return "notice", "YAML Unchanged"
+	case DiffCommentChanged:
+		return "notice", "YAML Comment Changed"
+	case DiffMoved:
+		return "notice", "YAML Moved"
+	case DiffWarning:
+		return "warning", "Certificate Warning"
 	default: // DiffOrderChanged
 		return "notice", "YAML Order Changed"
 	}
@@ -398,19 +511,131 @@ func diffDocSuffix(diff Difference) string {
 	return fmt.Sprintf(" (%s)", diff.DocumentName)
 }
 
+// docSection is one document's share of a file's differences, rendered as a
+// collapsible section by the HTML and Markdown formatters.
+type docSection struct {
+	Label string // empty for the only, unnamed document of a file
+	Diffs []Difference
+}
+
+// diffTypeCounts tallies differences by type for report summary tables.
+type diffTypeCounts struct {
+	Added, Removed, Modified, TypeChanged, Moved, OrderChanged, Unchanged, CommentChanged, Warnings int
+}
+
+func (c *diffTypeCounts) add(diffs []Difference) {
+	for _, d := range diffs {
+		switch d.Type {
+		case DiffAdded:
+			c.Added++
+		case DiffRemoved:
+			c.Removed++
+		case DiffModified:
+			c.Modified++
+		case DiffTypeChanged:
+			c.TypeChanged++
+		case DiffMoved:
+			c.Moved++
+		case DiffOrderChanged:
+			c.OrderChanged++
+		case DiffUnchanged:
+			c.Unchanged++
+		case DiffCommentChanged:
+			c.CommentChanged++
+		case DiffWarning:
+			c.Warnings++
+		}
+	}
+}
+
+// documentSections splits a file's differences into document sections in order
+// of first appearance. Documents are labeled like the detailed formatter
+// labels them: the Kubernetes resource name when known, otherwise
+// "document N" — unless the file has a single unnamed document, which gets no
+// section of its own.
+func documentSections(diffs []Difference) []docSection {
+	type docKey struct {
+		idx  int
+		name string
+	}
+	var keys []docKey
+	byKey := make(map[docKey][]Difference)
+	for _, d := range diffs {
+		k := docKey{d.DocumentIndex, d.DocumentName}
+		if _, seen := byKey[k]; !seen {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], d)
+	}
+
+	sections := make([]docSection, 0, len(keys))
+	for _, k := range keys {
+		label := k.name
+		if label == "" && len(keys) > 1 {
+			label = documentLabel(k.idx, "")
+		}
+		sections = append(sections, docSection{Label: label, Diffs: byKey[k]})
+	}
+	return sections
+}
+
+// sectionPathLabel renders a difference's path inside a document section.
+// The section already names its document, so the [N] prefix is dropped, and
+// the document root reads "(root level)" outside Go-Patch style.
+func sectionPathLabel(p DiffPath, opts *FormatOptions) string {
+	if _, rest, ok := p.DocIndexPrefix(); ok {
+		p = rest
+	}
+	if (p.IsEmpty() || p.IsBareDocIndex()) && !opts.UseGoPatchStyle {
+		return "(root level)"
+	}
+	return pathString(p, opts.UseGoPatchStyle)
+}
+
+// mappedSuffix returns the note " (mapped from PATH)" for a difference inside
+// a subtree moved by Options.PathMappings, naming its from-side path, or ""
+// for any other.
+func mappedSuffix(diff Difference, goPatch bool) string {
+	if diff.FromPath == nil || diff.Type == DiffMoved {
+		return ""
+	}
+	from := diff.FromPath
+	if _, rest, ok := from.DocIndexPrefix(); ok {
+		from = rest
+	}
+	return " (mapped from " + pathString(from, goPatch) + ")"
+}
+
+// deltaSuffix returns the parenthesized normalized delta appended to a
+// modification, or "" when the difference has none.
+func deltaSuffix(diff Difference) string {
+	if diff.Delta == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", diff.Delta)
+}
+
 // diffDescription returns a human-readable description of a difference.
 // Shared by GitHub, GitLab, and Gitea formatters.
 func diffDescription(diff Difference) string {
-	docSuffix := diffDocSuffix(diff)
+	docSuffix := diffDocSuffix(diff) + mappedSuffix(diff, false)
 	switch diff.Type {
 	case DiffAdded:
 		return fmt.Sprintf("Added: %s%s = %s", diff.Path, docSuffix, formatValue(diff.To))
 	case DiffRemoved:
 		return fmt.Sprintf("Removed: %s%s = %s", diff.Path, docSuffix, formatValue(diff.From))
 	case DiffModified:
-		return fmt.Sprintf("Modified: %s%s changed from %s to %s", diff.Path, docSuffix, formatValue(diff.From), formatValue(diff.To))
+		return fmt.Sprintf("Modified: %s%s changed from %s to %s%s", diff.Path, docSuffix, formatValue(diff.From), formatValue(diff.To), deltaSuffix(diff))
+	case DiffTypeChanged:
+		return fmt.Sprintf("Type changed: %s%s changed from %s %s to %s %s", diff.Path, docSuffix, diff.FromType, formatValue(typeChangeOperand(diff.From)), diff.ToType, formatValue(typeChangeOperand(diff.To)))
 	case DiffUnchanged:
 		return fmt.Sprintf("Unchanged: %s%s = %s", diff.Path, docSuffix, formatValue(diff.To))
+	case DiffCommentChanged:
+		return fmt.Sprintf("Comment changed: %s%s %s comment changed from %s to %s", diff.Path, docSuffix, diff.Comment, formatComment(diff.From), formatComment(diff.To))
+	case DiffMoved:
+		return fmt.Sprintf("Moved: %s%s moved from %s", diff.Path, docSuffix, diff.FromPath)
+	case DiffWarning:
+		return fmt.Sprintf("Warning: %s%s: %s", diff.Path, docSuffix, diff.Warning)
 	default: // DiffOrderChanged
 		return fmt.Sprintf("Order changed: %s%s", diff.Path, docSuffix)
 	}
@@ -424,12 +649,12 @@ const gitHubAnnotationLimit = 10
 func (f *GitHubFormatter) FormatSingle(diff Difference, opts *FormatOptions) string {
 	cmd, title := gitHubCommand(diff.Type)
 	var sb strings.Builder
-	gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), "")
+	gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), "", nil)
 	return sb.String()
 }
 
 // Format renders differences in GitHub Actions format.
-// When opts.FilePath is non-empty: ::<cmd> file=<path>,title=<title>::<message>
+// When opts.FilePath is non-empty: ::<cmd> file=<path>,line=<n>,endLine=<m>,title=<title>::<message>
 // When opts.FilePath is empty:     ::<cmd> title=<title>::<message>
 // Tracks per-type counts; truncates at 10 per command type.
 // Appends summary annotation per truncated type.
@@ -450,7 +675,7 @@ func (f *GitHubFormatter) Format(diffs []Difference, opts *FormatOptions) string
 	for _, diff := range diffs {
 		cmd, title := gitHubCommand(diff.Type)
 		if counts[cmd] < gitHubAnnotationLimit {
-			gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), filePath)
+			gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), filePath, diff.ToPos)
 			counts[cmd]++
 		} else {
 			omitted[cmd]++
@@ -482,11 +707,22 @@ func (f *GitHubFormatter) Format(diffs []Difference, opts *FormatOptions) string
 // It is bounded in practice anyway, by the filesystem: it reaches here either
 // from a file that had to exist to be read or from git's own repo-relative
 // path.
-func gitHubWriteCommand(sb *strings.Builder, cmd, title, msg, filePath string) {
+//
+// pos places the annotation on the changed lines of the to document. GitHub
+// only honors line= alongside file=, so it is dropped when there is no file,
+// and endLine= is written only when the change spans more than one line.
+func gitHubWriteCommand(sb *strings.Builder, cmd, title, msg, filePath string, pos *Position) {
 	msg = escapeGitHubData(truncateRunes(msg, gitHubMaxMessageRunes))
 	title = escapeGitHubProperty(title)
 	if filePath != "" {
-		fmt.Fprintf(sb, "::%s file=%s,title=%s::%s\n", cmd, escapeGitHubProperty(filePath), title, msg)
+		fmt.Fprintf(sb, "::%s file=%s", cmd, escapeGitHubProperty(filePath))
+		if pos != nil {
+			fmt.Fprintf(sb, ",line=%d", pos.Line)
+			if pos.EndLine > pos.Line {
+				fmt.Fprintf(sb, ",endLine=%d", pos.EndLine)
+			}
+		}
+		fmt.Fprintf(sb, ",title=%s::%s\n", title, msg)
 	} else {
 		fmt.Fprintf(sb, "::%s title=%s::%s\n", cmd, title, msg)
 	}
@@ -500,7 +736,7 @@ func gitHubWriteSummaries(sb *strings.Builder, omitted map[string]int) {
 	for _, cmd := range []string{"notice", "warning", "error"} {
 		if n := omitted[cmd]; n > 0 {
 			msg := fmt.Sprintf("%d additional %s annotations omitted due to GitHub Actions limit", n, cmd)
-			gitHubWriteCommand(sb, cmd, "diffyml", msg, "")
+			gitHubWriteCommand(sb, cmd, "diffyml", msg, "", nil)
 		}
 	}
 }
@@ -519,7 +755,7 @@ func (f *GitHubFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) str
 		for _, diff := range group.Diffs {
 			cmd, title := gitHubCommand(diff.Type)
 			if counts[cmd] < gitHubAnnotationLimit {
-				gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), group.FilePath)
+				gitHubWriteCommand(&sb, cmd, title, githubDiffDescription(diff, opts), group.FilePath, diff.ToPos)
 				counts[cmd]++
 			} else {
 				omitted[cmd]++
@@ -536,16 +772,26 @@ func (f *GitHubFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) str
 // location.lines.begin, unique SHA-256 fingerprints, and severity per diff type.
 type GitLabFormatter struct{}
 
+// gitLabBeginLine returns location.lines.begin for a diff: the line of the
+// change in the to document. Code Quality requires the field, so a diff with no
+// to-side position (a document removed from the stream) falls back to line 1.
+func gitLabBeginLine(diff Difference) int {
+	if diff.ToPos != nil {
+		return diff.ToPos.Line
+	}
+	return 1
+}
+
 // gitLabSeverity returns the Code Quality severity for a diff type.
 func gitLabSeverity(dt DiffType) string {
 	switch dt {
 	case DiffAdded:
 		return "info"
-	case DiffRemoved, DiffModified:
+	case DiffRemoved, DiffModified, DiffTypeChanged, DiffWarning:
 		return "major"
-	case DiffUnchanged:
+	case DiffUnchanged, DiffCommentChanged:
 		return "info"
-	default: // DiffOrderChanged
+	default: // DiffOrderChanged, DiffMoved
 		return "minor"
 	}
 }
@@ -559,8 +805,16 @@ func gitLabCheckName(dt DiffType) string {
 		return "diffyml/removed"
 	case DiffModified:
 		return "diffyml/modified"
+	case DiffTypeChanged:
+		return "diffyml/type-changed"
 	case DiffUnchanged:
 		return "diffyml/unchanged"
+	case DiffCommentChanged:
+		return "diffyml/comment-changed"
+	case DiffMoved:
+		return "diffyml/moved"
+	case DiffWarning:
+		return "diffyml/cert-warning"
 	default: // DiffOrderChanged
 		return "diffyml/order-changed"
 	}
@@ -582,8 +836,8 @@ func gitLabFingerprint(filePath, description string) string {
 func (f *GitLabFormatter) FormatSingle(diff Difference, opts *FormatOptions) string {
 	desc := diffDescription(diff)
 	return fmt.Sprintf(
-		`{"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": 1}}}`+"\n",
-		desc, gitLabCheckName(diff.Type), gitLabFingerprint("", desc), gitLabSeverity(diff.Type), diff.Path,
+		`{"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": %d}}}`+"\n",
+		desc, gitLabCheckName(diff.Type), gitLabFingerprint("", desc), gitLabSeverity(diff.Type), diff.Path, gitLabBeginLine(diff),
 	)
 }
 
@@ -602,13 +856,14 @@ func (f *GitLabFormatter) Format(diffs []Difference, opts *FormatOptions) string
 
 	for i, diff := range diffs {
 		desc := diffDescription(diff)
+		// A location needs a file in the repository; stdin has none.
 		locationPath := opts.FilePath
-		if locationPath == "" {
+		if locationPath == "" || locationPath == StdinLabel {
 			locationPath = diff.Path.String()
 		}
 		fmt.Fprintf(&sb,
-			`  {"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": 1}}}`,
-			desc, gitLabCheckName(diff.Type), gitLabFingerprint(opts.FilePath, desc), gitLabSeverity(diff.Type), locationPath)
+			`  {"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": %d}}}`,
+			desc, gitLabCheckName(diff.Type), gitLabFingerprint(opts.FilePath, desc), gitLabSeverity(diff.Type), locationPath, gitLabBeginLine(diff))
 
 		if i < len(diffs)-1 {
 			sb.WriteString(",")
@@ -642,8 +897,8 @@ func (f *GitLabFormatter) FormatAll(groups []DiffGroup, _ *FormatOptions) string
 			baseDesc := diffDescription(diff)
 			displayDesc := fmt.Sprintf("[%s] %s", group.FilePath, baseDesc)
 			fmt.Fprintf(&sb,
-				`  {"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": 1}}}`,
-				displayDesc, gitLabCheckName(diff.Type), gitLabFingerprint(group.FilePath, baseDesc), gitLabSeverity(diff.Type), group.FilePath)
+				`  {"description": %q, "check_name": %q, "fingerprint": %q, "severity": %q, "location": {"path": %q, "lines": {"begin": %d}}}`,
+				displayDesc, gitLabCheckName(diff.Type), gitLabFingerprint(group.FilePath, baseDesc), gitLabSeverity(diff.Type), group.FilePath, gitLabBeginLine(diff))
 
 			if idx < total-1 {
 				sb.WriteString(",")
@@ -657,18 +912,308 @@ func (f *GitLabFormatter) FormatAll(groups []DiffGroup, _ *FormatOptions) string
 	return sb.String()
 }
 
+// SARIFFormatter renders differences as a SARIF 2.1.0 log for code-scanning
+// dashboards. Every difference becomes one result in a single run; the rule is
+// its DiffType, the location is the changed line of the to file, and a
+// partial fingerprint keeps the result stable across uploads.
+type SARIFFormatter struct{}
+
+// sarifSchemaURI and sarifVersion identify the SARIF revision emitted.
+const (
+	sarifSchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion   = "2.1.0"
+)
+
+// sarifFingerprintKey names the partialFingerprints entry. The "/v1" suffix
+// follows the SARIF convention of versioning fingerprint algorithms, so a
+// future change to the hashed input can ship under a new key instead of
+// silently re-keying every existing alert.
+const sarifFingerprintKey = "diffyml/v1"
+
+// sarifStdinURI is the artifact URI of results without a file: content read
+// from stdin, or a library caller that set no path. Code scanning drops
+// results without a physical location, so these point at StdinLabel,
+// percent-encoded because "<" and ">" are not allowed in a URI.
+const sarifStdinURI = "%3Cstdin%3E"
+
+// sarifRuleTypes lists the DiffTypes in rule order. A result's ruleIndex is
+// its DiffType's position here, so the rules array is identical in every log.
+var sarifRuleTypes = []DiffType{DiffAdded, DiffRemoved, DiffModified, DiffOrderChanged, DiffUnchanged, DiffCommentChanged, DiffTypeChanged, DiffMoved, DiffWarning}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifLevel returns the SARIF result level for a diff type, mirroring the
+// GitHub command severities (error / warning / notice → note).
+func sarifLevel(dt DiffType) string {
+	switch dt {
+	case DiffRemoved:
+		return "error"
+	case DiffModified, DiffTypeChanged, DiffWarning:
+		return "warning"
+	default: // DiffAdded, DiffOrderChanged, DiffUnchanged, DiffCommentChanged, DiffMoved
+		return "note"
+	}
+}
+
+// sarifRuleName returns the human-readable rule name for a diff type.
+func sarifRuleName(dt DiffType) string {
+	switch dt {
+	case DiffAdded:
+		return "YAMLAdded"
+	case DiffRemoved:
+		return "YAMLRemoved"
+	case DiffModified:
+		return "YAMLModified"
+	case DiffTypeChanged:
+		return "YAMLTypeChanged"
+	case DiffUnchanged:
+		return "YAMLUnchanged"
+	case DiffCommentChanged:
+		return "YAMLCommentChanged"
+	case DiffMoved:
+		return "YAMLMoved"
+	case DiffWarning:
+		return "CertificateWarning"
+	default: // DiffOrderChanged
+		return "YAMLOrderChanged"
+	}
+}
+
+// sarifRuleDescription returns the rule's short description for a diff type.
+func sarifRuleDescription(dt DiffType) string {
+	switch dt {
+	case DiffAdded:
+		return "A YAML value was added."
+	case DiffRemoved:
+		return "A YAML value was removed."
+	case DiffModified:
+		return "A YAML value was modified."
+	case DiffTypeChanged:
+		return "A YAML value changed type."
+	case DiffUnchanged:
+		return "A YAML value is unchanged."
+	case DiffCommentChanged:
+		return "A YAML comment changed."
+	case DiffMoved:
+		return "A YAML subtree was moved or renamed."
+	case DiffWarning:
+		return "A certificate fails a policy check."
+	default: // DiffOrderChanged
+		return "The order of a YAML list changed."
+	}
+}
+
+// sarifRules builds the driver's rules array: one rule per DiffType, with
+// the same ids as the GitLab check names so both reports group alike.
+func sarifRules() []sarifRule {
+	rules := make([]sarifRule, len(sarifRuleTypes))
+	for i, dt := range sarifRuleTypes {
+		rules[i] = sarifRule{
+			ID:                   gitLabCheckName(dt),
+			Name:                 sarifRuleName(dt),
+			ShortDescription:     sarifMessage{Text: sarifRuleDescription(dt)},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(dt)},
+		}
+	}
+	return rules
+}
+
+// sarifResultFor converts one difference into a SARIF result. filePath is the
+// artifact the result belongs to; when empty or StdinLabel the result points
+// at the placeholder sarifStdinURI. The YAML path is the logical location. The
+// fingerprint is the GitLab one, so a finding keeps the same identity in both
+// reports.
+func sarifResultFor(diff Difference, filePath string) sarifResult {
+	desc := diffDescription(diff)
+	uri := filePath
+	if uri == "" || uri == StdinLabel {
+		uri = sarifStdinURI
+	}
+	phys := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}
+	if diff.ToPos != nil {
+		phys.Region = &sarifRegion{StartLine: diff.ToPos.Line, StartColumn: diff.ToPos.Column, EndLine: diff.ToPos.EndLine}
+	}
+	loc := sarifLocation{
+		PhysicalLocation: phys,
+		LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: diff.Path.String()}},
+	}
+	return sarifResult{
+		RuleID:              gitLabCheckName(diff.Type),
+		RuleIndex:           slices.Index(sarifRuleTypes, diff.Type),
+		Level:               sarifLevel(diff.Type),
+		Message:             sarifMessage{Text: desc},
+		Locations:           []sarifLocation{loc},
+		PartialFingerprints: map[string]string{sarifFingerprintKey: gitLabFingerprint(filePath, desc)},
+	}
+}
+
+// sarifMarshal wraps results in a single-run log and marshals it. A log with
+// no results is still a complete document, so uploads of a clean comparison
+// clear previously reported alerts instead of failing.
+func sarifMarshal(results []sarifResult) string {
+	if results == nil {
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "diffyml",
+				InformationURI: "https://github.com/szhekpisov/diffyml",
+				Rules:          sarifRules(),
+			}},
+			Results: results,
+		}},
+	}
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "{}\n"
+	}
+	return string(out) + "\n"
+}
+
+// Format renders differences as a SARIF log. opts.FilePath becomes each
+// result's artifact URI.
+func (f *SARIFFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	filePath := ""
+	if opts != nil {
+		filePath = opts.FilePath
+	}
+	results := make([]sarifResult, len(diffs))
+	for i, diff := range diffs {
+		results[i] = sarifResultFor(diff, filePath)
+	}
+	return sarifMarshal(results)
+}
+
+// FormatAll renders all diff groups as one SARIF run for directory mode, each
+// result located in its group's file. Implements StructuredFormatter interface.
+func (f *SARIFFormatter) FormatAll(groups []DiffGroup, _ *FormatOptions) string {
+	var results []sarifResult
+	for _, group := range groups {
+		for _, diff := range group.Diffs {
+			results = append(results, sarifResultFor(diff, group.FilePath))
+		}
+	}
+	return sarifMarshal(results)
+}
+
 // JSONFormatter renders differences as machine-readable JSON.
 // Output is a JSON array of objects, one per difference, with typed values.
 type JSONFormatter struct{}
 
 // jsonDiff is the JSON representation of a single difference.
 type jsonDiff struct {
-	Path          string `json:"path"`
-	Type          string `json:"type"`
-	From          any    `json:"from"`
-	To            any    `json:"to"`
-	DocumentIndex int    `json:"document_index"`
-	DocumentName  string `json:"document_name,omitempty"`
+	Path          string    `json:"path"`
+	Type          string    `json:"type"`
+	From          any       `json:"from"`
+	To            any       `json:"to"`
+	DocumentIndex int       `json:"document_index"`
+	DocumentName  string    `json:"document_name,omitempty"`
+	FromPosition  *Position `json:"from_position,omitempty"`
+	ToPosition    *Position `json:"to_position,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	Delta         string    `json:"delta,omitempty"`
+	FromType      string    `json:"from_type,omitempty"`
+	ToType        string    `json:"to_type,omitempty"`
+	// ListItem marks an added or removed value that is a whole list item
+	// rather than entries of the mapping at Path.
+	ListItem bool `json:"list_item,omitempty"`
+	// FromPath is the old path of a moved subtree, or the from-side path of
+	// a difference inside a mapped one.
+	FromPath string `json:"from_path,omitempty"`
+	// CertificateChanges are the field changes of a modified certificate or
+	// bundle (see CompareCertificates).
+	CertificateChanges []jsonCertChange `json:"certificate_changes,omitempty"`
+	// Warning describes the finding of a warning (see Options.CertPolicy).
+	Warning string `json:"warning,omitempty"`
+	// Within names the string a difference inside an embedded document lies
+	// within, which ApplyPatch replaces whole.
+	Within *jsonWithin `json:"within,omitempty"`
+}
+
+// jsonWithin is the string value a difference lies within: its path and the
+// text the to document holds.
+type jsonWithin struct {
+	Path string `json:"path"`
+	To   string `json:"to"`
+}
+
+// jsonCertChange is one field change of a certificate, at a path relative to
+// the value.
+type jsonCertChange struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
 }
 
 // jsonDirDiff extends jsonDiff with a file path for directory mode.
@@ -686,8 +1231,16 @@ func jsonDiffTypeName(dt DiffType) string {
 		return "removed"
 	case DiffModified:
 		return "modified"
+	case DiffTypeChanged:
+		return "type_changed"
 	case DiffUnchanged:
 		return "unchanged"
+	case DiffCommentChanged:
+		return "comment_changed"
+	case DiffMoved:
+		return "moved"
+	case DiffWarning:
+		return "warning"
 	default: // DiffOrderChanged
 		return "order_changed"
 	}
@@ -740,6 +1293,18 @@ func buildJSONDiff(diff Difference, opts *FormatOptions) jsonDiff {
 	if opts.UseGoPatchStyle {
 		path = diff.Path.GoPatchString()
 	}
+	var fromPath string
+	if diff.FromPath != nil {
+		fromPath = pathString(diff.FromPath, opts.UseGoPatchStyle)
+	}
+	var within *jsonWithin
+	if diff.within != nil {
+		within = &jsonWithin{Path: pathString(diff.within.path, opts.UseGoPatchStyle), To: diff.within.to}
+	}
+	var certChanges []jsonCertChange
+	if !opts.NoCertInspection && diff.Type == DiffModified {
+		certChanges = buildJSONCertChanges(diff.From, diff.To)
+	}
 	return jsonDiff{
 		Path:          path,
 		Type:          jsonDiffTypeName(diff.Type),
@@ -747,9 +1312,42 @@ func buildJSONDiff(diff Difference, opts *FormatOptions) jsonDiff {
 		To:            jsonPrepareValue(diff.To),
 		DocumentIndex: diff.DocumentIndex,
 		DocumentName:  diff.DocumentName,
+		FromPosition:  diff.FromPos,
+		ToPosition:    diff.ToPos,
+		Comment:       string(diff.Comment),
+		Delta:         diff.Delta,
+		FromType:      diff.FromType,
+		ToType:        diff.ToType,
+		ListItem:      diff.listEntry && (diff.Type == DiffAdded || diff.Type == DiffRemoved),
+		FromPath:      fromPath,
+
+		CertificateChanges: certChanges,
+		Warning:            diff.Warning,
+		Within:             within,
 	}
 }
 
+// buildJSONCertChanges returns the field changes between two certificate
+// values, or nil when they are not both certificates.
+func buildJSONCertChanges(from, to any) []jsonCertChange {
+	fromStr, fromOk := from.(string)
+	toStr, toOk := to.(string)
+	if !fromOk || !toOk {
+		return nil
+	}
+	changes, _ := CompareCertificates(fromStr, toStr)
+	var out []jsonCertChange
+	for _, c := range changes {
+		out = append(out, jsonCertChange{
+			Path: c.Path.String(),
+			Type: jsonDiffTypeName(c.Type),
+			From: jsonPrepareValue(c.From),
+			To:   jsonPrepareValue(c.To),
+		})
+	}
+	return out
+}
+
 // FormatSingle renders a single difference as a JSON object (without array wrapper).
 func (f *JSONFormatter) FormatSingle(diff Difference, opts *FormatOptions) string {
 	if opts == nil {
@@ -814,7 +1412,8 @@ func (f *JSONFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) strin
 
 // JSONPatchFormatter renders differences as an RFC 6902 JSON Patch array.
 // DiffOrderChanged is skipped (RFC 6902 has no reorder operation). DiffUnchanged
-// is likewise skipped (no "unchanged" op), so inverse mode yields an empty patch.
+// is likewise skipped (no "unchanged" op), so inverse mode yields an empty patch,
+// and so is DiffCommentChanged, since JSON has no comments.
 type JSONPatchFormatter struct{}
 
 // jsonPatchOp is a single RFC 6902 operation (add/replace).
@@ -831,6 +1430,13 @@ type jsonPatchRemoveOp struct {
 	Path string `json:"path"`
 }
 
+// jsonPatchMoveOp is an RFC 6902 "move" operation.
+type jsonPatchMoveOp struct {
+	Op   string `json:"op"`
+	From string `json:"from"`
+	Path string `json:"path"`
+}
+
 // jsonPatchDirGroup wraps per-file patches for directory mode.
 type jsonPatchDirGroup struct {
 	File  string `json:"file"`
@@ -838,15 +1444,18 @@ type jsonPatchDirGroup struct {
 }
 
 // rfc6902OpName maps DiffType to RFC 6902 operation names.
-// Returns empty string for DiffOrderChanged and DiffUnchanged (both skipped).
+// Returns empty string for DiffOrderChanged, DiffUnchanged,
+// DiffCommentChanged and DiffWarning (all skipped).
 func rfc6902OpName(dt DiffType) string {
 	switch dt {
 	case DiffAdded:
 		return "add"
 	case DiffRemoved:
 		return "remove"
-	case DiffModified:
+	case DiffModified, DiffTypeChanged:
 		return "replace"
+	case DiffMoved:
+		return "move"
 	default:
 		return ""
 	}
@@ -855,8 +1464,12 @@ func rfc6902OpName(dt DiffType) string {
 // buildJSONPatchPath builds an RFC 6901 JSON Pointer path for a difference.
 // Multi-document diffs get the document index prepended as the first segment.
 func buildJSONPatchPath(diff Difference) string {
-	path := diff.Path
+	return jsonPatchPointer(diff.Path)
+}
 
+// jsonPatchPointer renders a path as an RFC 6901 JSON Pointer, a document
+// index as the first segment.
+func jsonPatchPointer(path DiffPath) string {
 	// Prepend document index for multi-document YAML
 	if idx, rest, ok := path.DocIndexPrefix(); ok {
 		return "/" + strconv.Itoa(idx) + rest.JSONPointerString()
@@ -869,40 +1482,47 @@ func buildJSONPatchPath(diff Difference) string {
 // The diff engine reports map key adds/removes at the parent path with a
 // single-key *OrderedMap wrapping the actual key-value pair. This function
 // appends the key to the path and unwraps the value for RFC 6902 compatibility.
-// Multi-key OrderedMaps (e.g. list items matched by identifier) are left as-is.
+// Multi-key OrderedMaps, whole list items and items added to or removed from
+// a list matched by identifier are left as-is.
 func expandMapKeyDiff(diff Difference) Difference {
+	if diff.listEntry || diff.pointerItem != "" {
+		return diff
+	}
 	switch diff.Type {
 	case DiffRemoved:
 		if om, ok := diff.From.(*OrderedMap); ok && len(om.Keys) == 1 {
 			key := om.Keys[0]
 			diff.Path = diff.Path.Append(key)
 			diff.From = om.Values[key]
+			if diff.FromPath != nil {
+				diff.FromPath = diff.FromPath.Append(key)
+			}
 		}
 	case DiffAdded:
 		if om, ok := diff.To.(*OrderedMap); ok && len(om.Keys) == 1 {
 			key := om.Keys[0]
 			diff.Path = diff.Path.Append(key)
 			diff.To = om.Values[key]
+			if diff.FromPath != nil {
+				diff.FromPath = diff.FromPath.Append(key)
+			}
 		}
 	}
 	return diff
 }
 
-// buildJSONPatchOp converts a Difference to an RFC 6902 operation struct.
-// Returns nil for DiffOrderChanged.
+// buildJSONPatchOp converts a Difference, already expanded by
+// expandMapKeyDiff and of a type rfc6902OpName maps, to an RFC 6902
+// operation struct.
 func buildJSONPatchOp(diff Difference) any {
 	op := rfc6902OpName(diff.Type)
-	if op == "" {
-		return nil
-	}
-
-	// Expand parent-path map-key diffs to child-path diffs.
-	diff = expandMapKeyDiff(diff)
-
 	pointer := buildJSONPatchPath(diff)
 
-	if diff.Type == DiffRemoved {
+	switch diff.Type {
+	case DiffRemoved:
 		return jsonPatchRemoveOp{Op: op, Path: pointer}
+	case DiffMoved:
+		return jsonPatchMoveOp{Op: op, From: jsonPatchPointer(diff.FromPath), Path: pointer}
 	}
 
 	return jsonPatchOp{
@@ -912,15 +1532,63 @@ func buildJSONPatchOp(diff Difference) any {
 	}
 }
 
-// Format renders differences as an RFC 6902 JSON Patch array.
-func (f *JSONPatchFormatter) Format(diffs []Difference, _ *FormatOptions) string {
-	ops := make([]any, 0, len(diffs))
+// jsonPatchOps converts differences to RFC 6902 operations in an order that
+// applies them one after another, as orderDiffOps replays JSON output: moves
+// go first, since the changes inside a moved subtree name its new path, and
+// whole list items and documents, which shift the indices after them, are
+// removed and added after every other operation, deepest paths first and at
+// equal depth removals last-first. A set element or an item of a list
+// matched by identifier, reported at its list's path, is removed by its index
+// in the from list or its identifier and added at the list's end. The
+// differences inside an embedded document become one replacement of the
+// string holding it, since a pointer cannot address the inside of a string.
+func jsonPatchOps(diffs []Difference) []any {
+	var moves, ordered, removals, additions []Difference
+	replaced := make(map[*embeddedString]bool)
 	for _, diff := range diffs {
-		if op := buildJSONPatchOp(diff); op != nil {
-			ops = append(ops, op)
+		if rfc6902OpName(diff.Type) == "" {
+			continue
+		}
+		if w := diff.within; w != nil {
+			if !replaced[w] {
+				replaced[w] = true
+				ordered = append(ordered, Difference{Path: w.path, Type: DiffModified, To: w.to})
+			}
+			continue
 		}
+		diff = expandMapKeyDiff(diff)
+		if diff.pointerItem != "" {
+			diff.Path = diff.Path.Append(diff.pointerItem)
+		}
+		shifts := diff.listEntry || diff.pointerItem != "" || diff.Path.IsBareDocIndex()
+		switch {
+		case diff.Type == DiffMoved:
+			moves = append(moves, diff)
+		case shifts && diff.Type == DiffRemoved:
+			removals = append(removals, diff)
+		case shifts && diff.Type == DiffAdded:
+			additions = append(additions, diff)
+		default:
+			ordered = append(ordered, diff)
+		}
+	}
+	slices.Reverse(removals)
+	structural := append(removals, additions...)
+	slices.SortStableFunc(structural, func(x, y Difference) int {
+		return len(y.Path) - len(x.Path)
+	})
+	ordered = append(append(moves, ordered...), structural...)
+
+	ops := make([]any, 0, len(ordered))
+	for _, diff := range ordered {
+		ops = append(ops, buildJSONPatchOp(diff))
 	}
-	return jsonMarshalIndent(ops)
+	return ops
+}
+
+// Format renders differences as an RFC 6902 JSON Patch array.
+func (f *JSONPatchFormatter) Format(diffs []Difference, _ *FormatOptions) string {
+	return jsonMarshalIndent(jsonPatchOps(diffs))
 }
 
 // FormatAll renders all diff groups as file-grouped JSON Patch arrays for directory mode.
@@ -928,13 +1596,7 @@ func (f *JSONPatchFormatter) Format(diffs []Difference, _ *FormatOptions) string
 func (f *JSONPatchFormatter) FormatAll(groups []DiffGroup, _ *FormatOptions) string {
 	result := make([]jsonPatchDirGroup, 0, len(groups))
 	for _, group := range groups {
-		ops := make([]any, 0, len(group.Diffs))
-		for _, diff := range group.Diffs {
-			if op := buildJSONPatchOp(diff); op != nil {
-				ops = append(ops, op)
-			}
-		}
-		if len(ops) > 0 {
+		if ops := jsonPatchOps(group.Diffs); len(ops) > 0 {
 			result = append(result, jsonPatchDirGroup{
 				File:  group.FilePath,
 				Patch: ops,
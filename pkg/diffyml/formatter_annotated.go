@@ -0,0 +1,665 @@
+// formatter_annotated.go - Full-document YAML view with change gutters.
+//
+// Prints each changed document of the to side in full, re-serialized in input
+// key order with the ordered serialization of serialize.go, and marks every
+// line a difference covers in a gutter: + added, ~ modified, = unchanged
+// (inverse mode). A modified scalar carries its old value in a trailing
+// comment; a multiline old value is printed above the new one instead.
+// Removed entries are printed, marked -, at the end of the collection they
+// were removed from. Unchanged subtrees longer than FormatOptions.FoldUnchanged
+// lines fold to their first line, and regions FormatOptions.Filter hides fold
+// to a line count, so the view agrees with what --filter and --exclude report.
+//
+// Lines are matched to differences by source position, so the document comes
+// from FormatOptions.ToSource. Without it the formatter falls back to the
+// detailed output.
+// Key types: AnnotatedFormatter.
+package diffyml
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// AnnotatedFormatter renders each changed document in full with a change
+// gutter.
+type AnnotatedFormatter struct{}
+
+// annLine is one output line of the annotated view.
+type annLine struct {
+	Indent   int
+	Text     string
+	Type     DiffType
+	Marked   bool   // covered by a difference; Type says which kind
+	Note     string // trailing comment, such as a modified scalar's old value
+	Fold     int    // lines hidden behind this fold marker; 0 for other lines
+	Filtered bool   // the fold hides a filtered region, not an unchanged one
+}
+
+// annSpan returns how many document lines lines stand for, counting the
+// lines hidden behind fold markers.
+func annSpan(lines []annLine) int {
+	n := 0
+	for _, l := range lines {
+		n += max(l.Fold, 1)
+	}
+	return n
+}
+
+// annMark is the source range a difference covers: from its start position
+// to the end of its last line, and short of the node that follows it, so a
+// change in a flow collection leaves its later siblings on the line unmarked.
+type annMark struct {
+	Diff         *Difference
+	Line, Column int
+	EndLine      int
+	Next         [2]int // start of the following node; zero when none follows
+}
+
+// covers reports whether a node starting at line and column lies in the range.
+func (m *annMark) covers(line, column int) bool {
+	if m.Next != [2]int{} && (line > m.Next[0] || line == m.Next[0] && column >= m.Next[1]) {
+		return false
+	}
+	return (line > m.Line || line == m.Line && column >= m.Column) && line <= m.EndLine
+}
+
+// annNextStarts maps the start of every node under n to the start of the
+// node that follows it: its next sibling, or what follows its collection.
+// Nodes sharing a start, such as a block mapping and its first key, take the
+// outermost node's.
+func annNextStarts(n *yaml.Node, next [2]int, starts map[[2]int][2]int) {
+	if _, ok := starts[[2]int{n.Line, n.Column}]; !ok {
+		starts[[2]int{n.Line, n.Column}] = next
+	}
+	step := 1
+	if n.Kind == yaml.MappingNode {
+		step = 2
+	}
+	for i, child := range n.Content {
+		childNext := next
+		if j := i - i%step + step; j < len(n.Content) {
+			childNext = [2]int{n.Content[j].Line, n.Content[j].Column}
+		}
+		annNextStarts(child, childNext, starts)
+	}
+}
+
+// annFilter decides which document regions FormatOptions.Filter hides.
+type annFilter struct {
+	opts             *FilterOptions
+	include, exclude []*regexp.Regexp
+}
+
+// newAnnFilter returns nil when opts hides nothing or holds an invalid
+// pattern; the comparison reports invalid patterns before formatting.
+func newAnnFilter(opts *FilterOptions) *annFilter {
+	if opts == nil || len(opts.IncludePaths)+len(opts.ExcludePaths)+len(opts.IncludeRegexp)+len(opts.ExcludeRegexp) == 0 {
+		return nil
+	}
+	include, err := compileRegexPatterns(opts.IncludeRegexp)
+	if err != nil {
+		return nil
+	}
+	exclude, err := compileRegexPatterns(opts.ExcludeRegexp)
+	if err != nil {
+		return nil
+	}
+	return &annFilter{opts: opts, include: include, exclude: exclude}
+}
+
+// hides reports whether the node at aliases is filtered out. A collection
+// outside every include path stays visible while an include path lies below
+// it, so the included entries keep their place in the document.
+func (f *annFilter) hides(aliases []DiffPath, leaf bool) bool {
+	if f == nil {
+		return false
+	}
+	if anyAliasMatches(aliases, f.opts.ExcludePaths, f.exclude) {
+		return true
+	}
+	if len(f.opts.IncludePaths) == 0 && len(f.include) == 0 {
+		return false
+	}
+	if anyAliasMatches(aliases, f.opts.IncludePaths, f.include) {
+		return false
+	}
+	if leaf {
+		return true
+	}
+	// A regex may match any descendant, so containers stay open for it.
+	return len(f.include) == 0 && !f.includesBelow(aliases)
+}
+
+// includesBelow reports whether an include path names a descendant of the
+// node at aliases.
+func (f *annFilter) includesBelow(aliases []DiffPath) bool {
+	for _, alias := range aliases {
+		prefix := alias.String()
+		for _, p := range f.opts.IncludePaths {
+			if prefix == "" || strings.HasPrefix(p, prefix+".") || strings.HasPrefix(p, prefix+"[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// annotator renders one to-side document.
+type annotator struct {
+	opts        *FormatOptions
+	cover       map[int][]*annMark       // added, modified and unchanged ranges, by covered line
+	starts      map[[2]int][]*annMark    // modified and order-changed ranges, by start position
+	comments    map[[2]int][]*Difference // comment changes, by the position of the line they belong to
+	removed     map[[2]int][]Difference  // removed entries, by container position
+	moved       map[[2]int]*Difference   // moved entries, by key position
+	masked      map[*yaml.Node]bool
+	placeholder string
+	filter      *annFilter
+	ids         *Options // list identifier settings for filter paths
+}
+
+// newAnnotator indexes diffs by the to-side positions they cover in root.
+func newAnnotator(root *yaml.Node, diffs []Difference, opts *FormatOptions, filter *annFilter) *annotator {
+	a := &annotator{
+		opts:     opts,
+		cover:    make(map[int][]*annMark),
+		starts:   make(map[[2]int][]*annMark),
+		comments: make(map[[2]int][]*Difference),
+		removed:  make(map[[2]int][]Difference),
+		moved:    make(map[[2]int]*Difference),
+		filter:   filter,
+	}
+	next := make(map[[2]int][2]int)
+	annNextStarts(root, [2]int{}, next)
+	for i := range diffs {
+		d := &diffs[i]
+		if d.ToPos == nil || d.ToPos.Line < 1 {
+			continue
+		}
+		start := [2]int{d.ToPos.Line, d.ToPos.Column}
+		if d.Type == DiffRemoved {
+			a.removed[start] = append(a.removed[start], *d)
+			continue
+		}
+		if d.Type == DiffMoved {
+			// The changes inside the moved subtree are marked on their own.
+			a.moved[start] = d
+			continue
+		}
+		m := &annMark{Diff: d, Line: d.ToPos.Line, Column: d.ToPos.Column, EndLine: max(d.ToPos.EndLine, d.ToPos.Line), Next: next[start]}
+		if d.Type == DiffCommentChanged {
+			a.comments[start] = append(a.comments[start], d)
+			continue
+		}
+		if d.Type == DiffModified || d.Type == DiffTypeChanged || d.Type == DiffOrderChanged || d.Type == DiffWarning {
+			a.starts[start] = append(a.starts[start], m)
+		}
+		if d.Type == DiffOrderChanged || d.Type == DiffWarning {
+			// The reordered items are all still there, and a warned-about
+			// value need not have changed; the note says what it is about.
+			continue
+		}
+		for line := m.Line; line <= m.EndLine; line++ {
+			a.cover[line] = append(a.cover[line], m)
+		}
+	}
+	return a
+}
+
+// markAt returns the strongest difference type covering a node starting at
+// n's position.
+func (a *annotator) markAt(n *yaml.Node) (DiffType, bool) {
+	var t DiffType
+	marked := false
+	for _, m := range a.cover[n.Line] {
+		if m.covers(n.Line, n.Column) && (!marked || sbsMarkRank(m.Diff.Type) > sbsMarkRank(t)) {
+			t, marked = m.Diff.Type, true
+		}
+	}
+	return t, marked
+}
+
+// annotate adds the notes of differences starting exactly at n to the first
+// of lines, which render n. wrap serializes a value in the shape of those
+// lines, such as "key: value" for a mapping entry.
+func (a *annotator) annotate(lines []annLine, n *yaml.Node, wrap func(any) []string) []annLine {
+	if len(lines) == 0 {
+		return lines
+	}
+	var notes []string
+	var old []annLine
+	for _, m := range a.starts[[2]int{n.Line, n.Column}] {
+		if m.Diff.Type == DiffOrderChanged {
+			notes = append(notes, "order changed")
+			if !lines[0].Marked {
+				lines[0].Type, lines[0].Marked = DiffOrderChanged, true
+			}
+			continue
+		}
+		if m.Diff.Type == DiffWarning {
+			notes = append(notes, "⚠ "+m.Diff.Warning)
+			if !lines[0].Marked {
+				lines[0].Type, lines[0].Marked = DiffWarning, true
+			}
+			continue
+		}
+		if was := annotatedYAML(m.Diff.From); len(was) == 1 {
+			note := "was: " + was[0]
+			if m.Diff.Type == DiffTypeChanged {
+				note += " (" + m.Diff.FromType + ")"
+			}
+			notes = append(notes, note)
+			continue
+		}
+		for _, text := range wrap(m.Diff.From) {
+			old = append(old, annLine{Indent: lines[0].Indent, Text: text, Type: DiffRemoved, Marked: true})
+		}
+	}
+	if len(notes) > 0 {
+		lines[0].Note = strings.Join(notes, "; ")
+	}
+	if len(old) > 0 {
+		lines = append(old, lines...)
+	}
+	return lines
+}
+
+// annotateMove notes where the mapping entry whose key is key was moved
+// from, on the first of lines.
+func (a *annotator) annotateMove(lines []annLine, key *yaml.Node) []annLine {
+	d := a.moved[[2]int{key.Line, key.Column}]
+	if len(lines) == 0 || d == nil {
+		return lines
+	}
+	note := "moved from " + sectionPathLabel(d.FromPath, a.opts)
+	if lines[0].Note != "" {
+		note = lines[0].Note + "; " + note
+	}
+	lines[0].Note = note
+	if !lines[0].Marked {
+		lines[0].Type, lines[0].Marked = DiffMoved, true
+	}
+	return lines
+}
+
+// annotateComments adds the comment changes belonging to the line n starts
+// on to the first of lines. A collection starts where its first entry does,
+// so each change is taken once, by the innermost node rendered: the first
+// line is the same for all of them.
+func (a *annotator) annotateComments(lines []annLine, n *yaml.Node) []annLine {
+	at := [2]int{n.Line, n.Column}
+	if len(lines) == 0 || len(a.comments[at]) == 0 {
+		return lines
+	}
+	notes := make([]string, 0, len(a.comments[at])+1)
+	if lines[0].Note != "" {
+		notes = append(notes, lines[0].Note)
+	}
+	for _, d := range a.comments[at] {
+		notes = append(notes, fmt.Sprintf("%s comment: %s → %s", d.Comment, formatComment(d.From), formatComment(d.To)))
+	}
+	delete(a.comments, at)
+	lines[0].Note = strings.Join(notes, "; ")
+	if !lines[0].Marked {
+		lines[0].Type, lines[0].Marked = DiffCommentChanged, true
+	}
+	return lines
+}
+
+// value returns the scalar value of n, or the mask placeholder when n, or
+// anything an alias at n leads to, is masked.
+func (a *annotator) value(n *yaml.Node) any {
+	if a.hasMasked(resolveAlias(n)) {
+		return a.placeholder
+	}
+	return nodeToInterface(n)
+}
+
+// hasMasked reports whether n or one of its descendants is masked.
+func (a *annotator) hasMasked(n *yaml.Node) bool {
+	if n == nil || len(a.masked) == 0 {
+		return false
+	}
+	if a.masked[n] {
+		return true
+	}
+	for _, c := range n.Content {
+		if a.hasMasked(resolveAlias(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAnnLeaf reports whether n renders as one serialized block rather than
+// child by child: scalars, aliases and empty collections.
+func isAnnLeaf(n *yaml.Node) bool {
+	return n.Kind != yaml.MappingNode && n.Kind != yaml.SequenceNode || len(n.Content) == 0
+}
+
+// leaf renders a serialized value whose lines all belong to node n.
+func (a *annotator) leaf(text []string, indent int, n *yaml.Node) []annLine {
+	t, marked := a.markAt(n)
+	lines := make([]annLine, len(text))
+	for i, s := range text {
+		lines[i] = annLine{Indent: indent, Text: s, Type: t, Marked: marked}
+	}
+	return lines
+}
+
+// fold collapses an unchanged block longer than FoldUnchanged lines to its
+// first line and a count. Blocks holding a change or a filtered region stay
+// open.
+func (a *annotator) fold(lines []annLine, indent int) []annLine {
+	if a.opts.FoldUnchanged <= 0 || annSpan(lines) <= a.opts.FoldUnchanged {
+		return lines
+	}
+	for _, l := range lines {
+		if l.Marked || l.Filtered {
+			return lines
+		}
+	}
+	return []annLine{lines[0], {Indent: indent + 2, Fold: annSpan(lines[1:])}}
+}
+
+// filtered replaces a hidden region with a fold marker. A region that still
+// shows a difference stays visible.
+func (a *annotator) filtered(lines []annLine, aliases []DiffPath, leaf bool, indent int) []annLine {
+	if !a.filter.hides(aliases, leaf) {
+		return lines
+	}
+	for _, l := range lines {
+		if l.Marked {
+			return lines
+		}
+	}
+	return []annLine{{Indent: indent, Fold: annSpan(lines), Filtered: true}}
+}
+
+// entry renders one mapping entry.
+func (a *annotator) entry(key, val *yaml.Node, indent int, aliases []DiffPath) []annLine {
+	wrap := func(v any) []string {
+		return annotatedYAML(&OrderedMap{Keys: []string{key.Value}, Values: map[string]any{key.Value: v}})
+	}
+	var lines []annLine
+	if isAnnLeaf(val) {
+		at := val
+		if val.Line == 0 {
+			at = key
+		}
+		lines = a.leaf(wrap(a.value(val)), indent, at)
+		// An emptied collection still lists what was removed from it.
+		lines = append(lines, a.children(val, indent+2, aliases)...)
+	} else {
+		header := a.leaf([]string{annotatedKey(key.Value) + ":"}, indent, key)
+		lines = append(header, a.children(val, indent+2, aliases)...)
+	}
+	lines = a.annotateComments(a.annotateMove(a.annotate(lines, val, wrap), key), key)
+	return a.filtered(a.fold(lines, indent), aliases, isAnnLeaf(val), indent)
+}
+
+// item renders one sequence item. A collection item's first line takes the
+// item's dash.
+func (a *annotator) item(n *yaml.Node, indent int, aliases []DiffPath) []annLine {
+	wrap := func(v any) []string { return annotatedYAML([]any{v}) }
+	var lines []annLine
+	if isAnnLeaf(n) {
+		lines = a.leaf(wrap(a.value(n)), indent, n)
+		lines = append(lines, a.children(n, indent+2, aliases)...)
+	} else {
+		lines = a.children(n, indent+2, aliases)
+		if len(lines) > 0 {
+			lines[0].Indent = indent
+			lines[0].Text = "- " + lines[0].Text
+		}
+	}
+	lines = a.annotateComments(a.annotate(lines, n, wrap), n)
+	return a.filtered(a.fold(lines, indent), aliases, isAnnLeaf(n), indent)
+}
+
+// children renders the entries or items of a collection, then the entries
+// removed from it.
+func (a *annotator) children(n *yaml.Node, indent int, aliases []DiffPath) []annLine {
+	var lines []annLine
+	add := func(child []annLine) {
+		// Neighboring filtered regions read as one.
+		if len(child) == 1 && child[0].Filtered && len(lines) > 0 {
+			if last := &lines[len(lines)-1]; last.Filtered && last.Indent == child[0].Indent && last.Text == "" {
+				last.Fold += child[0].Fold
+				return
+			}
+		}
+		lines = append(lines, child...)
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			var child []DiffPath
+			if a.filter != nil {
+				child = mappingValuePathAliases(aliases, n.Content[i].Value)
+			}
+			add(a.entry(n.Content[i], n.Content[i+1], indent, child))
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			var child []DiffPath
+			if a.filter != nil {
+				child = sequenceValuePathAliases(aliases, nodeToInterface(item), i, a.ids)
+			}
+			add(a.item(item, indent, child))
+		}
+	}
+	for _, d := range a.removed[[2]int{n.Line, n.Column}] {
+		for _, text := range removedEntryYAML(d, n.Kind == yaml.MappingNode) {
+			lines = append(lines, annLine{Indent: indent, Text: text, Type: DiffRemoved, Marked: true})
+		}
+	}
+	return lines
+}
+
+// document renders a document's root node.
+func (a *annotator) document(root *yaml.Node, aliases []DiffPath) []annLine {
+	if !isAnnLeaf(root) {
+		return a.annotateComments(a.annotate(a.children(root, 0, aliases), root, annotatedYAML), root)
+	}
+	lines := a.leaf(annotatedYAML(a.value(root)), 0, root)
+	lines = append(lines, a.children(root, 0, aliases)...)
+	return a.annotateComments(a.annotate(lines, root, annotatedYAML), root)
+}
+
+// removedEntryYAML serializes a removed entry the way it sat in its
+// collection: as "key: value" in a mapping, as "- value" in a sequence.
+func removedEntryYAML(d Difference, mapping bool) []string {
+	if !mapping {
+		return annotatedYAML([]any{d.From})
+	}
+	if om, ok := d.From.(*OrderedMap); ok && len(om.Keys) == 1 {
+		return annotatedYAML(om)
+	}
+	key := d.Path.Last()
+	return annotatedYAML(&OrderedMap{Keys: []string{key}, Values: map[string]any{key: d.From}})
+}
+
+// annotatedYAML serializes a value with two-space indentation, split into
+// lines.
+func annotatedYAML(v any) []string {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(valueToYAMLNode(v)); err != nil {
+		return []string{fmt.Sprint(v)}
+	}
+	_ = enc.Close()
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// annotatedKey serializes a mapping key, quoting it where YAML requires.
+func annotatedKey(key string) string {
+	return annotatedYAML(key)[0]
+}
+
+// annValueLines lays out one difference as its old and new value under a
+// path comment, for sections without a to-side document, such as a removed
+// Kubernetes resource.
+func annValueLines(d Difference, opts *FormatOptions) []annLine {
+	d = expandMapKeyDiff(d)
+	var lines []annLine
+	if p := d.Path; !(p.IsEmpty() || p.IsBareDocIndex()) {
+		lines = append(lines, annLine{Text: "# " + sectionPathLabel(p, opts)})
+	}
+	if d.Type != DiffAdded {
+		for _, text := range annotatedYAML(d.From) {
+			lines = append(lines, annLine{Text: text, Type: DiffRemoved, Marked: true})
+		}
+	}
+	if d.Type != DiffRemoved {
+		for _, text := range annotatedYAML(d.To) {
+			lines = append(lines, annLine{Text: text, Type: DiffAdded, Marked: true})
+		}
+	}
+	return lines
+}
+
+// writeAnnotatedLines renders lines behind a two-column gutter.
+func writeAnnotatedLines(sb *strings.Builder, lines []annLine, opts *FormatOptions) {
+	p := resolvedPalette(opts)
+	for _, l := range lines {
+		indent := strings.Repeat(" ", l.Indent)
+		if l.Fold > 0 {
+			label := collapsedRunLabel(l.Fold)
+			if l.Filtered {
+				label = fmt.Sprintf("[%d %s filtered]", l.Fold, pluralize(l.Fold, "line", "lines"))
+			}
+			sb.WriteString("  " + indent + l.Text)
+			sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleContext, opts.TrueColor)))
+			sb.WriteString("# " + label)
+			sb.WriteString(colorEnd(opts))
+			sb.WriteString("\n")
+			continue
+		}
+		if l.Marked {
+			marker, role := sbsMarker(&sbsCell{Type: l.Type})
+			sb.WriteString(colorStart(opts, p.ColorCode(role, opts.TrueColor)))
+			sb.WriteString(marker + " " + indent + l.Text)
+			sb.WriteString(colorEnd(opts))
+		} else {
+			sb.WriteString("  " + indent + l.Text)
+		}
+		if l.Note != "" {
+			sb.WriteString("  ")
+			sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleContext, opts.TrueColor)))
+			sb.WriteString("# " + l.Note)
+			sb.WriteString(colorEnd(opts))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// Format renders each changed document in full with a change gutter.
+func (f *AnnotatedFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	if len(diffs) == 0 {
+		return emptyResultMessage(opts, " found")
+	}
+	docs, err := parse(opts.ToSource)
+	if len(opts.ToSource) == 0 || err != nil {
+		return (&DetailedFormatter{}).Format(diffs, opts)
+	}
+
+	placeholder := DefaultMaskPlaceholder
+	if opts.Mask != nil && opts.Mask.Placeholder != "" {
+		placeholder = opts.Mask.Placeholder
+	}
+	filter := newAnnFilter(opts.Filter)
+
+	type section struct {
+		Label string
+		Lines []annLine
+	}
+	var sections []section
+	for _, doc := range documentSections(diffs) {
+		idx, root := annotatedDocument(docs, doc.Diffs)
+		var lines []annLine
+		if root == nil {
+			for _, d := range doc.Diffs {
+				lines = append(lines, annValueLines(d, opts)...)
+			}
+			sections = append(sections, section{Label: doc.Label, Lines: lines})
+			continue
+		}
+		a := newAnnotator(root, doc.Diffs, opts, filter)
+		a.placeholder = placeholder
+		if filter != nil {
+			a.ids = identifierOptions(filter.opts.AdditionalIdentifiers, filter.opts.ListKeys, filter.opts.Schema).forResource(k8sNodeResource(root))
+		}
+		if opts.Mask != nil {
+			if a.masked, err = maskedScalarNodes(root, *opts.Mask); err != nil {
+				// An invalid pattern already failed MaskDifferences; showing
+				// nothing is the safe answer if a caller skipped it.
+				continue
+			}
+		}
+		aliases := []DiffPath{{}}
+		if len(docs) > 1 {
+			// Filters may name the document, as in "[1].spec".
+			aliases = append(aliases, DiffPath{fmt.Sprintf("[%d]", idx)})
+		}
+		sections = append(sections, section{Label: doc.Label, Lines: a.document(root, aliases)})
+	}
+
+	p := resolvedPalette(opts)
+	var sb strings.Builder
+	if !opts.OmitHeader {
+		noun := pluralize(len(diffs), "difference", "differences")
+		if opts.Unchanged {
+			noun = pluralize(len(diffs), "unchanged value", "unchanged values")
+		}
+		sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleModified, opts.TrueColor)))
+		fmt.Fprintf(&sb, "Found %s %s", formatCount(len(diffs)), noun)
+		sb.WriteString(colorEnd(opts))
+		sb.WriteString("\n\n")
+	}
+	for i, s := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if s.Label != "" {
+			sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleDocName, opts.TrueColor)))
+			sb.WriteString(s.Label)
+			sb.WriteString(colorEnd(opts))
+			sb.WriteString("\n")
+		}
+		writeAnnotatedLines(&sb, s.Lines, opts)
+	}
+	return sb.String()
+}
+
+// annotatedDocument returns the index and root node of the to-side document
+// holding a difference of diffs, or nil when none has a to-side position.
+func annotatedDocument(docs []*yaml.Node, diffs []Difference) (int, *yaml.Node) {
+	for _, d := range diffs {
+		if d.ToPos == nil {
+			continue
+		}
+		for i, doc := range docs {
+			if doc == nil {
+				continue
+			}
+			root := doc
+			if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+				root = root.Content[0]
+			}
+			if root.Line <= d.ToPos.Line && d.ToPos.Line <= max(nodeEndLine(root), root.Line) {
+				return i, root
+			}
+		}
+	}
+	return 0, nil
+}
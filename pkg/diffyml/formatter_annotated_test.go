@@ -0,0 +1,233 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+// annotated compares from and to and renders the result over the to source.
+func annotated(t *testing.T, from, to string, opts *FormatOptions) string {
+	t.Helper()
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	opts.ToSource = []byte(to)
+	return (&AnnotatedFormatter{}).Format(diffs, opts)
+}
+
+func TestFormatterByName_Annotated(t *testing.T) {
+	f, err := FormatterByName("annotated")
+	if err != nil {
+		t.Fatalf("FormatterByName(annotated) returned error: %v", err)
+	}
+	if _, ok := f.(*AnnotatedFormatter); !ok {
+		t.Errorf("expected *AnnotatedFormatter, got %T", f)
+	}
+}
+
+func TestAnnotatedFormatter_Format(t *testing.T) {
+	from := "app:\n  image: nginx:1.24\n  debug: true\n  port: 80\n  env:\n    - name: A\n      value: 1\n"
+	to := "app:\n  image: nginx:1.25\n  port: 80\n  env:\n    - name: NEW\n      value: 0\n    - name: A\n      value: 1\n  tls: on\n"
+	output := annotated(t, from, to, &FormatOptions{})
+
+	want := "Found four differences\n\n" +
+		"  app:\n" +
+		"~   image: nginx:1.25  # was: nginx:1.24\n" +
+		"    port: 80\n" +
+		"    env:\n" +
+		"+     - name: NEW\n" +
+		"+       value: 0\n" +
+		"      - name: A\n" +
+		"        value: 1\n" +
+		"+   tls: \"on\"\n" +
+		"-   debug: true\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_MultilineOldValue(t *testing.T) {
+	from := "script: |\n  echo a\n  echo b\nn: 1\n"
+	to := "script: |\n  echo a\n  echo c\nn: 1\n"
+	output := annotated(t, from, to, &FormatOptions{OmitHeader: true})
+
+	want := "- script: |\n" +
+		"-   echo a\n" +
+		"-   echo b\n" +
+		"~ script: |\n" +
+		"~   echo a\n" +
+		"~   echo c\n" +
+		"  n: 1\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_FlowCollections(t *testing.T) {
+	from := "labels: {a: 1, mem: 2}\nargs: [a, b, c]\nextra: {mem: 2}\n"
+	to := "labels: {a: 9, mem: 2}\nargs: [x, b, c]\nextra: {a: 1, mem: 2}\n"
+	output := annotated(t, from, to, &FormatOptions{OmitHeader: true})
+
+	want := "  labels:\n" +
+		"~   a: 9  # was: 1\n" +
+		"    mem: 2\n" +
+		"  args:\n" +
+		"~   - x  # was: a\n" +
+		"    - b\n" +
+		"    - c\n" +
+		"  extra:\n" +
+		"+   a: 1\n" +
+		"    mem: 2\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_OrderChangedAndEmptiedCollection(t *testing.T) {
+	from := "env:\n  - name: a\n  - name: b\nlabels:\n  k: v\n"
+	to := "env:\n  - name: b\n  - name: a\nlabels: {}\n"
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := (&AnnotatedFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true, ToSource: []byte(to)})
+
+	want := "~ env:  # order changed\n" +
+		"    - name: b\n" +
+		"    - name: a\n" +
+		"  labels: {}\n" +
+		"-   k: v\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_FoldUnchanged(t *testing.T) {
+	from := "big:\n  a: 1\n  b: 2\n  c: 3\nsmall:\n  a: 1\nx: 1\n"
+	to := strings.Replace(from, "x: 1", "x: 2", 1)
+	output := annotated(t, from, to, &FormatOptions{OmitHeader: true, FoldUnchanged: 2})
+
+	want := "  big:\n" +
+		"    # [3 lines unchanged]\n" +
+		"  small:\n" +
+		"    a: 1\n" +
+		"~ x: 2  # was: 1\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_FoldsFilteredRegions(t *testing.T) {
+	from := "meta:\n  name: a\n  team: x\nspec:\n  replicas: 1\n  image: old\nstatus:\n  ready: false\n"
+	to := "meta:\n  name: a\n  team: y\nspec:\n  replicas: 2\n  image: old\nstatus:\n  ready: true\n"
+	filter := &FilterOptions{IncludePaths: []string{"spec.replicas", "status"}, ExcludePaths: []string{"status"}}
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = FilterDiffsWithRegexp(diffs, filter); err != nil {
+		t.Fatal(err)
+	}
+	output := (&AnnotatedFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true, ToSource: []byte(to), Filter: filter})
+
+	// meta is outside every include path; status is excluded outright. The
+	// excluded status still counts the lines it hides.
+	want := "  # [3 lines filtered]\n" +
+		"  spec:\n" +
+		"~   replicas: 2  # was: 1\n" +
+		"    # [1 line filtered]\n" +
+		"  # [2 lines filtered]\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestAnnotatedFormatter_KubernetesDocuments(t *testing.T) {
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: old\n"
+	svc := "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n"
+	output := annotated(t, cm+"---\n"+secret, strings.Replace(cm, "k: a", "k: b", 1)+"---\n"+svc, &FormatOptions{OmitHeader: true})
+
+	for _, want := range []string{
+		"v1/ConfigMap/cfg\n  apiVersion: v1\n",
+		"~   k: b  # was: a\n",
+		// A removed document has no to side and is shown from its old value.
+		"\nv1/Secret/old\n- apiVersion: v1\n- kind: Secret\n",
+		"\nv1/Service/svc\n+ apiVersion: v1\n",
+		"+   name: svc\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestAnnotatedFormatter_MasksValues(t *testing.T) {
+	from := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\ndata:\n  password: aHVudGVyMg==\n  user: YWRtaW4=\n"
+	to := strings.Replace(from, "aHVudGVyMg==", "c3dvcmRmaXNo", 1)
+	mask := MaskOptions{MaskSecrets: true}
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = MaskDifferences(diffs, mask); err != nil {
+		t.Fatal(err)
+	}
+	output := (&AnnotatedFormatter{}).Format(diffs, &FormatOptions{ToSource: []byte(to), Mask: &mask})
+
+	for _, secret := range []string{"aHVudGVyMg==", "c3dvcmRmaXNo", "YWRtaW4="} {
+		if strings.Contains(output, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, output)
+		}
+	}
+	for _, want := range []string{"~   password: '***'  # was: '***'\n", "    user: '***'\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestAnnotatedFormatter_WithoutSourceFallsBackToDetailed(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffModified, From: 1, To: 2}}
+	opts := &FormatOptions{OmitHeader: true}
+	got := (&AnnotatedFormatter{}).Format(diffs, opts)
+	if want := (&DetailedFormatter{}).Format(diffs, opts); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAnnotatedFormatter_Color(t *testing.T) {
+	palette := DefaultCustomColorPalette()
+	palette.Added = &CustomColor{R: 1, G: 2, B: 3, ANSICode: colorGreen, IsCustom: true}
+	output := annotated(t, "a: 1\n", "a: 1\nb: 2\n", &FormatOptions{Color: true, TrueColor: true, Palette: palette})
+	if !strings.Contains(output, TrueColorCode(1, 2, 3)+"+ b: 2") {
+		t.Errorf("added line not colored with the palette:\n%q", output)
+	}
+}
+
+func TestAnnotatedFormatter_Empty(t *testing.T) {
+	if got := (&AnnotatedFormatter{}).Format(nil, nil); got != "no differences found\n" {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
+
+func TestAnnotatedFormatter_CommentChanges(t *testing.T) {
+	from := "app:\n  # owner: team-a\n  replicas: 3\n  ports:\n    - 80 # http\n"
+	to := "app:\n  # owner: team-b\n  replicas: 4\n  ports:\n    - 80 # web\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{CompareComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := (&AnnotatedFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true, ToSource: []byte(to)})
+
+	// The comment change of replicas belongs to its line, not to app, whose
+	// mapping starts at the same position.
+	want := "  app:\n" +
+		"~   replicas: 4  # was: 3; head comment: \"# owner: team-a\" → \"# owner: team-b\"\n" +
+		"    ports:\n" +
+		"#     - 80  # line comment: \"# http\" → \"# web\"\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
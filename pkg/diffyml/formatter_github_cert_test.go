@@ -37,9 +37,9 @@ func TestGitHubFormatter_CertInspection_Modified(t *testing.T) {
 		t.Errorf("expected no raw PEM in the annotation, got: %s", msg)
 	}
 	if strings.Contains(msg, "changed in multiline text") {
-		t.Errorf("expected the summary path, not the line diff, got: %s", msg)
+		t.Errorf("expected the certificate fields, not the line diff, got: %s", msg)
 	}
-	for _, want := range []string{"changed from Certificate(", "old.example.com", "new.example.com"} {
+	for _, want := range []string{"changed in certificate (six changes)", "± subject: CN=old.example.com → CN=new.example.com"} {
 		if !strings.Contains(msg, want) {
 			t.Errorf("expected %q in the annotation, got: %s", want, msg)
 		}
@@ -152,7 +152,7 @@ func TestGithubDiffDescription_NilOptionsInspectsCerts(t *testing.T) {
 	if want := githubDiffDescription(diff, DefaultFormatOptions()); got != want {
 		t.Errorf("nil opts = %q, want %q", got, want)
 	}
-	if !strings.Contains(got, "Certificate(") {
+	if !strings.Contains(got, "changed in certificate") {
 		t.Errorf("expected nil opts to inspect certificates, got: %s", got)
 	}
 }
@@ -210,13 +210,19 @@ func TestGitHubFormatter_CertInspection_ChainIsNotSummarized(t *testing.T) {
 
 	t.Run("modified pair", func(t *testing.T) {
 		// Only one side is a single certificate; the pair must not be
-		// summarized on the strength of that side alone.
+		// summarized on the strength of that side alone, but compared
+		// certificate by certificate, naming the one dropped from the bundle.
 		msg := githubMessage(t, f.Format([]Difference{
 			{Path: DiffPath{"data", "ca-bundle.crt"}, Type: DiffModified, From: chain, To: second},
 		}, opts))
 
-		if strings.Contains(msg, "Certificate(") {
-			t.Errorf("expected no summary when one side is a bundle, got: %s", msg)
+		if strings.Contains(msg, "changed from Certificate(") {
+			t.Errorf("expected no summary of the pair when one side is a bundle, got: %s", msg)
+		}
+		for _, want := range []string{"changed in certificate bundle (one change)", "- 0: Certificate(CN=old.example.com"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("expected %q in the annotation, got: %s", want, msg)
+			}
 		}
 	})
 
@@ -252,21 +252,23 @@ func githubTruncatedValue(val any, maxLines, maxRunes int) string {
 	return strings.Join(lines, "\n")
 }
 
-// githubCertValue replaces a single PEM certificate with its one-line summary,
-// mirroring what the detailed formatter does to added, removed and unchanged
+// githubCertValue replaces a single PEM certificate, key or certificate
+// request with its one-line summary (see SummarizePEM), mirroring what the detailed formatter does to added, removed and unchanged
 // values. Anything else comes back untouched.
 func githubCertValue(val any, enabled bool) any {
 	if !enabled {
 		return val
 	}
-	if s, ok := val.(string); ok && IsPEMCertificate(s) {
-		return FormatCertificate(s)
+	if s, ok := val.(string); ok {
+		if summary, ok := SummarizePEM(s); ok {
+			return summary
+		}
 	}
 	return val
 }
 
 // githubCertPair does the same for a modified pair, and like the detailed
-// formatter only when *both* sides are certificates — a certificate replaced by
+// formatter only when *both* sides are PEM values — a certificate replaced by
 // something else is still best shown as a plain value change. Callers must run
 // this before the multiline check, or a rotated certificate takes the line-diff
 // path and renders as base64 instead of a summary.
@@ -276,12 +278,39 @@ func githubCertPair(from, to any, enabled bool) (any, any) {
 	}
 	fromStr, fromOk := from.(string)
 	toStr, toOk := to.(string)
-	if fromOk && toOk && IsPEMCertificate(fromStr) && IsPEMCertificate(toStr) {
-		return FormatCertificate(fromStr), FormatCertificate(toStr)
+	if !fromOk || !toOk {
+		return from, to
+	}
+	fromSummary, fromPEM := SummarizePEM(fromStr)
+	toSummary, toPEM := SummarizePEM(toStr)
+	if fromPEM && toPEM {
+		return fromSummary, toSummary
 	}
 	return from, to
 }
 
+// githubCertChanges describes the field changes between two certificates or
+// bundles (see CompareCertificates) under a header counting them, the way
+// githubMultilineDiff describes a multiline string. ok is false unless both
+// values are certificates that differ in some field.
+func githubCertChanges(from, to any, enabled bool) (string, bool) {
+	fromStr, fromOk := from.(string)
+	toStr, toOk := to.(string)
+	if !enabled || !fromOk || !toOk {
+		return "", false
+	}
+	changes, ok := CompareCertificates(fromStr, toStr)
+	if !ok {
+		return "", false
+	}
+	lines := []string{fmt.Sprintf("%s (%s %s)", certKind(fromStr, toStr),
+		formatCount(len(changes)), pluralize(len(changes), "change", "changes"))}
+	for _, c := range changes {
+		lines = append(lines, truncateRunes(certChangeLine(c), gitHubMaxLineRunes))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
 // githubDiffDescription describes a difference for a GitHub Actions annotation,
 // keeping multiline values bounded. A changed multiline string becomes a
 // collapsed line diff; every other value is truncated at gitHubMaxValueLines.
@@ -295,7 +324,7 @@ func githubDiffDescription(diff Difference, opts *FormatOptions) string {
 		opts = DefaultFormatOptions()
 	}
 	certs := !opts.NoCertInspection
-	docSuffix := diffDocSuffix(diff)
+	docSuffix := diffDocSuffix(diff) + mappedSuffix(diff, false)
 
 	switch diff.Type {
 	case DiffAdded:
@@ -308,6 +337,9 @@ func githubDiffDescription(diff Difference, opts *FormatOptions) string {
 		return fmt.Sprintf("Unchanged: %s%s = %s", diff.Path, docSuffix,
 			githubTruncatedValue(githubCertValue(diff.To, certs), gitHubMaxValueLines, gitHubMaxLineRunes))
 	case DiffModified:
+		if body, ok := githubCertChanges(diff.From, diff.To, certs); ok {
+			return fmt.Sprintf("Modified: %s%s changed in %s", diff.Path, docSuffix, body)
+		}
 		from, to := githubCertPair(diff.From, diff.To, certs)
 		if fromStr, toStr, ok := multilineStrings(from, to); ok {
 			// A multiline pair keeps the multiline framing either way: when it
@@ -320,10 +352,16 @@ func githubDiffDescription(diff Difference, opts *FormatOptions) string {
 			}
 			return fmt.Sprintf("Modified: %s%s changed in %s", diff.Path, docSuffix, body)
 		}
-		return fmt.Sprintf("Modified: %s%s changed from %s to %s", diff.Path, docSuffix,
+		return fmt.Sprintf("Modified: %s%s changed from %s to %s%s", diff.Path, docSuffix,
 			githubTruncatedValue(from, gitHubMaxValueLines, gitHubMaxLineRunes),
-			githubTruncatedValue(to, gitHubMaxValueLines, gitHubMaxLineRunes))
-	default: // DiffOrderChanged carries no value
+			githubTruncatedValue(to, gitHubMaxValueLines, gitHubMaxLineRunes), deltaSuffix(diff))
+	case DiffTypeChanged:
+		from, to := githubCertPair(diff.From, diff.To, certs)
+		from, to = typeChangeOperand(from), typeChangeOperand(to)
+		return fmt.Sprintf("Type changed: %s%s changed from %s %s to %s %s", diff.Path, docSuffix,
+			diff.FromType, githubTruncatedValue(from, gitHubMaxValueLines, gitHubMaxLineRunes),
+			diff.ToType, githubTruncatedValue(to, gitHubMaxValueLines, gitHubMaxLineRunes))
+	default: // DiffOrderChanged, DiffMoved and DiffWarning carry no value; DiffCommentChanged only comments
 		return diffDescription(diff)
 	}
 }
@@ -1095,7 +1095,7 @@ func TestGitHubWriteCommand_EscapesTitle(t *testing.T) {
 	// bearing: a comma in a title ends the property list and the message with
 	// it. gitHubWriteCommand is the choke point, so exercise it directly.
 	var sb strings.Builder
-	gitHubWriteCommand(&sb, "warning", "a,b:c%", "the message", "")
+	gitHubWriteCommand(&sb, "warning", "a,b:c%", "the message", "", nil)
 
 	want := "::warning title=a%2Cb%3Ac%25::the message\n"
 	if got := sb.String(); got != want {
@@ -1115,7 +1115,7 @@ func TestGitHubWriteCommand_DoesNotTruncateFilePath(t *testing.T) {
 	longPath := strings.Repeat("nested/", 400) + "values.yaml"
 
 	var sb strings.Builder
-	gitHubWriteCommand(&sb, "warning", "YAML Modified", "msg", longPath)
+	gitHubWriteCommand(&sb, "warning", "YAML Modified", "msg", longPath, nil)
 	got := sb.String()
 
 	if !strings.Contains(got, "file="+longPath+",") {
@@ -1127,7 +1127,7 @@ func TestGitHubWriteCommand_DoesNotTruncateFilePath(t *testing.T) {
 	// Intact, but still escaped — the property encoding is what keeps a comma
 	// or colon in the path from ending the value early.
 	sb.Reset()
-	gitHubWriteCommand(&sb, "warning", "YAML Modified", "msg", "a,b:c/"+longPath)
+	gitHubWriteCommand(&sb, "warning", "YAML Modified", "msg", "a,b:c/"+longPath, nil)
 	if !strings.Contains(sb.String(), "file=a%2Cb%3Ac/"+longPath+",") {
 		t.Errorf("expected a long path to still be escaped, got: %s", sb.String())
 	}
@@ -1185,13 +1185,13 @@ func TestGitHubCaps_MessageBoundary(t *testing.T) {
 	// the test follow it wherever it moved, which is the one thing these
 	// boundary tests exist to prevent.
 	var sb strings.Builder
-	gitHubWriteCommand(&sb, "warning", "t", strings.Repeat("x", 4000), "")
+	gitHubWriteCommand(&sb, "warning", "t", strings.Repeat("x", 4000), "", nil)
 	if strings.Contains(sb.String(), "more character") {
 		t.Errorf("a 4000-character message must not be truncated, got: %s", sb.String())
 	}
 
 	sb.Reset()
-	gitHubWriteCommand(&sb, "warning", "t", strings.Repeat("x", 4001), "")
+	gitHubWriteCommand(&sb, "warning", "t", strings.Repeat("x", 4001), "", nil)
 	if !strings.Contains(sb.String(), escapeGitHubData("…[1 more character]")) {
 		t.Errorf("a 4001-character message must drop exactly one character, got: %s", sb.String())
 	}
@@ -0,0 +1,314 @@
+// formatter_html.go - Self-contained HTML report output.
+//
+// Renders differences as a single HTML page with inline CSS: a summary table
+// followed by collapsible per-file and per-document sections. Section bodies
+// are the detailed formatter's output rendered in true-color mode, with its
+// SGR escape sequences translated to styled spans, so the report carries
+// exactly what the terminal shows (grouped paths, multiline line diffs,
+// inline word highlighting, certificate summaries) and cannot drift from it.
+// Key types: HTMLFormatter.
+package diffyml
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// HTMLFormatter renders differences as a self-contained HTML report.
+// Colors come from FormatOptions.Palette, so custom palettes carry over;
+// FormatOptions.Color is ignored because the page is always styled.
+type HTMLFormatter struct{}
+
+// htmlDefaultFileName labels the single file section when no path is known
+// (library callers, or a to side read from stdin).
+const htmlDefaultFileName = "input"
+
+// htmlColor returns a CSS rgb() value for a palette role.
+func htmlColor(opts *FormatOptions, role ColorRole) string {
+	c := resolvedPalette(opts).colorForRole(role)
+	return fmt.Sprintf("rgb(%d,%d,%d)", c.R, c.G, c.B)
+}
+
+// htmlStyle is the text style in effect while translating SGR sequences.
+type htmlStyle struct {
+	Color  string // CSS color, empty for the page default
+	Bold   bool
+	Italic bool
+}
+
+func (s htmlStyle) css() string {
+	var parts []string
+	if s.Color != "" {
+		parts = append(parts, "color:"+s.Color)
+	}
+	if s.Bold {
+		parts = append(parts, "font-weight:bold")
+	}
+	if s.Italic {
+		parts = append(parts, "font-style:italic")
+	}
+	return strings.Join(parts, ";")
+}
+
+// applySGR updates the style for one SGR parameter list (the text between
+// "\033[" and "m"). Covers the sequences the detailed formatter emits: reset,
+// bold and italic on/off, 24-bit foreground, and the 8-color foregrounds in
+// ansiColorRefs. Anything else is ignored.
+func (s *htmlStyle) applySGR(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		switch codes[i] {
+		case "", "0":
+			*s = htmlStyle{}
+		case "1":
+			s.Bold = true
+		case "22":
+			s.Bold = false
+		case "3":
+			s.Italic = true
+		case "23":
+			s.Italic = false
+		case "38":
+			if i+4 < len(codes) && codes[i+1] == "2" {
+				r, _ := strconv.Atoi(codes[i+2])
+				g, _ := strconv.Atoi(codes[i+3])
+				b, _ := strconv.Atoi(codes[i+4])
+				s.Color = fmt.Sprintf("rgb(%d,%d,%d)", clamp(r, 0, 255), clamp(g, 0, 255), clamp(b, 0, 255))
+				i += 4
+			}
+		default:
+			for _, ref := range ansiColorRefs {
+				if ref.code == "\033["+codes[i]+"m" {
+					s.Color = fmt.Sprintf("rgb(%d,%d,%d)", ref.r, ref.g, ref.b)
+				}
+			}
+		}
+	}
+}
+
+// writeANSIAsHTML writes text containing SGR escape sequences as escaped HTML,
+// wrapping each styled run in a span.
+func writeANSIAsHTML(sb *strings.Builder, s string) {
+	var style htmlStyle
+	open := false
+	flush := func(text string) {
+		if text == "" {
+			return
+		}
+		css := style.css()
+		if css != "" && !open {
+			fmt.Fprintf(sb, `<span style="%s">`, css)
+			open = true
+		}
+		sb.WriteString(html.EscapeString(text))
+	}
+	for {
+		start := strings.Index(s, "\033[")
+		if start < 0 {
+			flush(s)
+			break
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			flush(s)
+			break
+		}
+		flush(s[:start])
+		next := style
+		next.applySGR(s[start+2 : start+end])
+		if next != style && open {
+			sb.WriteString("</span>")
+			open = false
+		}
+		style = next
+		s = s[start+end+1:]
+	}
+	if open {
+		sb.WriteString("</span>")
+	}
+}
+
+// htmlStylesheet returns the page CSS. Diff colors are not set here: they
+// arrive inline from the translated output, already resolved against the
+// palette. Only the summary table's column colors are derived from it.
+func htmlStylesheet(opts *FormatOptions) string {
+	return `body{background:#1e1e1e;color:#d4d4d4;font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;margin:2em}
+h1{font-size:1.4em;margin:0 0 .5em}
+p.header{margin:0 0 1em}
+table.summary{border-collapse:collapse;margin-bottom:1.5em}
+table.summary th,table.summary td{border:1px solid #444;padding:.3em .8em;text-align:left}
+table.summary td.n{text-align:right;font-variant-numeric:tabular-nums}
+table.summary tfoot td{font-weight:bold}
+table.summary a{color:inherit}
+.added{color:` + htmlColor(opts, ColorRoleAdded) + `}
+.removed{color:` + htmlColor(opts, ColorRoleRemoved) + `}
+.modified{color:` + htmlColor(opts, ColorRoleModified) + `}
+.unchanged{color:` + htmlColor(opts, ColorRoleContext) + `}
+.doc{color:` + htmlColor(opts, ColorRoleDocName) + `}
+details{margin:.5em 0}
+details details{margin-left:1.2em}
+summary{cursor:pointer;font-weight:bold}
+pre{background:#141414;border:1px solid #333;padding:.8em;overflow-x:auto;font-family:ui-monospace,Menlo,Consolas,monospace;font-size:.9em;line-height:1.35}
+`
+}
+
+// htmlSummaryRow writes one row of the summary table, linking to its section.
+func htmlSummaryRow(sb *strings.Builder, anchor, file, doc string, c, totals diffTypeCounts, opts *FormatOptions) {
+	fmt.Fprintf(sb, `<tr><td><a href="#%s">%s</a></td><td class="doc">%s</td>`,
+		anchor, html.EscapeString(file), html.EscapeString(doc))
+	htmlCountCells(sb, c, totals, opts)
+	sb.WriteString("</tr>\n")
+}
+
+// htmlCountCells writes the per-type count cells. The unchanged column only
+// exists in inverse mode, where it is the only nonzero one; the type changed,
+// moved, comments and warnings columns only when totals has some.
+func htmlCountCells(sb *strings.Builder, c, totals diffTypeCounts, opts *FormatOptions) {
+	if opts.Unchanged {
+		fmt.Fprintf(sb, `<td class="n unchanged">%d</td>`, c.Unchanged)
+		return
+	}
+	fmt.Fprintf(sb, `<td class="n added">%d</td><td class="n removed">%d</td><td class="n modified">%d</td><td class="n modified">%d</td>`,
+		c.Added, c.Removed, c.Modified, c.OrderChanged)
+	if totals.TypeChanged > 0 {
+		fmt.Fprintf(sb, `<td class="n modified">%d</td>`, c.TypeChanged)
+	}
+	if totals.Moved > 0 {
+		fmt.Fprintf(sb, `<td class="n modified">%d</td>`, c.Moved)
+	}
+	if totals.CommentChanged > 0 {
+		fmt.Fprintf(sb, `<td class="n modified">%d</td>`, c.CommentChanged)
+	}
+	if totals.Warnings > 0 {
+		fmt.Fprintf(sb, `<td class="n modified">%d</td>`, c.Warnings)
+	}
+}
+
+// htmlDetailedBody renders diffs with the detailed formatter and translates
+// its colors to HTML. True color is forced so every color arrives as an exact
+// RGB value from the palette rather than an 8-color approximation.
+func htmlDetailedBody(sb *strings.Builder, diffs []Difference, opts *FormatOptions) {
+	bodyOpts := *opts
+	bodyOpts.Color = true
+	bodyOpts.TrueColor = true
+	bodyOpts.OmitHeader = true
+	sb.WriteString("<pre>")
+	writeANSIAsHTML(sb, strings.TrimRight((&DetailedFormatter{}).Format(diffs, &bodyOpts), "\n"))
+	sb.WriteString("</pre>\n")
+}
+
+// Format renders differences as an HTML report with a single file section
+// named after opts.FilePath.
+func (f *HTMLFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	name := opts.FilePath
+	if name == "" {
+		name = htmlDefaultFileName
+	}
+	return f.FormatAll([]DiffGroup{{FilePath: name, Diffs: diffs}}, opts)
+}
+
+// FormatAll renders one collapsible section per file for directory mode.
+// Implements StructuredFormatter interface.
+func (f *HTMLFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+
+	var total int
+	for _, g := range groups {
+		total += len(g.Diffs)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>diffyml report</title>\n<style>\n")
+	sb.WriteString(htmlStylesheet(opts))
+	sb.WriteString("</style>\n</head>\n<body>\n<h1>diffyml report</h1>\n")
+
+	if total == 0 {
+		fmt.Fprintf(&sb, "<p class=\"header\">%s</p>\n</body>\n</html>\n",
+			html.EscapeString(strings.TrimSuffix(emptyResultMessage(opts, " found"), "\n")))
+		return sb.String()
+	}
+
+	if !opts.OmitHeader {
+		noun := pluralize(total, "difference", "differences")
+		if opts.Unchanged {
+			noun = pluralize(total, "unchanged value", "unchanged values")
+		}
+		fmt.Fprintf(&sb, "<p class=\"header modified\">Found %s %s</p>\n", formatCount(total), noun)
+	}
+
+	type fileSection struct {
+		Name string
+		Docs []docSection
+	}
+	var files []fileSection
+	for _, g := range groups {
+		if len(g.Diffs) > 0 {
+			files = append(files, fileSection{Name: g.FilePath, Docs: documentSections(g.Diffs)})
+		}
+	}
+
+	// Summary table: one row per document section, plus a totals row.
+	var totals diffTypeCounts
+	for _, g := range groups {
+		totals.add(g.Diffs)
+	}
+	sb.WriteString("<table class=\"summary\">\n<thead><tr><th>File</th><th>Document</th>")
+	if opts.Unchanged {
+		sb.WriteString("<th class=\"unchanged\">Unchanged</th>")
+	} else {
+		sb.WriteString("<th class=\"added\">Added</th><th class=\"removed\">Removed</th><th class=\"modified\">Modified</th><th class=\"modified\">Order changed</th>")
+		if totals.TypeChanged > 0 {
+			sb.WriteString("<th class=\"modified\">Type changed</th>")
+		}
+		if totals.Moved > 0 {
+			sb.WriteString("<th class=\"modified\">Moved</th>")
+		}
+		if totals.CommentChanged > 0 {
+			sb.WriteString("<th class=\"modified\">Comments</th>")
+		}
+		if totals.Warnings > 0 {
+			sb.WriteString("<th class=\"modified\">Warnings</th>")
+		}
+	}
+	sb.WriteString("</tr></thead>\n<tbody>\n")
+	for i, file := range files {
+		for j, doc := range file.Docs {
+			var c diffTypeCounts
+			c.add(doc.Diffs)
+			htmlSummaryRow(&sb, fmt.Sprintf("f%d-d%d", i, j), file.Name, doc.Label, c, totals, opts)
+		}
+	}
+	sb.WriteString("</tbody>\n<tfoot><tr><td colspan=\"2\">Total</td>")
+	htmlCountCells(&sb, totals, totals, opts)
+	sb.WriteString("</tr></tfoot>\n</table>\n")
+
+	// Collapsible sections. A file whose only document is unnamed renders its
+	// diff directly, so plain single-document YAML is not nested twice.
+	for i, file := range files {
+		fmt.Fprintf(&sb, "<details class=\"file\" open>\n<summary>%s</summary>\n", html.EscapeString(file.Name))
+		for j, doc := range file.Docs {
+			anchor := fmt.Sprintf("f%d-d%d", i, j)
+			if doc.Label == "" {
+				fmt.Fprintf(&sb, "<div id=\"%s\">\n", anchor)
+				htmlDetailedBody(&sb, doc.Diffs, opts)
+				sb.WriteString("</div>\n")
+				continue
+			}
+			fmt.Fprintf(&sb, "<details class=\"document\" id=\"%s\" open>\n<summary class=\"doc\">%s</summary>\n",
+				anchor, html.EscapeString(doc.Label))
+			htmlDetailedBody(&sb, doc.Diffs, opts)
+			sb.WriteString("</details>\n")
+		}
+		sb.WriteString("</details>\n")
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
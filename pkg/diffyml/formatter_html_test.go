@@ -0,0 +1,202 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatterByName_HTML(t *testing.T) {
+	f, err := FormatterByName("html")
+	if err != nil {
+		t.Fatalf("FormatterByName(html) returned error: %v", err)
+	}
+	if _, ok := f.(*HTMLFormatter); !ok {
+		t.Errorf("expected *HTMLFormatter, got %T", f)
+	}
+	if _, ok := f.(StructuredFormatter); !ok {
+		t.Error("HTMLFormatter should implement StructuredFormatter")
+	}
+}
+
+func TestHTMLFormatter_Page(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "image"}, Type: DiffModified, From: "nginx:1.24.0", To: "nginx:1.25.3"},
+		{Path: DiffPath{"app", "note"}, Type: DiffAdded, To: "<b> & co"},
+	}
+	output := (&HTMLFormatter{}).Format(diffs, &FormatOptions{FilePath: "cfg.yaml"})
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"Found two differences",
+		`<a href="#f0-d0">cfg.yaml</a>`,
+		`<details class="file" open>`,
+		`<summary>cfg.yaml</summary>`,
+		// Inline word highlighting survives as a bold span on the changed token.
+		`<span style="color:rgb(88,191,56);font-weight:bold">25</span>`,
+		// Values are escaped, not interpreted as markup.
+		"&lt;b&gt; &amp; co",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "\033[") {
+		t.Errorf("output contains raw ANSI escapes:\n%q", output)
+	}
+	// A single unnamed document is not nested in a document section.
+	if strings.Contains(output, `class="document"`) {
+		t.Errorf("unexpected document section for single-document input:\n%s", output)
+	}
+}
+
+func TestHTMLFormatter_TypeChangedAndMovedColumns(t *testing.T) {
+	plain := (&HTMLFormatter{}).Format([]Difference{
+		{Path: DiffPath{"app", "debug"}, Type: DiffModified, From: false, To: true},
+	}, &FormatOptions{})
+	if strings.Contains(plain, "Type changed") || strings.Contains(plain, ">Moved<") {
+		t.Errorf("columns without counts should be left out:\n%s", plain)
+	}
+
+	output := (&HTMLFormatter{}).Format([]Difference{
+		{Path: DiffPath{"app", "port"}, Type: DiffTypeChanged, From: "8080", To: 8080, FromType: "!!str", ToType: "!!int"},
+		{Path: DiffPath{"app", "server"}, Type: DiffMoved, FromPath: DiffPath{"app", "http"}},
+	}, &FormatOptions{})
+	for _, want := range []string{
+		`<th class="modified">Order changed</th><th class="modified">Type changed</th><th class="modified">Moved</th>`,
+		`<td class="n modified">0</td><td class="n modified">0</td><td class="n modified">1</td><td class="n modified">1</td>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestHTMLFormatter_DocumentSections(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"spec", "replicas"}, Type: DiffModified, From: 2, To: 3, DocumentName: "apps/v1/Deployment/web"},
+		{Path: DiffPath{"data", "k"}, Type: DiffRemoved, From: "v", DocumentIndex: 1, DocumentName: "v1/ConfigMap/cfg"},
+	}
+	output := (&HTMLFormatter{}).Format(diffs, nil)
+
+	for _, want := range []string{
+		`<details class="document" id="f0-d0" open>`,
+		`<summary class="doc">apps/v1/Deployment/web</summary>`,
+		`<details class="document" id="f0-d1" open>`,
+		`<summary class="doc">v1/ConfigMap/cfg</summary>`,
+		`<a href="#f0-d1">input</a></td><td class="doc">v1/ConfigMap/cfg</td><td class="n added">0</td><td class="n removed">1</td>`,
+		`<td colspan="2">Total</td><td class="n added">0</td><td class="n removed">1</td><td class="n modified">1</td>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDocumentSections_UnnamedMultiDoc(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"[0]", "a"}, Type: DiffModified, From: 1, To: 2},
+		{Path: DiffPath{"[1]", "a"}, Type: DiffModified, From: 1, To: 2, DocumentIndex: 1},
+		{Path: DiffPath{"[0]", "b"}, Type: DiffAdded, To: 1},
+	}
+	sections := documentSections(diffs)
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %+v", sections)
+	}
+	if sections[0].Label != "document 0" || len(sections[0].Diffs) != 2 {
+		t.Errorf("section 0 = %q with %d diffs, want \"document 0\" with 2", sections[0].Label, len(sections[0].Diffs))
+	}
+	if sections[1].Label != "document 1" {
+		t.Errorf("section 1 label = %q, want \"document 1\"", sections[1].Label)
+	}
+}
+
+func TestHTMLFormatter_CustomPalette(t *testing.T) {
+	palette := DefaultCustomColorPalette()
+	palette.Added = &CustomColor{R: 1, G: 2, B: 3, ANSICode: colorGreen, IsCustom: true}
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffAdded, To: 1}}
+	output := (&HTMLFormatter{}).Format(diffs, &FormatOptions{Palette: palette})
+
+	if !strings.Contains(output, ".added{color:rgb(1,2,3)}") {
+		t.Errorf("summary table does not use the custom palette:\n%s", output)
+	}
+	if !strings.Contains(output, `<span style="color:rgb(1,2,3)`) {
+		t.Errorf("diff body does not use the custom palette:\n%s", output)
+	}
+}
+
+func TestHTMLFormatter_Empty(t *testing.T) {
+	for name, output := range map[string]string{
+		"Format":    (&HTMLFormatter{}).Format(nil, nil),
+		"FormatAll": (&HTMLFormatter{}).FormatAll(nil, nil),
+	} {
+		if !strings.Contains(output, "no differences found") {
+			t.Errorf("%s: expected empty-result message:\n%s", name, output)
+		}
+		if strings.Contains(output, "<table") {
+			t.Errorf("%s: unexpected summary table for empty report", name)
+		}
+		if !strings.HasSuffix(output, "</html>\n") {
+			t.Errorf("%s: page not closed:\n%s", name, output)
+		}
+	}
+	output := (&HTMLFormatter{}).Format(nil, &FormatOptions{Unchanged: true})
+	if !strings.Contains(output, "no unchanged values found") {
+		t.Errorf("inverse mode empty message missing:\n%s", output)
+	}
+}
+
+func TestHTMLFormatter_Unchanged(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffUnchanged, From: 1, To: 1}}
+	output := (&HTMLFormatter{}).Format(diffs, &FormatOptions{Unchanged: true})
+	if !strings.Contains(output, `<th class="unchanged">Unchanged</th>`) || strings.Contains(output, "<th class=\"added\">") {
+		t.Errorf("inverse mode should show only the unchanged column:\n%s", output)
+	}
+	if !strings.Contains(output, "Found one unchanged value") {
+		t.Errorf("inverse mode header missing:\n%s", output)
+	}
+}
+
+func TestHTMLFormatter_FormatAll(t *testing.T) {
+	groups := []DiffGroup{
+		{FilePath: "a.yaml", Diffs: []Difference{{Path: DiffPath{"x"}, Type: DiffAdded, To: 1}}},
+		{FilePath: "b.yaml", Diffs: []Difference{{Path: DiffPath{"y"}, Type: DiffModified, From: 1, To: 2}}},
+	}
+	output := (&HTMLFormatter{}).FormatAll(groups, &FormatOptions{OmitHeader: true})
+	if strings.Count(output, "<!DOCTYPE html>") != 1 {
+		t.Errorf("expected a single page:\n%s", output)
+	}
+	for _, want := range []string{`<summary>a.yaml</summary>`, `<summary>b.yaml</summary>`, `href="#f1-d0"`, `id="f1-d0"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Found ") {
+		t.Errorf("OmitHeader should drop the header line:\n%s", output)
+	}
+}
+
+func TestWriteANSIAsHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "a < b", "a &lt; b"},
+		{"true color", "\033[38;2;1;2;3mx\033[0m y", `<span style="color:rgb(1,2,3)">x</span> y`},
+		{"bold then off", "\033[1m\033[38;2;9;9;9mA\033[22mB\033[0m", `<span style="color:rgb(9,9,9);font-weight:bold">A</span><span style="color:rgb(9,9,9)">B</span>`},
+		{"italic", "\033[3mi\033[23mn", `<span style="font-style:italic">i</span>n`},
+		{"eight color", colorRed + "r" + colorReset, `<span style="color:rgb(205,0,0)">r</span>`},
+		{"redundant codes add no spans", "\033[1m\033[1mb\033[0m\033[0m", `<span style="font-weight:bold">b</span>`},
+		{"unterminated sequence", "a\033[31", "a\033[31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			writeANSIAsHTML(&sb, tt.in)
+			if got := sb.String(); got != tt.want {
+				t.Errorf("writeANSIAsHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
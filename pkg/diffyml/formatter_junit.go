@@ -0,0 +1,197 @@
+// formatter_junit.go - JUnit XML report output.
+//
+// Renders differences as a JUnit XML report so drift checks surface in CI
+// test-report widgets (Jenkins, GitLab) as failing test cases. Each file is a
+// testsuite; each Kubernetes resource, or each top-level path for plain YAML,
+// is a testcase that fails when it has differences.
+// Key types: JUnitFormatter.
+package diffyml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// JUnitFormatter renders differences as a JUnit XML report. A testcase fails
+// with the compact rendering of its differences as the failure body; in
+// inverse mode (DiffUnchanged entries only) every testcase passes.
+type JUnitFormatter struct{}
+
+// junitDefaultSuiteName names the testsuite when no file path is known
+// (library callers, or a to side read from stdin).
+const junitDefaultSuiteName = "diffyml"
+
+// junitNoDiffsCaseName names the single passing testcase of a suite with no
+// differences. A suite with zero testcases renders as "no tests" in most
+// report widgets, which reads as a broken check rather than a clean one.
+const junitNoDiffsCaseName = "no differences"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// junitCaseName returns the testcase a difference belongs to: its Kubernetes
+// resource when one was detected, otherwise its top-level path (keeping the
+// [N] document prefix of a multi-document file). Map entries added or removed
+// at the root are reported at the empty path with the key inside the value,
+// so the key is unwrapped first to name the case after it.
+func junitCaseName(diff Difference) string {
+	if diff.DocumentName != "" {
+		return diff.DocumentName
+	}
+	path := diff.Path
+	if path.IsEmpty() || path.IsBareDocIndex() {
+		path = expandMapKeyDiff(diff).Path
+	}
+	depth := 1
+	if _, ok := path.DocIndex(); ok {
+		depth = 2
+	}
+	if len(path) > depth {
+		path = path[:depth]
+	}
+	if path.IsEmpty() {
+		return "(root)"
+	}
+	return path.String()
+}
+
+// junitBuildSuite groups a file's differences into testcases, in order of
+// first appearance, and renders each failing case's differences with the
+// compact formatter. Color is forced off: ANSI escapes are noise in XML, and
+// the header is omitted because the failure message already carries the count.
+func junitBuildSuite(name string, diffs []Difference, opts *FormatOptions) junitTestSuite {
+	suite := junitTestSuite{Name: name}
+	if len(diffs) == 0 {
+		suite.Tests = 1
+		suite.Cases = []junitTestCase{{Name: junitNoDiffsCaseName, ClassName: name}}
+		return suite
+	}
+
+	var order []string
+	byCase := make(map[string][]Difference)
+	for _, diff := range diffs {
+		caseName := junitCaseName(diff)
+		if _, seen := byCase[caseName]; !seen {
+			order = append(order, caseName)
+		}
+		byCase[caseName] = append(byCase[caseName], diff)
+	}
+
+	bodyOpts := *opts
+	bodyOpts.Color = false
+	bodyOpts.OmitHeader = true
+	compact := &CompactFormatter{}
+
+	for _, caseName := range order {
+		tc := junitTestCase{Name: caseName, ClassName: name}
+		caseDiffs := byCase[caseName]
+		var failing int
+		for _, d := range caseDiffs {
+			if d.Type != DiffUnchanged {
+				failing++
+			}
+		}
+		if failing > 0 {
+			tc.Failure = &junitFailure{
+				Message: junitXMLText(fmt.Sprintf("%d %s", failing, pluralize(failing, "difference", "differences"))),
+				Type:    "drift",
+				Body:    junitXMLText(compact.Format(caseDiffs, &bodyOpts)),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	return suite
+}
+
+// junitXMLText replaces the runes XML 1.0 cannot carry, such as control
+// characters a YAML value may hold, with U+FFFD, as encoding/xml does for
+// attributes but not for CDATA. Invalid UTF-8 is replaced the same way.
+func junitXMLText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r',
+			r >= 0x20 && r <= 0xD7FF,
+			r >= 0xE000 && r <= 0xFFFD,
+			r >= 0x10000 && r <= 0x10FFFF:
+			return r
+		}
+		return '\uFFFD'
+	}, s)
+}
+
+// junitMarshal wraps suites in a <testsuites> root with aggregate counts and
+// marshals it with an XML declaration.
+func junitMarshal(suites []junitTestSuite) string {
+	root := junitTestSuites{Name: junitDefaultSuiteName, Suites: suites}
+	for _, s := range suites {
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+	}
+	out, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return xml.Header
+	}
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.Write(out)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Format renders differences as a JUnit report with a single testsuite named
+// after opts.FilePath.
+func (f *JUnitFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	name := opts.FilePath
+	if name == "" {
+		name = junitDefaultSuiteName
+	}
+	return junitMarshal([]junitTestSuite{junitBuildSuite(name, diffs, opts)})
+}
+
+// FormatAll renders one testsuite per file for directory mode.
+// Implements StructuredFormatter interface.
+func (f *JUnitFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	// Directory mode only passes files that differ; with none, report one
+	// passing suite so the check still shows up as green rather than empty.
+	if len(groups) == 0 {
+		return junitMarshal([]junitTestSuite{junitBuildSuite(junitDefaultSuiteName, nil, opts)})
+	}
+	suites := make([]junitTestSuite, 0, len(groups))
+	for _, group := range groups {
+		suites = append(suites, junitBuildSuite(group.FilePath, group.Diffs, opts))
+	}
+	return junitMarshal(suites)
+}
@@ -0,0 +1,175 @@
+package diffyml
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func parseJUnit(t *testing.T, output string) junitTestSuites {
+	t.Helper()
+	if !strings.HasPrefix(output, xml.Header) {
+		t.Fatalf("output missing XML declaration:\n%s", output)
+	}
+	var root junitTestSuites
+	if err := xml.Unmarshal([]byte(output), &root); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, output)
+	}
+	return root
+}
+
+func TestFormatterByName_JUnit(t *testing.T) {
+	f, err := FormatterByName("junit")
+	if err != nil {
+		t.Fatalf("FormatterByName(junit) returned error: %v", err)
+	}
+	if _, ok := f.(*JUnitFormatter); !ok {
+		t.Errorf("expected *JUnitFormatter, got %T", f)
+	}
+	if _, ok := f.(StructuredFormatter); !ok {
+		t.Error("JUnitFormatter should implement StructuredFormatter")
+	}
+}
+
+func TestJUnitCaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		diff Difference
+		want string
+	}{
+		{"kubernetes resource", Difference{Path: DiffPath{"spec", "replicas"}, DocumentName: "apps/v1/Deployment/web"}, "apps/v1/Deployment/web"},
+		{"top-level path", Difference{Path: DiffPath{"app", "server", "port"}}, "app"},
+		{"document prefix kept", Difference{Path: DiffPath{"[1]", "app", "port"}}, "[1].app"},
+		{"root entry unwrapped", Difference{Path: DiffPath{}, Type: DiffAdded, To: &OrderedMap{Keys: []string{"extra"}, Values: map[string]any{"extra": 1}}}, "extra"},
+		{"root scalar", Difference{Path: DiffPath{}, Type: DiffModified, From: 1, To: 2}, "(root)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := junitCaseName(tt.diff); got != tt.want {
+				t.Errorf("junitCaseName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJUnitFormatter_Format(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "port"}, Type: DiffModified, From: 80, To: 8080},
+		{Path: DiffPath{"db", "host"}, Type: DiffModified, From: "a", To: "b"},
+		{Path: DiffPath{"app", "debug"}, Type: DiffAdded, To: true},
+	}
+	root := parseJUnit(t, (&JUnitFormatter{}).Format(diffs, &FormatOptions{FilePath: "cfg.yaml", Color: true}))
+
+	if root.Tests != 2 || root.Failures != 2 {
+		t.Errorf("testsuites tests/failures = %d/%d, want 2/2", root.Tests, root.Failures)
+	}
+	if len(root.Suites) != 1 || root.Suites[0].Name != "cfg.yaml" {
+		t.Fatalf("expected one suite named cfg.yaml, got %+v", root.Suites)
+	}
+	cases := root.Suites[0].Cases
+	if len(cases) != 2 || cases[0].Name != "app" || cases[1].Name != "db" {
+		t.Fatalf("cases = %+v, want app then db", cases)
+	}
+	app := cases[0]
+	if app.ClassName != "cfg.yaml" {
+		t.Errorf("classname = %q, want cfg.yaml", app.ClassName)
+	}
+	if app.Failure == nil {
+		t.Fatal("app case should fail")
+	}
+	if app.Failure.Message != "2 differences" || app.Failure.Type != "drift" {
+		t.Errorf("failure message/type = %q/%q", app.Failure.Message, app.Failure.Type)
+	}
+	if !strings.Contains(app.Failure.Body, "app.port") || !strings.Contains(app.Failure.Body, "app.debug") {
+		t.Errorf("failure body missing compact diff lines:\n%s", app.Failure.Body)
+	}
+	if strings.Contains(app.Failure.Body, "\x1b[") {
+		t.Errorf("failure body contains ANSI escapes:\n%q", app.Failure.Body)
+	}
+	if cases[1].Failure.Message != "1 difference" {
+		t.Errorf("db failure message = %q, want singular", cases[1].Failure.Message)
+	}
+}
+
+func TestJUnitFormatter_UnchangedPasses(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"a"}, Type: DiffUnchanged, From: 1, To: 1},
+		{Path: DiffPath{"b"}, Type: DiffModified, From: 1, To: 2},
+	}
+	root := parseJUnit(t, (&JUnitFormatter{}).Format(diffs, nil))
+	cases := root.Suites[0].Cases
+	if len(cases) != 2 {
+		t.Fatalf("expected 2 cases, got %+v", cases)
+	}
+	if cases[0].Failure != nil {
+		t.Errorf("unchanged case should pass, got failure %+v", cases[0].Failure)
+	}
+	if cases[1].Failure == nil {
+		t.Error("modified case should fail")
+	}
+	if root.Failures != 1 {
+		t.Errorf("failures = %d, want 1", root.Failures)
+	}
+}
+
+func TestJUnitFormatter_Empty(t *testing.T) {
+	for name, output := range map[string]string{
+		"Format":    (&JUnitFormatter{}).Format(nil, nil),
+		"FormatAll": (&JUnitFormatter{}).FormatAll(nil, nil),
+	} {
+		root := parseJUnit(t, output)
+		if len(root.Suites) != 1 || root.Suites[0].Name != junitDefaultSuiteName {
+			t.Fatalf("%s: expected one %q suite, got %+v", name, junitDefaultSuiteName, root.Suites)
+		}
+		cases := root.Suites[0].Cases
+		if len(cases) != 1 || cases[0].Name != junitNoDiffsCaseName || cases[0].Failure != nil {
+			t.Errorf("%s: expected a single passing %q case, got %+v", name, junitNoDiffsCaseName, cases)
+		}
+		if root.Tests != 1 || root.Failures != 0 {
+			t.Errorf("%s: tests/failures = %d/%d, want 1/0", name, root.Tests, root.Failures)
+		}
+	}
+}
+
+func TestJUnitFormatter_FormatAll(t *testing.T) {
+	groups := []DiffGroup{
+		{FilePath: "a.yaml", Diffs: []Difference{{Path: DiffPath{"x"}, Type: DiffAdded, To: 1}}},
+		{FilePath: "b.yaml", Diffs: []Difference{
+			{Path: DiffPath{"y"}, Type: DiffModified, From: 1, To: 2},
+			{Path: DiffPath{"z"}, Type: DiffRemoved, From: 3},
+		}},
+	}
+	root := parseJUnit(t, (&JUnitFormatter{}).FormatAll(groups, nil))
+	if len(root.Suites) != 2 || root.Suites[0].Name != "a.yaml" || root.Suites[1].Name != "b.yaml" {
+		t.Fatalf("expected suites a.yaml and b.yaml, got %+v", root.Suites)
+	}
+	if root.Tests != 3 || root.Failures != 3 {
+		t.Errorf("testsuites tests/failures = %d/%d, want 3/3", root.Tests, root.Failures)
+	}
+	if root.Suites[1].Tests != 2 || root.Suites[1].Failures != 2 {
+		t.Errorf("b.yaml tests/failures = %d/%d, want 2/2", root.Suites[1].Tests, root.Suites[1].Failures)
+	}
+}
+
+func TestJUnitFormatter_EscapesMarkup(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffModified, From: "<b>", To: "x & y ]]>"}}
+	root := parseJUnit(t, (&JUnitFormatter{}).Format(diffs, nil))
+	body := root.Suites[0].Cases[0].Failure.Body
+	if !strings.Contains(body, "<b>") || !strings.Contains(body, "x & y ]]>") {
+		t.Errorf("failure body did not round-trip markup:\n%s", body)
+	}
+}
+
+func TestJUnitFormatter_ReplacesInvalidXMLCharacters(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffModified, From: "bell\x01", To: "\x1b[31mred ]]> \xff"}}
+	output := (&JUnitFormatter{}).Format(diffs, nil)
+	if strings.ContainsAny(output, "\x01\x1b") {
+		t.Errorf("output holds control characters:\n%q", output)
+	}
+	body := parseJUnit(t, output).Suites[0].Cases[0].Failure.Body
+	for _, want := range []string{"bell\uFFFD", "\uFFFD[31mred ]]> \uFFFD"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("failure body missing %q:\n%q", want, body)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// formatter_markdown.go - Markdown output for pull-request comments.
+//
+// Renders differences as GitHub-flavored Markdown: a summary table of counts
+// per difference type (and per Kubernetes kind when resources were detected),
+// then one collapsed <details> section per file and document holding a fenced
+// diff block. Values are bounded with the same caps the GitHub annotation
+// formatter uses (formatter_github_multiline.go), and the comment as a whole
+// stops before it reaches GitHub's comment size limit, cutting the section
+// that reaches it short.
+// Key types: MarkdownFormatter.
+package diffyml
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MarkdownFormatter renders differences as a Markdown pull-request comment.
+type MarkdownFormatter struct{}
+
+// markdownMaxCommentRunes caps the rendered comment. GitHub rejects comment
+// bodies over 65,536 characters; the headroom leaves space for a bot to wrap
+// the output in its own header or footer. The section that would push the
+// comment past the cap keeps the diff lines that fit, with a note counting the
+// rest inside its fence so the block still closes; later sections are dropped
+// and counted in a closing note.
+const markdownMaxCommentRunes = 60000
+
+// markdownNoteRunes is room kept for the closing note when a section is cut
+// short.
+const markdownNoteRunes = 100
+
+// markdownSection is one rendered <details> block and what it covers.
+type markdownSection struct {
+	Summary string
+	Body    []string
+}
+
+// markdownFence returns a code fence longer than any backtick run in lines,
+// so a value containing ``` cannot close the block early.
+func markdownFence(lines []string) string {
+	longest := 0
+	for _, line := range lines {
+		run := 0
+		for _, r := range line {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// markdownMarked renders a value as lines carrying a diff marker, bounded like
+// an added or removed value in a GitHub annotation.
+func markdownMarked(val any, mark string) []string {
+	return githubMarkedValue(formatValue(val), mark, gitHubMaxValueLines, gitHubMaxLineRunes)
+}
+
+// markdownDiffLines renders one difference as lines of a diff block: a "#"
+// comment naming the path, then "-" lines for the old value and "+" lines for
+// the new one. A modified multiline string becomes the same collapsed line
+// diff a GitHub annotation shows.
+func markdownDiffLines(diff Difference, opts *FormatOptions) []string {
+	certs := !opts.NoCertInspection
+	diff = expandMapKeyDiff(diff)
+	heading := "# " + truncateRunes(sectionPathLabel(diff.Path, opts)+mappedSuffix(diff, opts.UseGoPatchStyle), gitHubMaxLineRunes)
+
+	switch diff.Type {
+	case DiffAdded:
+		return append([]string{heading}, markdownMarked(githubCertValue(diff.To, certs), "+ ")...)
+	case DiffRemoved:
+		return append([]string{heading}, markdownMarked(githubCertValue(diff.From, certs), "- ")...)
+	case DiffUnchanged:
+		return append([]string{heading + ": unchanged"}, markdownMarked(githubCertValue(diff.To, certs), "  ")...)
+	case DiffOrderChanged:
+		return []string{
+			heading + ": order changed",
+			truncateRunes("- "+formatCommaSeparated(diff.From), gitHubMaxLineRunes),
+			truncateRunes("+ "+formatCommaSeparated(diff.To), gitHubMaxLineRunes),
+		}
+	case DiffCommentChanged:
+		lines := []string{fmt.Sprintf("%s: %s comment changed", heading, diff.Comment)}
+		if diff.From != nil {
+			lines = append(lines, markdownMarked(diff.From, "- ")...)
+		}
+		if diff.To != nil {
+			lines = append(lines, markdownMarked(diff.To, "+ ")...)
+		}
+		return lines
+	case DiffMoved:
+		return []string{heading + ": moved from " + truncateRunes(sectionPathLabel(diff.FromPath, opts), gitHubMaxLineRunes)}
+	case DiffWarning:
+		return []string{heading + ": warning", truncateRunes("! "+diff.Warning, gitHubMaxLineRunes)}
+	case DiffTypeChanged:
+		from, to := githubCertPair(diff.From, diff.To, certs)
+		from, to = typeChangeOperand(from), typeChangeOperand(to)
+		lines := []string{fmt.Sprintf("%s: type changed from %s to %s", heading, diff.FromType, diff.ToType)}
+		lines = append(lines, markdownMarked(from, "- ")...)
+		return append(lines, markdownMarked(to, "+ ")...)
+	default: // DiffModified
+		from, to := githubCertPair(diff.From, diff.To, certs)
+		if fromStr, toStr, ok := multilineStrings(from, to); ok {
+			body, ok := githubMultilineDiff(fromStr, toStr, opts.ContextLines)
+			if !ok {
+				body = githubRewrittenPair(fromStr, toStr, gitHubMaxValueLines, gitHubMaxLineRunes)
+			}
+			// The first line of either rendering describes the change.
+			lines := strings.Split(body, "\n")
+			return append([]string{heading + ": " + lines[0]}, lines[1:]...)
+		}
+		lines := []string{heading}
+		lines = append(lines, markdownMarked(from, "- ")...)
+		return append(lines, markdownMarked(to, "+ ")...)
+	}
+}
+
+// markdownSectionSummary labels a <details> section with its file, document
+// and difference count. The summary is HTML, not Markdown, so names are
+// escaped rather than backtick-quoted.
+func markdownSectionSummary(file, doc string, n int, opts *FormatOptions) string {
+	noun := pluralize(n, "difference", "differences")
+	if opts.Unchanged {
+		noun = pluralize(n, "unchanged value", "unchanged values")
+	}
+	var parts []string
+	if file != "" {
+		parts = append(parts, "<code>"+html.EscapeString(file)+"</code>")
+	}
+	if doc != "" {
+		parts = append(parts, html.EscapeString(doc))
+	}
+	parts = append(parts, fmt.Sprintf("(%d %s)", n, noun))
+	return strings.Join(parts, " ")
+}
+
+// markdownCountCells renders the per-type count columns of a table row. Only
+// the unchanged column exists in inverse mode, where it is the only nonzero one.
+// The type changed, moved, comments and warnings columns exist only when
+// totals has some, that is when some value changed type, some subtree moved
+// (Options.DetectMoves), some comment changed (Options.CompareComments) or
+// some certificate was warned about (Options.CertPolicy).
+func markdownCountCells(c, totals diffTypeCounts, opts *FormatOptions) string {
+	if opts.Unchanged {
+		return fmt.Sprintf(" %d |", c.Unchanged)
+	}
+	cells := fmt.Sprintf(" %d | %d | %d | %d |", c.Added, c.Removed, c.Modified, c.OrderChanged)
+	if totals.TypeChanged > 0 {
+		cells += fmt.Sprintf(" %d |", c.TypeChanged)
+	}
+	if totals.Moved > 0 {
+		cells += fmt.Sprintf(" %d |", c.Moved)
+	}
+	if totals.CommentChanged > 0 {
+		cells += fmt.Sprintf(" %d |", c.CommentChanged)
+	}
+	if totals.Warnings > 0 {
+		cells += fmt.Sprintf(" %d |", c.Warnings)
+	}
+	return cells
+}
+
+// markdownSummaryTable renders counts per difference type. When any
+// difference belongs to a Kubernetes resource, counts are also broken down by
+// kind, one row each in alphabetical order, with non-resource differences on
+// a row of their own.
+func markdownSummaryTable(sb *strings.Builder, diffs []Difference, opts *FormatOptions) {
+	byKind := make(map[string][]Difference)
+	var total diffTypeCounts
+	for _, d := range diffs {
+		byKind[d.DocumentKind] = append(byKind[d.DocumentKind], d)
+	}
+	total.add(diffs)
+	_, hasPlain := byKind[""]
+	hasKinds := len(byKind) > 1 || !hasPlain
+
+	header, rule := "|", "|"
+	if hasKinds {
+		header, rule = "| Kind |", "| --- |"
+	}
+	if opts.Unchanged {
+		header += " Unchanged |"
+		rule += " ---: |"
+	} else {
+		header += " Added | Removed | Modified | Order changed |"
+		rule += " ---: | ---: | ---: | ---: |"
+	}
+	if total.TypeChanged > 0 && !opts.Unchanged {
+		header += " Type changed |"
+		rule += " ---: |"
+	}
+	if total.Moved > 0 && !opts.Unchanged {
+		header += " Moved |"
+		rule += " ---: |"
+	}
+	if total.CommentChanged > 0 && !opts.Unchanged {
+		header += " Comments |"
+		rule += " ---: |"
+	}
+	if total.Warnings > 0 && !opts.Unchanged {
+		header += " Warnings |"
+		rule += " ---: |"
+	}
+	sb.WriteString(header + "\n" + rule + "\n")
+
+	if !hasKinds {
+		sb.WriteString("|" + markdownCountCells(total, total, opts) + "\n\n")
+		return
+	}
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		if kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	if hasPlain {
+		kinds = append(kinds, "")
+	}
+	for _, kind := range kinds {
+		var c diffTypeCounts
+		c.add(byKind[kind])
+		label := kind
+		if label == "" {
+			label = "(other)"
+		}
+		fmt.Fprintf(sb, "| %s |%s\n", label, markdownCountCells(c, total, opts))
+	}
+	fmt.Fprintf(sb, "| **Total** |%s\n\n", markdownCountCells(total, total, opts))
+}
+
+// Format renders differences as a Markdown comment with sections labeled by
+// opts.FilePath when it is set.
+func (f *MarkdownFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	return f.FormatAll([]DiffGroup{{FilePath: opts.FilePath, Diffs: diffs}}, opts)
+}
+
+// FormatAll renders a single comment covering every file for directory mode.
+// Implements StructuredFormatter interface.
+func (f *MarkdownFormatter) FormatAll(groups []DiffGroup, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+
+	var all []Difference
+	var sections []markdownSection
+	for _, g := range groups {
+		all = append(all, g.Diffs...)
+		for _, doc := range documentSections(g.Diffs) {
+			var body []string
+			for _, d := range doc.Diffs {
+				body = append(body, markdownDiffLines(d, opts)...)
+			}
+			sections = append(sections, markdownSection{
+				Summary: markdownSectionSummary(g.FilePath, doc.Label, len(doc.Diffs), opts),
+				Body:    body,
+			})
+		}
+	}
+	if len(all) == 0 {
+		return emptyResultMessage(opts, " found")
+	}
+
+	var sb strings.Builder
+	if !opts.OmitHeader {
+		noun := pluralize(len(all), "difference", "differences")
+		if opts.Unchanged {
+			noun = pluralize(len(all), "unchanged value", "unchanged values")
+		}
+		fmt.Fprintf(&sb, "**Found %s %s**\n\n", formatCount(len(all)), noun)
+	}
+	markdownSummaryTable(&sb, all, opts)
+
+	used := utf8.RuneCountInString(sb.String())
+	for i, s := range sections {
+		block := markdownDetails(s.Summary, markdownFence(s.Body), s.Body)
+		n := utf8.RuneCountInString(block)
+		if used+n > markdownMaxCommentRunes {
+			omitted := len(sections) - i
+			if cut, ok := truncatedMarkdownSection(s, markdownMaxCommentRunes-used-markdownNoteRunes); ok {
+				sb.WriteString(cut)
+				omitted--
+			}
+			if omitted > 0 {
+				fmt.Fprintf(&sb, "_%d more %s not shown: comment size limit reached._\n",
+					omitted, pluralize(omitted, "section", "sections"))
+			}
+			break
+		}
+		sb.WriteString(block)
+		used += n
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// markdownDetails renders a collapsed <details> block holding body in a diff
+// fence.
+func markdownDetails(summary, fence string, body []string) string {
+	return fmt.Sprintf("<details>\n<summary>%s</summary>\n\n%sdiff\n%s\n%s\n\n</details>\n\n",
+		summary, fence, strings.Join(body, "\n"), fence)
+}
+
+// truncatedMarkdownSection renders s with as many of its diff lines as fit in
+// budget runes, followed inside the fence by a note counting the lines left
+// out. ok is false when not even one line fits.
+func truncatedMarkdownSection(s markdownSection, budget int) (string, bool) {
+	note := func(kept int) string {
+		rest := len(s.Body) - kept
+		return fmt.Sprintf("[%d more %s not shown: comment size limit reached]", rest, pluralize(rest, "line", "lines"))
+	}
+	fence := markdownFence(s.Body)
+	size := utf8.RuneCountInString(markdownDetails(s.Summary, fence, []string{note(0)}))
+	kept := 0
+	for kept < len(s.Body) && size+utf8.RuneCountInString(s.Body[kept])+1 <= budget {
+		size += utf8.RuneCountInString(s.Body[kept]) + 1
+		kept++
+	}
+	if kept == 0 {
+		return "", false
+	}
+	return markdownDetails(s.Summary, fence, append(s.Body[:kept:kept], note(kept))), true
+}
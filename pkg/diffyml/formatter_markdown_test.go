@@ -0,0 +1,200 @@
+package diffyml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatterByName_Markdown(t *testing.T) {
+	f, err := FormatterByName("markdown")
+	if err != nil {
+		t.Fatalf("FormatterByName(markdown) returned error: %v", err)
+	}
+	if _, ok := f.(*MarkdownFormatter); !ok {
+		t.Errorf("expected *MarkdownFormatter, got %T", f)
+	}
+	if _, ok := f.(StructuredFormatter); !ok {
+		t.Error("MarkdownFormatter should implement StructuredFormatter")
+	}
+}
+
+func TestMarkdownFormatter_Format(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "port"}, Type: DiffModified, From: 80, To: 8080},
+		{Path: DiffPath{"app", "debug"}, Type: DiffRemoved, From: true},
+		{Path: DiffPath{"app", "tags"}, Type: DiffOrderChanged, From: []any{"a", "b"}, To: []any{"b", "a"}},
+	}
+	output := (&MarkdownFormatter{}).Format(diffs, &FormatOptions{FilePath: "cfg.yaml", ContextLines: 4})
+
+	want := "**Found three differences**\n\n" +
+		"| Added | Removed | Modified | Order changed |\n" +
+		"| ---: | ---: | ---: | ---: |\n" +
+		"| 0 | 1 | 1 | 1 |\n\n" +
+		"<details>\n<summary><code>cfg.yaml</code> (3 differences)</summary>\n\n" +
+		"```diff\n" +
+		"# app.port\n- 80\n+ 8080\n" +
+		"# app.debug\n- true\n" +
+		"# app.tags: order changed\n- a, b\n+ b, a\n" +
+		"```\n\n</details>\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestMarkdownFormatter_KindTable(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"[0]", "spec", "replicas"}, Type: DiffModified, From: 2, To: 3, DocumentName: "apps/v1/Deployment/web", DocumentKind: "Deployment"},
+		{Path: DiffPath{"[1]", "data", "k"}, Type: DiffAdded, To: "v", DocumentIndex: 1, DocumentName: "v1/ConfigMap/cfg", DocumentKind: "ConfigMap"},
+		{Path: DiffPath{"[2]", "x"}, Type: DiffRemoved, From: 1, DocumentIndex: 2},
+	}
+	output := (&MarkdownFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true})
+
+	for _, want := range []string{
+		"| Kind | Added | Removed | Modified | Order changed |\n| --- | ---: | ---: | ---: | ---: |\n",
+		"| ConfigMap | 1 | 0 | 0 | 0 |\n| Deployment | 0 | 0 | 1 | 0 |\n| (other) | 0 | 1 | 0 | 0 |\n| **Total** | 1 | 1 | 1 | 0 |\n",
+		"<summary>apps/v1/Deployment/web (1 difference)</summary>",
+		"<summary>v1/ConfigMap/cfg (1 difference)</summary>",
+		"<summary>document 2 (1 difference)</summary>",
+		// The [N] prefix is dropped inside a document's section.
+		"# spec.replicas\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.HasPrefix(output, "**Found") {
+		t.Errorf("OmitHeader should drop the header:\n%s", output)
+	}
+}
+
+func TestMarkdownFormatter_TypeChangedAndMovedColumns(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "port"}, Type: DiffTypeChanged, From: "8080", To: 8080, FromType: "!!str", ToType: "!!int"},
+		{Path: DiffPath{"app", "server"}, Type: DiffMoved, FromPath: DiffPath{"app", "http"}},
+		{Path: DiffPath{"app", "debug"}, Type: DiffModified, From: false, To: true},
+	}
+	output := (&MarkdownFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true})
+
+	want := "| Added | Removed | Modified | Order changed | Type changed | Moved |\n" +
+		"| ---: | ---: | ---: | ---: | ---: | ---: |\n" +
+		"| 0 | 0 | 1 | 0 | 1 | 1 |\n"
+	if !strings.HasPrefix(output, want) {
+		t.Errorf("output should start with %q:\n%s", want, output)
+	}
+}
+
+func TestMarkdownFormatter_MultilineAndRootEntry(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"script"}, Type: DiffModified, From: "a\nb\nc\n", To: "a\nB\nc\n"},
+		{Path: DiffPath{}, Type: DiffAdded, To: &OrderedMap{Keys: []string{"extra"}, Values: map[string]any{"extra": "x"}}},
+	}
+	output := (&MarkdownFormatter{}).Format(diffs, nil)
+	for _, want := range []string{
+		"# script: multiline text (one insert, one deletion)\n  a\n- b\n+ B\n  c\n",
+		"# extra\n+ x\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestMarkdownFormatter_BoundsValues(t *testing.T) {
+	lines := make([]string, 50)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	long := strings.Repeat("x", 2*gitHubMaxLineRunes)
+	diffs := []Difference{
+		{Path: DiffPath{"big"}, Type: DiffAdded, To: strings.Join(lines, "\n")},
+		{Path: DiffPath{"wide"}, Type: DiffAdded, To: long},
+	}
+	output := (&MarkdownFormatter{}).Format(diffs, nil)
+	if !strings.Contains(output, "+ line 19\n[30 more lines]\n") || strings.Contains(output, "line 20") {
+		t.Errorf("added value not truncated at %d lines:\n%s", gitHubMaxValueLines, output)
+	}
+	if strings.Contains(output, long) || !strings.Contains(output, "more characters]") {
+		t.Error("long line not truncated")
+	}
+}
+
+func TestMarkdownFormatter_FenceLongerThanValueBackticks(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"doc"}, Type: DiffAdded, To: "see ```` here"}}
+	output := (&MarkdownFormatter{}).Format(diffs, nil)
+	if !strings.Contains(output, "`````diff\n") || !strings.Contains(output, "\n`````\n") {
+		t.Errorf("fence should outrun the value's backticks:\n%s", output)
+	}
+}
+
+func TestMarkdownFormatter_CommentSizeLimit(t *testing.T) {
+	var groups []DiffGroup
+	value := strings.Repeat("y", gitHubMaxLineRunes-10)
+	for i := range 400 {
+		groups = append(groups, DiffGroup{
+			FilePath: fmt.Sprintf("f%03d.yaml", i),
+			Diffs:    []Difference{{Path: DiffPath{"k"}, Type: DiffAdded, To: value}},
+		})
+	}
+	output := (&MarkdownFormatter{}).FormatAll(groups, nil)
+	if n := utf8.RuneCountInString(output); n > markdownMaxCommentRunes+200 {
+		t.Errorf("comment is %d runes, want at most about %d", n, markdownMaxCommentRunes)
+	}
+	if !strings.Contains(output, "more sections not shown: comment size limit reached._") {
+		t.Errorf("missing omitted-sections note:\n%s", output[len(output)-300:])
+	}
+	if strings.Count(output, "<details>") != strings.Count(output, "</details>") {
+		t.Error("a section was cut off mid-block")
+	}
+}
+
+func TestMarkdownFormatter_OversizedSectionTruncated(t *testing.T) {
+	var diffs []Difference
+	value := strings.Repeat("y", gitHubMaxLineRunes-10)
+	for i := range 400 {
+		diffs = append(diffs, Difference{Path: DiffPath{fmt.Sprintf("k%03d", i)}, Type: DiffAdded, To: value})
+	}
+	groups := []DiffGroup{
+		{FilePath: "big.yaml", Diffs: diffs},
+		{FilePath: "next.yaml", Diffs: []Difference{{Path: DiffPath{"k"}, Type: DiffAdded, To: "v"}}},
+	}
+	output := (&MarkdownFormatter{}).FormatAll(groups, nil)
+	if n := utf8.RuneCountInString(output); n > markdownMaxCommentRunes {
+		t.Errorf("comment is %d runes, want at most %d", n, markdownMaxCommentRunes)
+	}
+	if !strings.Contains(output, "<summary><code>big.yaml</code>") || !strings.Contains(output, "# k000\n+ y") {
+		t.Fatalf("oversized section dropped:\n%s", output[:300])
+	}
+	if !regexp.MustCompile("\\n\\[\\d+ more lines not shown: comment size limit reached\\]\\n```\\n\\n</details>").MatchString(output) {
+		t.Errorf("missing truncation note inside the fence:\n%s", output[len(output)-300:])
+	}
+	if !strings.HasSuffix(output, "_1 more section not shown: comment size limit reached._\n") {
+		t.Errorf("missing omitted-sections note:\n%s", output[len(output)-300:])
+	}
+}
+
+func TestMarkdownFormatter_Empty(t *testing.T) {
+	if got := (&MarkdownFormatter{}).Format(nil, nil); got != "no differences found\n" {
+		t.Errorf("Format(nil) = %q", got)
+	}
+	if got := (&MarkdownFormatter{}).FormatAll(nil, &FormatOptions{Unchanged: true}); got != "no unchanged values found\n" {
+		t.Errorf("FormatAll(nil) in inverse mode = %q", got)
+	}
+}
+
+func TestMarkdownFormatter_Unchanged(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffUnchanged, From: 1, To: 1}}
+	output := (&MarkdownFormatter{}).Format(diffs, &FormatOptions{Unchanged: true})
+	for _, want := range []string{
+		"**Found one unchanged value**",
+		"| Unchanged |\n| ---: |\n| 1 |\n",
+		"(1 unchanged value)",
+		"# a: unchanged\n  1\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package diffyml
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// sarifTestLog is the subset of a SARIF log the tests inspect.
+type sarifTestLog struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID string `json:"id"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex int    `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation *struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region *struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+						EndLine     int `json:"endLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+				LogicalLocations []struct {
+					FullyQualifiedName string `json:"fullyQualifiedName"`
+				} `json:"logicalLocations"`
+			} `json:"locations"`
+			PartialFingerprints map[string]string `json:"partialFingerprints"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func parseSARIF(t *testing.T, output string) sarifTestLog {
+	t.Helper()
+	var log sarifTestLog
+	if err := json.Unmarshal([]byte(output), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v\n%s", err, output)
+	}
+	if log.Version != "2.1.0" || log.Schema == "" {
+		t.Fatalf("version/schema = %q/%q", log.Version, log.Schema)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected exactly one run, got %d", len(log.Runs))
+	}
+	return log
+}
+
+func TestFormatterByName_SARIF(t *testing.T) {
+	f, err := FormatterByName("sarif")
+	if err != nil {
+		t.Fatalf("FormatterByName(sarif) returned error: %v", err)
+	}
+	if _, ok := f.(*SARIFFormatter); !ok {
+		t.Errorf("expected *SARIFFormatter, got %T", f)
+	}
+	if _, ok := f.(StructuredFormatter); !ok {
+		t.Error("SARIFFormatter should implement StructuredFormatter")
+	}
+}
+
+func TestSARIFFormatter_Results(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "version"}, Type: DiffModified, From: "1.0", To: "2.0",
+			ToPos: &Position{Line: 3, Column: 12, EndLine: 3}},
+		{Path: DiffPath{"app"}, Type: DiffRemoved, From: "x"},
+	}
+	log := parseSARIF(t, (&SARIFFormatter{}).Format(diffs, &FormatOptions{FilePath: "deploy.yaml"}))
+	run := log.Runs[0]
+
+	if run.Tool.Driver.Name != "diffyml" {
+		t.Errorf("driver name = %q", run.Tool.Driver.Name)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+
+	mod := run.Results[0]
+	if mod.RuleID != "diffyml/modified" || mod.Level != "warning" {
+		t.Errorf("modified rule/level = %q/%q", mod.RuleID, mod.Level)
+	}
+	if run.Tool.Driver.Rules[mod.RuleIndex].ID != mod.RuleID {
+		t.Errorf("ruleIndex %d does not point at rule %q", mod.RuleIndex, mod.RuleID)
+	}
+	phys := mod.Locations[0].PhysicalLocation
+	if phys == nil || phys.ArtifactLocation.URI != "deploy.yaml" {
+		t.Fatalf("physical location = %+v", phys)
+	}
+	if phys.Region == nil || phys.Region.StartLine != 3 || phys.Region.StartColumn != 12 || phys.Region.EndLine != 3 {
+		t.Errorf("region = %+v", phys.Region)
+	}
+	if mod.Locations[0].LogicalLocations[0].FullyQualifiedName != "app.version" {
+		t.Errorf("logical location = %+v", mod.Locations[0].LogicalLocations)
+	}
+	want := gitLabFingerprint("deploy.yaml", diffDescription(diffs[0]))
+	if mod.PartialFingerprints["diffyml/v1"] != want {
+		t.Errorf("fingerprint = %q, want %q", mod.PartialFingerprints["diffyml/v1"], want)
+	}
+
+	// No to-side position: the file is still located, without a region.
+	rem := run.Results[1]
+	if rem.Level != "error" || rem.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("removed result = %+v", rem)
+	}
+}
+
+func TestSARIFFormatter_RuleIndexPerType(t *testing.T) {
+	rules := sarifRules()
+	for _, dt := range []DiffType{DiffAdded, DiffRemoved, DiffModified, DiffOrderChanged, DiffUnchanged} {
+		r := sarifResultFor(Difference{Path: DiffPath{"a"}, Type: dt}, "")
+		if rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("%v: ruleIndex %d names %q, result has %q", dt, r.RuleIndex, rules[r.RuleIndex].ID, r.RuleID)
+		}
+		if rules[r.RuleIndex].DefaultConfiguration.Level != r.Level {
+			t.Errorf("%v: rule level %q != result level %q", dt, rules[r.RuleIndex].DefaultConfiguration.Level, r.Level)
+		}
+	}
+}
+
+func TestSARIFFormatter_NoFilePath(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffAdded, To: 1, ToPos: &Position{Line: 1, Column: 1, EndLine: 1}}}
+	log := parseSARIF(t, (&SARIFFormatter{}).Format(diffs, nil))
+	loc := log.Runs[0].Results[0].Locations[0]
+	if loc.PhysicalLocation == nil || loc.PhysicalLocation.ArtifactLocation.URI != "%3Cstdin%3E" {
+		t.Fatalf("physicalLocation should point at the stdin placeholder, got %+v", loc.PhysicalLocation)
+	}
+	if r := loc.PhysicalLocation.Region; r == nil || r.StartLine != 1 {
+		t.Errorf("region = %+v, want line 1", r)
+	}
+	if loc.LogicalLocations[0].FullyQualifiedName != "a" {
+		t.Errorf("logical location = %+v", loc.LogicalLocations)
+	}
+}
+
+func TestSARIFFormatter_StdinLabel(t *testing.T) {
+	diffs := []Difference{{Path: DiffPath{"a"}, Type: DiffAdded, To: 1}}
+	log := parseSARIF(t, (&SARIFFormatter{}).Format(diffs, &FormatOptions{FilePath: StdinLabel}))
+	loc := log.Runs[0].Results[0].Locations[0]
+	if loc.PhysicalLocation == nil || loc.PhysicalLocation.ArtifactLocation.URI != "%3Cstdin%3E" {
+		t.Errorf("physicalLocation = %+v, want the stdin placeholder", loc.PhysicalLocation)
+	}
+}
+
+func TestSARIFFormatter_Empty(t *testing.T) {
+	log := parseSARIF(t, (&SARIFFormatter{}).Format(nil, nil))
+	if log.Runs[0].Results == nil || len(log.Runs[0].Results) != 0 {
+		t.Errorf("expected an empty results array, got %+v", log.Runs[0].Results)
+	}
+	log = parseSARIF(t, (&SARIFFormatter{}).FormatAll(nil, nil))
+	if log.Runs[0].Results == nil {
+		t.Error("FormatAll with no groups should emit an empty results array")
+	}
+}
+
+func TestSARIFFormatter_FormatAll(t *testing.T) {
+	groups := []DiffGroup{
+		{FilePath: "a.yaml", Diffs: []Difference{{Path: DiffPath{"x"}, Type: DiffAdded, To: 1}}},
+		{FilePath: "b.yaml", Diffs: []Difference{
+			{Path: DiffPath{"y"}, Type: DiffModified, From: 1, To: 2},
+			{Path: DiffPath{"z"}, Type: DiffRemoved, From: 3},
+		}},
+	}
+	log := parseSARIF(t, (&SARIFFormatter{}).FormatAll(groups, nil))
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results in one run, got %d", len(results))
+	}
+	wantURIs := []string{"a.yaml", "b.yaml", "b.yaml"}
+	for i, r := range results {
+		if got := r.Locations[0].PhysicalLocation.ArtifactLocation.URI; got != wantURIs[i] {
+			t.Errorf("result %d uri = %q, want %q", i, got, wantURIs[i])
+		}
+	}
+	// The same change in two files must not share a fingerprint.
+	same := []DiffGroup{
+		{FilePath: "a.yaml", Diffs: []Difference{{Path: DiffPath{"x"}, Type: DiffAdded, To: 1}}},
+		{FilePath: "b.yaml", Diffs: []Difference{{Path: DiffPath{"x"}, Type: DiffAdded, To: 1}}},
+	}
+	log = parseSARIF(t, (&SARIFFormatter{}).FormatAll(same, nil))
+	r := log.Runs[0].Results
+	if r[0].PartialFingerprints["diffyml/v1"] == r[1].PartialFingerprints["diffyml/v1"] {
+		t.Error("fingerprints should differ across files")
+	}
+}
@@ -0,0 +1,616 @@
+// formatter_sidebyside.go - Two-column terminal output.
+//
+// Renders each changed document as its from-side YAML next to its to-side
+// YAML. Every source line is keyed by the YAML path it belongs to and the two
+// sides are aligned on those keys with the Myers diff the multiline renderer
+// uses, so an entry sits level with its counterpart even when lines around it
+// were added or removed. Lines covered by a difference's position are marked
+// and colored by difference type; runs of untouched lines fold like multiline
+// context. Long lines wrap onto a few continuation rows, then truncate.
+//
+// Whole documents need the raw inputs (FormatOptions.FromSource/ToSource).
+// Without them, as for library callers that only hold differences, each
+// difference is shown as its old and new value side by side under its path.
+// Key types: SideBySideFormatter.
+package diffyml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// SideBySideFormatter renders differences as two aligned columns sized to
+// FormatOptions.Width.
+type SideBySideFormatter struct{}
+
+const (
+	// sideBySideDefaultWidth is used when FormatOptions.Width is unset.
+	sideBySideDefaultWidth = 120
+	// sideBySideMinColumn is the narrowest text column; narrower widths
+	// overflow rather than squeeze values into unreadable slivers.
+	sideBySideMinColumn = 16
+	// sideBySideMaxWrapRows caps how many rows one long line may wrap onto
+	// before the rest is truncated, so a large scalar cannot flood the view.
+	sideBySideMaxWrapRows = 4
+	// sideBySideMaxEditDistance bounds the alignment search. Documents that
+	// differ by more lines than this are paired line by line instead.
+	sideBySideMaxEditDistance = 2000
+	// sideBySideSeparator divides the two columns.
+	sideBySideSeparator = " │ "
+)
+
+// sbsCell is one source line shown in a column.
+type sbsCell struct {
+	Num      int // 1-based source line number
+	Text     string
+	Type     DiffType
+	Marked   bool // covered by a difference; Type says which kind
+	Redacted bool // text replaced by the mask placeholder
+}
+
+// sbsRow is one aligned row. A nil side has no line on this row. Label rows
+// (folded runs, path headings) span both columns and have neither side.
+type sbsRow struct {
+	Left, Right *sbsCell
+	Label       string
+}
+
+// changed reports whether the row shows a difference, which keeps it and its
+// neighbors out of folded runs.
+func (r sbsRow) changed() bool {
+	if r.Label != "" {
+		return false
+	}
+	return r.Left == nil || r.Right == nil || r.Left.Marked || r.Right.Marked
+}
+
+// sbsSpan is the inclusive 1-based line range of one document.
+type sbsSpan struct {
+	Start, End int
+}
+
+// sbsSource is one side's raw input, split into lines with the path key and
+// difference mark of each line.
+type sbsSource struct {
+	Cells  []sbsCell
+	Keys   []string
+	Spans  []sbsSpan // per document index; zero span for an empty document
+	parsed bool
+}
+
+// newSideBySideSource splits src into lines and keys each line by the YAML
+// path it belongs to, redacting values mask covers. Input that does not parse
+// keeps its lines but has no document spans, so sections fall back to value
+// pairs, which are masked already.
+func newSideBySideSource(src []byte, mask *MaskOptions) *sbsSource {
+	s := &sbsSource{}
+	if len(src) == 0 {
+		return s
+	}
+	text := strings.TrimSuffix(string(src), "\n")
+	for i, line := range strings.Split(text, "\n") {
+		s.Cells = append(s.Cells, sbsCell{Num: i + 1, Text: strings.TrimSuffix(line, "\r")})
+	}
+	s.Keys = make([]string, len(s.Cells))
+
+	docs, err := parse(src)
+	if err != nil {
+		return s
+	}
+	s.parsed = true
+	prevEnd := 0
+	for _, doc := range docs {
+		if doc == nil {
+			s.Spans = append(s.Spans, sbsSpan{})
+			continue
+		}
+		s.keyNode(doc, "")
+		if mask != nil {
+			masked, err := maskedScalarNodes(doc, *mask)
+			if err != nil {
+				// An invalid pattern already failed MaskDifferences; showing
+				// nothing is the safe answer if a caller skipped it.
+				return &sbsSource{}
+			}
+			s.redact(masked, mask.Placeholder)
+		}
+		start := prevEnd + 1
+		// Leading separators and blank lines belong to no document.
+		for start <= len(s.Cells) && isDocumentGap(s.Cells[start-1].Text) {
+			start++
+		}
+		end := min(max(nodeEndLine(doc), start), len(s.Cells))
+		s.Spans = append(s.Spans, sbsSpan{Start: start, End: end})
+		prevEnd = end
+	}
+	for i, key := range s.Keys {
+		if key == "" {
+			// Comments, blank lines and separators align on their text.
+			s.Keys[i] = "#" + strings.TrimSpace(s.Cells[i].Text)
+		}
+	}
+	return s
+}
+
+// redact replaces the values of masked scalar nodes with placeholder. The
+// text before a value (indentation, key, list dash) stays; each content line
+// of a block scalar keeps its indentation.
+func (s *sbsSource) redact(masked map[*yaml.Node]bool, placeholder string) {
+	if placeholder == "" {
+		placeholder = DefaultMaskPlaceholder
+	}
+	for n := range masked {
+		if n.Line < 1 || n.Line > len(s.Cells) {
+			continue
+		}
+		c := &s.Cells[n.Line-1]
+		runes := []rune(c.Text)
+		c.Text = string(runes[:min(max(n.Column-1, 0), len(runes))]) + placeholder
+		c.Redacted = true
+		for line := n.Line + 1; line <= min(nodeEndLine(n), len(s.Cells)); line++ {
+			c := &s.Cells[line-1]
+			c.Text = c.Text[:len(c.Text)-len(strings.TrimLeft(c.Text, " \t"))] + placeholder
+			c.Redacted = true
+		}
+	}
+}
+
+// isDocumentGap reports whether a line separates documents rather than
+// belonging to one.
+func isDocumentGap(line string) bool {
+	t := strings.TrimSpace(line)
+	return t == "" || t == "---" || t == "..."
+}
+
+// setKey records path as the key of a 1-based line. Nodes are visited parent
+// first, so the deepest path on a line wins.
+func (s *sbsSource) setKey(line int, path string) {
+	if line >= 1 && line <= len(s.Keys) {
+		s.Keys[line-1] = path
+	}
+}
+
+// keyNode keys every line n spans. Sequence items are keyed by identifier
+// (or scalar value) where they have one, so an inserted item does not shift
+// the keys of the items after it.
+func (s *sbsSource) keyNode(n *yaml.Node, path string) {
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			s.keyNode(c, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			p := path + "." + n.Content[i].Value
+			s.setKey(n.Content[i].Line, p)
+			s.keyNode(n.Content[i+1], p)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			seg := strconv.Itoa(i)
+			if id := getIdentifierNode(item, nil); id != nil {
+				seg = fmt.Sprintf("=%v", id)
+			} else if item.Kind == yaml.ScalarNode {
+				seg = "=" + item.Value
+			}
+			p := path + "[" + seg + "]"
+			s.setKey(item.Line, p)
+			s.keyNode(item, p)
+		}
+	case yaml.ScalarNode:
+		s.setKey(n.Line, path)
+		// Block scalar content lines follow the indicator line.
+		for line := n.Line + 1; line <= nodeEndLine(n); line++ {
+			s.setKey(line, path+"#"+strconv.Itoa(line-n.Line))
+		}
+	default:
+		s.setKey(n.Line, path)
+	}
+}
+
+// sbsMarkRank orders difference types for lines covered by more than one
+// difference: an added or removed entry outranks a change to its container.
+func sbsMarkRank(t DiffType) int {
+	switch t {
+	case DiffAdded, DiffRemoved:
+		return 3
+	case DiffModified, DiffTypeChanged, DiffOrderChanged:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// sbsModifies reports whether t replaces a value in place, so a line it spans
+// may be the same on both sides.
+func sbsModifies(t DiffType) bool {
+	return t == DiffModified || t == DiffTypeChanged
+}
+
+// mark flags the lines pos covers as belonging to a difference of type t.
+func (s *sbsSource) mark(pos *Position, t DiffType) {
+	if pos == nil || pos.Line < 1 {
+		return
+	}
+	for line := pos.Line; line <= max(pos.EndLine, pos.Line) && line <= len(s.Cells); line++ {
+		c := &s.Cells[line-1]
+		if !c.Marked || sbsMarkRank(t) > sbsMarkRank(c.Type) {
+			c.Type, c.Marked = t, true
+		}
+	}
+}
+
+// spanOf returns the span of the document containing a difference's position
+// on this side.
+func (s *sbsSource) spanOf(diffs []Difference, pos func(Difference) *Position) (sbsSpan, bool) {
+	for _, d := range diffs {
+		p := pos(d)
+		if p == nil {
+			continue
+		}
+		for _, span := range s.Spans {
+			if span.Start <= p.Line && p.Line <= span.End {
+				return span, true
+			}
+		}
+	}
+	return sbsSpan{}, false
+}
+
+// sbsAlign pairs the lines of two spans into rows. Lines with matching path
+// keys share a row; a run of lines only on the left followed by a run only on
+// the right is laid out side by side rather than one after the other.
+func sbsAlign(from, to *sbsSource, fromSpan, toSpan sbsSpan) []sbsRow {
+	var left, right []*sbsCell
+	var leftKeys, rightKeys []string
+	for line := fromSpan.Start; line >= 1 && line <= fromSpan.End; line++ {
+		left = append(left, &from.Cells[line-1])
+		leftKeys = append(leftKeys, from.Keys[line-1])
+	}
+	for line := toSpan.Start; line >= 1 && line <= toSpan.End; line++ {
+		right = append(right, &to.Cells[line-1])
+		rightKeys = append(rightKeys, to.Keys[line-1])
+	}
+
+	ops, ok := computeLineDiffBounded(leftKeys, rightKeys, sideBySideMaxEditDistance)
+	if !ok {
+		rows := make([]sbsRow, max(len(left), len(right)))
+		for i := range rows {
+			if i < len(left) {
+				rows[i].Left = left[i]
+			}
+			if i < len(right) {
+				rows[i].Right = right[i]
+			}
+		}
+		return rows
+	}
+
+	var rows []sbsRow
+	var dels, ins []*sbsCell
+	flush := func() {
+		for i := range max(len(dels), len(ins)) {
+			var row sbsRow
+			if i < len(dels) {
+				row.Left = dels[i]
+			}
+			if i < len(ins) {
+				row.Right = ins[i]
+			}
+			rows = append(rows, row)
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op.Type {
+		case editKeep:
+			flush()
+			l, r := left[i], right[j]
+			if l.Marked && r.Marked && sbsModifies(l.Type) && sbsModifies(r.Type) && l.Text == r.Text && !l.Redacted && !r.Redacted {
+				// A line a modified value spans without changing, such as
+				// the indicator or an untouched line of a block scalar.
+				lc, rc := *l, *r
+				lc.Marked, rc.Marked = false, false
+				l, r = &lc, &rc
+			}
+			rows = append(rows, sbsRow{Left: l, Right: r})
+			i++
+			j++
+		case editDelete:
+			if len(ins) > 0 {
+				flush()
+			}
+			dels = append(dels, left[i])
+			i++
+		case editInsert:
+			ins = append(ins, right[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+// sbsFold replaces every run of unchanged rows farther than contextLines from
+// a change with a single label row.
+func sbsFold(rows []sbsRow, contextLines int) []sbsRow {
+	contextLines = resolveContextLines(contextLines)
+	near := make([]bool, len(rows))
+	for i, row := range rows {
+		if row.changed() {
+			for k := max(0, i-contextLines); k <= min(len(rows)-1, i+contextLines); k++ {
+				near[k] = true
+			}
+		}
+	}
+	var out []sbsRow
+	for i := 0; i < len(rows); {
+		if near[i] || rows[i].Label != "" {
+			out = append(out, rows[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(rows) && !near[end] && rows[end].Label == "" {
+			end++
+		}
+		out = append(out, sbsRow{Label: collapsedRunLabel(end - i)})
+		i = end
+	}
+	return out
+}
+
+// sbsValueRows lays out one difference as its old value next to its new one
+// under a path heading, for sections whose source lines are unavailable.
+func sbsValueRows(diff Difference, opts *FormatOptions) []sbsRow {
+	diff = expandMapKeyDiff(diff)
+	label := sectionPathLabel(diff.Path, opts) + mappedSuffix(diff, opts.UseGoPatchStyle)
+	switch diff.Type {
+	case DiffCommentChanged:
+		label += fmt.Sprintf(" (%s comment)", diff.Comment)
+	case DiffTypeChanged:
+		label += fmt.Sprintf(" (%s → %s)", diff.FromType, diff.ToType)
+	case DiffMoved:
+		// The changes inside the subtree follow as differences of their own.
+		return []sbsRow{{Label: label + " (moved from " + sectionPathLabel(diff.FromPath, opts) + ")"}}
+	case DiffWarning:
+		return []sbsRow{{Label: "⚠ " + label + ": " + diff.Warning}}
+	}
+	rows := []sbsRow{{Label: label}}
+	valueLines := func(val any) []string {
+		switch diff.Type {
+		case DiffOrderChanged:
+			return []string{formatCommaSeparated(val)}
+		case DiffCommentChanged:
+			if val == nil {
+				return nil
+			}
+		}
+		return strings.Split(strings.TrimSuffix(formatValue(val), "\n"), "\n")
+	}
+	var left, right []string
+	if diff.Type != DiffAdded {
+		left = valueLines(diff.From)
+	}
+	if diff.Type != DiffRemoved {
+		right = valueLines(diff.To)
+	}
+	for i := range max(len(left), len(right)) {
+		var row sbsRow
+		if i < len(left) {
+			row.Left = &sbsCell{Text: left[i], Type: diff.Type, Marked: true}
+		}
+		if i < len(right) {
+			row.Right = &sbsCell{Text: right[i], Type: diff.Type, Marked: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// sbsLayout holds the column geometry for one rendering.
+type sbsLayout struct {
+	NumWidth int // digits in the line-number gutter
+	TextCols int // runes of text per column
+}
+
+// newSideBySideLayout divides width between two columns, each led by a gutter
+// of line number and marker.
+func newSideBySideLayout(width, maxLine int) sbsLayout {
+	if width <= 0 {
+		width = sideBySideDefaultWidth
+	}
+	numWidth := len(strconv.Itoa(max(maxLine, 1)))
+	gutter := numWidth + 3 // number, space, marker, space
+	text := (width - utf8.RuneCountInString(sideBySideSeparator) - 2*gutter) / 2
+	return sbsLayout{NumWidth: numWidth, TextCols: max(text, sideBySideMinColumn)}
+}
+
+// sbsWrap splits text into chunks of at most cols runes, truncating with an
+// ellipsis past sideBySideMaxWrapRows chunks. Tabs expand to four spaces so
+// they cannot break the column arithmetic.
+func sbsWrap(text string, cols int) []string {
+	runes := []rune(strings.ReplaceAll(text, "\t", "    "))
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	var chunks []string
+	for len(runes) > 0 && len(chunks) < sideBySideMaxWrapRows {
+		n := min(cols, len(runes))
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	if len(runes) > 0 {
+		last := []rune(chunks[len(chunks)-1])
+		chunks[len(chunks)-1] = string(last[:len(last)-1]) + "…"
+	}
+	return chunks
+}
+
+// sbsMarker returns the gutter marker and color role for a cell.
+func sbsMarker(c *sbsCell) (string, ColorRole) {
+	switch c.Type {
+	case DiffAdded:
+		return "+", ColorRoleAdded
+	case DiffRemoved:
+		return "-", ColorRoleRemoved
+	case DiffUnchanged:
+		return "=", ColorRoleContext
+	case DiffCommentChanged:
+		return "#", ColorRoleModified
+	case DiffTypeChanged:
+		return "≠", ColorRoleModified
+	case DiffMoved:
+		return "→", ColorRoleModified
+	case DiffWarning:
+		return "⚠", ColorRoleModified
+	default:
+		return "~", ColorRoleModified
+	}
+}
+
+// writeSideBySideCell writes one wrapped row of a cell: the gutter, then the
+// text padded to the column width when pad is set. chunk indexes the wrapped
+// row; rows past the cell's last chunk, and absent cells, are blank.
+func writeSideBySideCell(sb *strings.Builder, c *sbsCell, chunks []string, chunk int, layout sbsLayout, pad bool, opts *FormatOptions) {
+	p := resolvedPalette(opts)
+	text := ""
+	if c != nil && chunk < len(chunks) {
+		text = chunks[chunk]
+	}
+	if c == nil || chunk > 0 || c.Num == 0 {
+		sb.WriteString(strings.Repeat(" ", layout.NumWidth+1))
+	} else {
+		sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleContext, opts.TrueColor)))
+		fmt.Fprintf(sb, "%*d", layout.NumWidth, c.Num)
+		sb.WriteString(colorEnd(opts))
+		sb.WriteString(" ")
+	}
+	marker := " "
+	if c != nil && c.Marked {
+		var role ColorRole
+		marker, role = sbsMarker(c)
+		if chunk > 0 {
+			marker = " "
+		}
+		sb.WriteString(colorStart(opts, p.ColorCode(role, opts.TrueColor)))
+		sb.WriteString(marker + " " + text)
+		sb.WriteString(colorEnd(opts))
+	} else {
+		sb.WriteString(marker + " " + text)
+	}
+	if pad {
+		sb.WriteString(strings.Repeat(" ", layout.TextCols-utf8.RuneCountInString(text)))
+	}
+}
+
+// writeSideBySideRows renders rows in the given layout.
+func writeSideBySideRows(sb *strings.Builder, rows []sbsRow, layout sbsLayout, opts *FormatOptions) {
+	p := resolvedPalette(opts)
+	for _, row := range rows {
+		if row.Label != "" {
+			sb.WriteString(strings.Repeat(" ", layout.NumWidth+3))
+			sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleContext, opts.TrueColor)))
+			sb.WriteString(row.Label)
+			sb.WriteString(colorEnd(opts))
+			sb.WriteString("\n")
+			continue
+		}
+		var left, right []string
+		if row.Left != nil {
+			left = sbsWrap(row.Left.Text, layout.TextCols)
+		}
+		if row.Right != nil {
+			right = sbsWrap(row.Right.Text, layout.TextCols)
+		}
+		for k := range max(len(left), len(right), 1) {
+			var line strings.Builder
+			writeSideBySideCell(&line, row.Left, left, k, layout, true, opts)
+			line.WriteString(sideBySideSeparator)
+			writeSideBySideCell(&line, row.Right, right, k, layout, false, opts)
+			sb.WriteString(strings.TrimRight(line.String(), " "))
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// Format renders each changed document as two aligned columns.
+func (f *SideBySideFormatter) Format(diffs []Difference, opts *FormatOptions) string {
+	if opts == nil {
+		opts = DefaultFormatOptions()
+	}
+	if len(diffs) == 0 {
+		return emptyResultMessage(opts, " found")
+	}
+
+	from := newSideBySideSource(opts.FromSource, opts.Mask)
+	to := newSideBySideSource(opts.ToSource, opts.Mask)
+	for _, d := range diffs {
+		if d.Type != DiffAdded {
+			from.mark(d.FromPos, d.Type)
+		}
+		if d.Type != DiffRemoved {
+			to.mark(d.ToPos, d.Type)
+		}
+	}
+
+	type section struct {
+		Label string
+		Rows  []sbsRow
+	}
+	var sections []section
+	for _, doc := range documentSections(diffs) {
+		fromSpan, fromOK := from.spanOf(doc.Diffs, func(d Difference) *Position { return d.FromPos })
+		toSpan, toOK := to.spanOf(doc.Diffs, func(d Difference) *Position { return d.ToPos })
+		var rows []sbsRow
+		if (fromOK || toOK) && (from.parsed || len(from.Cells) == 0) && (to.parsed || len(to.Cells) == 0) {
+			rows = sbsFold(sbsAlign(from, to, fromSpan, toSpan), opts.ContextLines)
+			// The marked lines locate a warning; its finding follows the document.
+			for _, d := range doc.Diffs {
+				if d.Type == DiffWarning {
+					rows = append(rows, sbsValueRows(d, opts)...)
+				}
+			}
+		} else {
+			for _, d := range doc.Diffs {
+				rows = append(rows, sbsValueRows(d, opts)...)
+			}
+		}
+		sections = append(sections, section{Label: doc.Label, Rows: rows})
+	}
+
+	layout := newSideBySideLayout(opts.Width, max(len(from.Cells), len(to.Cells)))
+	p := resolvedPalette(opts)
+	var sb strings.Builder
+	if !opts.OmitHeader {
+		noun := pluralize(len(diffs), "difference", "differences")
+		if opts.Unchanged {
+			noun = pluralize(len(diffs), "unchanged value", "unchanged values")
+		}
+		sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleModified, opts.TrueColor)))
+		fmt.Fprintf(&sb, "Found %s %s", formatCount(len(diffs)), noun)
+		sb.WriteString(colorEnd(opts))
+		sb.WriteString("\n\n")
+	}
+	for i, s := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if s.Label != "" {
+			sb.WriteString(colorStart(opts, p.ColorCode(ColorRoleDocName, opts.TrueColor)))
+			sb.WriteString(s.Label)
+			sb.WriteString(colorEnd(opts))
+			sb.WriteString("\n")
+		}
+		writeSideBySideRows(&sb, s.Rows, layout, opts)
+	}
+	return sb.String()
+}
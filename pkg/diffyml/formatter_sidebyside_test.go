@@ -0,0 +1,221 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+// sideBySide compares from and to and renders the result with both sources.
+func sideBySide(t *testing.T, from, to string, opts *FormatOptions) string {
+	t.Helper()
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	opts.FromSource, opts.ToSource = []byte(from), []byte(to)
+	return (&SideBySideFormatter{}).Format(diffs, opts)
+}
+
+func TestFormatterByName_SideBySide(t *testing.T) {
+	f, err := FormatterByName("side-by-side")
+	if err != nil {
+		t.Fatalf("FormatterByName(side-by-side) returned error: %v", err)
+	}
+	if _, ok := f.(*SideBySideFormatter); !ok {
+		t.Errorf("expected *SideBySideFormatter, got %T", f)
+	}
+}
+
+func TestSideBySideFormatter_Aligned(t *testing.T) {
+	from := "app:\n  image: nginx:1.24\n  debug: true\n  port: 80\n"
+	to := "app:\n  image: nginx:1.25\n  port: 80\n  tls: on\n"
+	output := sideBySide(t, from, to, &FormatOptions{Width: 60, ContextLines: 4})
+
+	want := "Found three differences\n\n" +
+		"1   app:                     │ 1   app:\n" +
+		"2 ~   image: nginx:1.24      │ 2 ~   image: nginx:1.25\n" +
+		"3 -   debug: true            │\n" +
+		"4     port: 80               │ 3     port: 80\n" +
+		"                             │ 4 +   tls: on\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestSideBySideFormatter_FoldsUnchangedRuns(t *testing.T) {
+	var from, to strings.Builder
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		from.WriteString(k + ": 1\n")
+		if k == "e" {
+			to.WriteString(k + ": 2\n")
+		} else {
+			to.WriteString(k + ": 1\n")
+		}
+	}
+	output := sideBySide(t, from.String(), to.String(), &FormatOptions{OmitHeader: true, Width: 60, ContextLines: 1})
+
+	want := "    [3 lines unchanged]\n" +
+		"4   d: 1                     │ 4   d: 1\n" +
+		"5 ~ e: 1                     │ 5 ~ e: 2\n" +
+		"6   f: 1                     │ 6   f: 1\n" +
+		"    [2 lines unchanged]\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestSideBySideFormatter_BlockScalarMarksChangedLinesOnly(t *testing.T) {
+	from := "script: |\n  echo start\n  run --fast\n  echo done\n"
+	to := "script: |\n  echo start\n  run --slow\n  echo done\n"
+	output := sideBySide(t, from, to, &FormatOptions{OmitHeader: true, Width: 60, ContextLines: 4})
+
+	for _, want := range []string{
+		"1   script: |",
+		"2     echo start",
+		"3 ~   run --fast             │ 3 ~   run --slow\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestSideBySideFormatter_ListItemsAlignByIdentifier(t *testing.T) {
+	from := "env:\n  - name: A\n    value: 1\n  - name: B\n    value: 2\n"
+	to := "env:\n  - name: NEW\n    value: 0\n  - name: A\n    value: 1\n  - name: B\n    value: 3\n"
+	output := sideBySide(t, from, to, &FormatOptions{OmitHeader: true, Width: 60, ContextLines: 4})
+
+	for _, want := range []string{
+		"                             │ 2 +   - name: NEW\n",
+		"2     - name: A              │ 4     - name: A\n",
+		"5 ~     value: 2             │ 7 ~     value: 3\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestSideBySideFormatter_KubernetesDocuments(t *testing.T) {
+	from := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	to := from + "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n"
+	to = strings.Replace(to, "k: a", "k: b", 1)
+	output := sideBySide(t, from, to, &FormatOptions{OmitHeader: true, Width: 60, ContextLines: 4})
+
+	for _, want := range []string{
+		"v1/ConfigMap/cfg\n",
+		" 6 ~   k: a                  │  6 ~   k: b\n",
+		"\nv1/Service/svc\n",
+		// An added document has nothing on the from side.
+		"                             │  8 + apiVersion: v1\n",
+		"                             │ 11 +   name: svc\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "---") {
+		t.Errorf("document separator should not be shown:\n%s", output)
+	}
+}
+
+func TestSideBySideFormatter_WithoutSources(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"app", "port"}, Type: DiffModified, From: 80, To: 8080},
+		{Path: DiffPath{"app", "debug"}, Type: DiffRemoved, From: true},
+	}
+	output := (&SideBySideFormatter{}).Format(diffs, &FormatOptions{OmitHeader: true, Width: 50})
+
+	want := "    app.port\n" +
+		"  ~ 80                  │   ~ 8080\n" +
+		"    app.debug\n" +
+		"  - true                │\n"
+	if output != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestSideBySideFormatter_Color(t *testing.T) {
+	palette := DefaultCustomColorPalette()
+	palette.Added = &CustomColor{R: 1, G: 2, B: 3, ANSICode: colorGreen, IsCustom: true}
+	output := sideBySide(t, "a: 1\n", "a: 1\nb: 2\n", &FormatOptions{Color: true, TrueColor: true, Palette: palette, Width: 60})
+	if !strings.Contains(output, TrueColorCode(1, 2, 3)+"+ b: 2") {
+		t.Errorf("added line not colored with the palette:\n%q", output)
+	}
+}
+
+func TestSideBySideFormatter_Empty(t *testing.T) {
+	if got := (&SideBySideFormatter{}).Format(nil, nil); got != "no differences found\n" {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
+
+func TestNewSideBySideLayout(t *testing.T) {
+	tests := []struct {
+		width, maxLine   int
+		numWidth, textCs int
+	}{
+		{100, 9, 1, 44},
+		{100, 120, 3, 42},
+		{0, 9, 1, 54},  // default width
+		{20, 9, 1, 16}, // floor
+	}
+	for _, tt := range tests {
+		got := newSideBySideLayout(tt.width, tt.maxLine)
+		if got.NumWidth != tt.numWidth || got.TextCols != tt.textCs {
+			t.Errorf("newSideBySideLayout(%d, %d) = %+v, want NumWidth %d TextCols %d",
+				tt.width, tt.maxLine, got, tt.numWidth, tt.textCs)
+		}
+	}
+}
+
+func TestSbsWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		cols int
+		want []string
+	}{
+		{"fits", "abc", 5, []string{"abc"}},
+		{"empty", "", 5, []string{""}},
+		{"wraps", "abcdefg", 3, []string{"abc", "def", "g"}},
+		{"truncates past the row cap", strings.Repeat("x", 20), 3, []string{"xxx", "xxx", "xxx", "xx…"}},
+		{"expands tabs", "a\tb", 10, []string{"a    b"}},
+		{"counts runes", "ééé", 2, []string{"éé", "é"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sbsWrap(tt.text, tt.cols)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("sbsWrap(%q, %d) = %q, want %q", tt.text, tt.cols, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSideBySideFormatter_MasksSourceValues(t *testing.T) {
+	from := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\ndata:\n  password: aHVudGVyMg==\n  cert: |\n    line one\n"
+	to := strings.Replace(from, "aHVudGVyMg==", "c3dvcmRmaXNo", 1)
+	mask := MaskOptions{MaskSecrets: true, MaskPaths: []string{"metadata.name"}}
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = MaskDifferences(diffs, mask); err != nil {
+		t.Fatal(err)
+	}
+	output := (&SideBySideFormatter{}).Format(diffs, &FormatOptions{
+		FromSource: []byte(from), ToSource: []byte(to), Mask: &mask, Width: 80, ContextLines: 4,
+	})
+
+	for _, secret := range []string{"aHVudGVyMg==", "c3dvcmRmaXNo", "line one", "name: creds"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, output)
+		}
+	}
+	for _, want := range []string{"~   password: ***", "    name: ***", "  cert: ***", "      ***"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -3163,3 +3163,41 @@ func TestDiffDescription_WithDocumentName(t *testing.T) {
 		t.Errorf("expected document name in description, got: %q", desc)
 	}
 }
+
+func TestFormatters_TypeChanged(t *testing.T) {
+	diff := Difference{
+		Path:     DiffPath{"spec", "port"},
+		Type:     DiffTypeChanged,
+		From:     "8080",
+		To:       8080,
+		FromType: "string",
+		ToType:   "int",
+	}
+	noColor := &FormatOptions{OmitHeader: true}
+	tests := []struct {
+		name string
+		f    Formatter
+		want []string
+	}{
+		{"compact", &CompactFormatter{}, []string{`≠ spec.port (string → int) : "8080" → 8080`}},
+		{"brief", &BriefFormatter{}, []string{"1 type changed\n"}},
+		{"detailed", &DetailedFormatter{}, []string{"  ± type change from string to int\n"}},
+		{"github", &GitHubFormatter{}, []string{`::warning title=YAML Type Changed::Type changed: spec.port changed from string "8080" to int 8080`}},
+		{"gitlab", &GitLabFormatter{}, []string{`"check_name": "diffyml/type-changed"`, `"severity": "major"`}},
+		{"sarif", &SARIFFormatter{}, []string{`"ruleId": "diffyml/type-changed"`, `"ruleIndex": 6`, `"level": "warning"`}},
+		{"json", &JSONFormatter{}, []string{`"type": "type_changed"`, `"from": "8080"`, `"to": 8080`, `"from_type": "string"`, `"to_type": "int"`}},
+		{"json-patch", &JSONPatchFormatter{}, []string{`"op": "replace"`, `"path": "/spec/port"`, `"value": 8080`}},
+		{"markdown", &MarkdownFormatter{}, []string{"spec.port: type changed from string to int\n- \"8080\"\n+ 8080\n"}},
+		{"side-by-side", &SideBySideFormatter{}, []string{"spec.port (string → int)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.f.Format([]Difference{diff}, noColor)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output missing %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
@@ -49,28 +49,10 @@ func getIdentifierNode(n *yaml.Node, opts *Options) any {
 
 // canMatchByIdentifierNodes mirrors canMatchByIdentifier for a slice of
 // nodes: every item must be a MappingNode (or fail the check), and at least
-// one item must yield a usable comparable identifier. The nil and Kind guards
-// are kept independent so each mutation target is testable in isolation.
+// one item must yield a usable comparable identifier. It applies the default
+// name/id rule; lists with a ListKey go through identityAt.
 func canMatchByIdentifierNodes(items []*yaml.Node, opts *Options) bool {
-	hasIdentifier := false
-	for _, item := range items {
-		item = resolveAlias(item)
-		if item == nil {
-			// Cycle-collapsed alias (resolveAlias returns nil) disqualifies
-			// outright; nodeToInterface would render it as a nil entry.
-			return false
-		}
-		if item.Kind != yaml.MappingNode {
-			// Non-mapping item disqualifies identifier matching outright,
-			// matching CanMatchByIdentifierWithAdditional's behavior.
-			return false
-		}
-		id := getIdentifierNode(item, opts)
-		if isComparableIdentifier(id) {
-			hasIdentifier = true
-		}
-	}
-	return hasIdentifier
+	return listIdentity{opts: opts}.canMatch(items)
 }
 
 // lookupMappingValueNode returns the value node paired with the LAST source-
@@ -96,13 +96,13 @@ func collectMatchedK8sUnchanged(matched map[int]int, fromNodes, toNodes []*yaml.
 			pathPrefix = DiffPath{"[" + strconv.Itoa(docIdx) + "]"}
 		}
 
-		// Document root is not a sequence element (inList false).
-		nodeDiffs := collectUnchanged(pathPrefix, fromNodes[fromIdx], toNodes[toIdx], opts, false)
-		var docName, docKind string
+		var docName, docKind, docAPIVersion string
 		if f, ok := k8sExtractFields(toDocs[toIdx]); ok {
 			docName = f.displayName()
-			docKind = f.kind
+			docKind, docAPIVersion = f.kind, f.apiVersion
 		}
+		// Document root is not a sequence element (inList false).
+		nodeDiffs := collectUnchanged(pathPrefix, fromNodes[fromIdx], toNodes[toIdx], opts.forResource(docAPIVersion, docKind), false)
 		for i := range nodeDiffs {
 			nodeDiffs[i].DocumentIndex = docIdx
 			nodeDiffs[i].DocumentName = docName
@@ -154,6 +154,11 @@ func collectUnchanged(path DiffPath, fromN, toN *yaml.Node, opts *Options, inLis
 		if deepEqualSequenceNodes(fromN, toN, opts) {
 			return []Difference{unchangedEntry(path, fromN, toN, inList)}
 		}
+		if isScalarSet(path, identityAt(path, opts), fromN, toN, opts) {
+			// A reordered set is still equal as a whole, so the collapse
+			// needs inList too.
+			return collectUnchangedScalarSet(path, fromN, toN, inList, opts)
+		}
 		return collectUnchangedSequence(path, fromN, toN, opts)
 	default:
 		// Scalar: isNullNode already excluded !!null on both sides, so this
@@ -170,7 +175,15 @@ func collectUnchanged(path DiffPath, fromN, toN *yaml.Node, opts *Options, inLis
 // materializing both sides once. inList tags a collapse whose container is a
 // sequence so isListEntryDiff renders it with the "- " list prefix.
 func unchangedEntry(path DiffPath, fromN, toN *yaml.Node, inList bool) Difference {
-	return Difference{Path: path, Type: DiffUnchanged, From: nodeToInterface(fromN), To: nodeToInterface(toN), listEntry: inList}
+	return Difference{
+		Path:      path,
+		Type:      DiffUnchanged,
+		From:      nodeToInterface(fromN),
+		To:        nodeToInterface(toN),
+		FromPos:   nodePosition(fromN),
+		ToPos:     nodePosition(toN),
+		listEntry: inList,
+	}
 }
 
 // collectUnchangedMapping recurses on keys present in BOTH mappings, preserving
@@ -206,7 +219,15 @@ func descendUnchangedMapping(path DiffPath, fromN, toN *yaml.Node, opts *Options
 // --ignore-order-changes or for heterogeneous single-key-map lists), otherwise
 // positional.
 func collectUnchangedSequence(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
-	if canMatchByIdentifierNodes(fromN.Content, opts) && canMatchByIdentifierNodes(toN.Content, opts) {
+	ids := identityAt(path, opts)
+	switch {
+	case ids.listType == listTypeAtomic:
+		// A changed atomic list is one modified value: nothing in it is
+		// unchanged.
+		return nil
+	case ids.listType == listTypeSet:
+		return collectUnchangedSequenceUnordered(path, fromN, toN, opts)
+	case ids.canMatch(fromN.Content) && ids.canMatch(toN.Content):
 		return collectUnchangedSequenceByIdentifier(path, fromN, toN, opts)
 	}
 
@@ -216,12 +237,14 @@ func collectUnchangedSequence(path DiffPath, fromN, toN *yaml.Node, opts *Option
 
 	from := fromN.Content
 	to := toN.Content
-	minLen := min(len(from), len(to))
 
 	var diffs []Difference
-	for i := range minLen {
+	for _, p := range alignSequence(from, to) {
+		if p.from < 0 || p.to < 0 {
+			continue
+		}
 		// Sequence element: a collapse here is a list item.
-		diffs = append(diffs, collectUnchanged(path.Append(strconv.Itoa(i)), from[i], to[i], opts, true)...)
+		diffs = append(diffs, collectUnchanged(path.Append(strconv.Itoa(p.from)), from[p.from], to[p.to], opts, true)...)
 	}
 
 	return diffs
@@ -269,6 +292,8 @@ func collectUnchangedUnorderedItems(path DiffPath, from, to []*yaml.Node, fromId
 					Type:      DiffUnchanged,
 					From:      nodeToInterface(from[fromIdx]),
 					To:        nodeToInterface(to[toIdx]),
+					FromPos:   nodePosition(from[fromIdx]),
+					ToPos:     nodePosition(to[toIdx]),
 					listEntry: true,
 				})
 				matched = true
@@ -307,11 +332,12 @@ func collectUnchangedUnorderedItems(path DiffPath, from, to []*yaml.Node, fromId
 func collectUnchangedSequenceByIdentifier(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
 	from := fromN.Content
 	to := toN.Content
+	ids := identityAt(path, opts)
 
 	fromIndex := make(map[any]int, len(from))
 	var fromNoID []int
 	for i, item := range from {
-		id := getIdentifierNode(item, opts)
+		id := ids.of(item)
 		if isComparableIdentifier(id) {
 			fromIndex[id] = i // last-write-wins, matching the normal path
 			continue
@@ -322,7 +348,7 @@ func collectUnchangedSequenceByIdentifier(path DiffPath, fromN, toN *yaml.Node,
 	toIndex := make(map[any]int, len(to))
 	var toNoID []int
 	for i, item := range to {
-		id := getIdentifierNode(item, opts)
+		id := ids.of(item)
 		if isComparableIdentifier(id) {
 			toIndex[id] = i // last-write-wins, matching the normal path
 			continue
@@ -332,7 +358,7 @@ func collectUnchangedSequenceByIdentifier(path DiffPath, fromN, toN *yaml.Node,
 
 	var diffs []Difference
 	for i, item := range from {
-		id := getIdentifierNode(item, opts)
+		id := ids.of(item)
 		// gomutants:disable-next-line BRANCH_IF reason="defensive; non-comparable identifiers are absent from both indexes, so the following index guards also continue"
 		if !isComparableIdentifier(id) {
 			continue
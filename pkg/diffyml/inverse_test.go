@@ -498,19 +498,19 @@ func TestInverse_DetailedIdentifierListItemIsList(t *testing.T) {
 }
 
 func TestInverse_IgnoreOrderChangesPlainList(t *testing.T) {
-	// A reordered scalar list: without --ignore-order-changes only the
-	// position-aligned value (2 at index 1) is unchanged; with the flag every
-	// common value is matched order-independently. The differing 'other' sibling
+	// A reordered scalar list: without --ignore-order-changes the alignment
+	// keeps only one of the common values in place (3 at old index 2); with
+	// the flag every common value is matched order-independently. The differing 'other' sibling
 	// keeps the root from collapsing so the list is descended into.
 	from := "nums:\n  - 1\n  - 2\n  - 3\nother: 1\n"
 	to := "nums:\n  - 3\n  - 2\n  - 1\nother: 2\n"
 
 	plain := unchangedByPath(t, mustCompareUnchanged(t, from, to, nil))
-	if _, ok := plain["nums.1"]; !ok {
-		t.Errorf("positional: expected nums.1 unchanged, got %v", keys(plain))
+	if _, ok := plain["nums.2"]; !ok {
+		t.Errorf("aligned: expected nums.2 unchanged, got %v", keys(plain))
 	}
 	if len(plain) != 1 {
-		t.Errorf("positional: expected only nums.1, got %v", keys(plain))
+		t.Errorf("aligned: expected only nums.2, got %v", keys(plain))
 	}
 
 	ordered := unchangedByPath(t, mustCompareUnchanged(t, from, to, &diffyml.Options{IgnoreOrderChanges: true}))
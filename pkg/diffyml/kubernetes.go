@@ -350,12 +350,16 @@ func compareMatchedK8sDocs(matched map[int]int, fromNodes, toNodes []*yaml.Node,
 			pathPrefix = DiffPath{fmt.Sprintf("[%d]", docIdx)}
 		}
 
-		nodeDiffs := compareNodes(pathPrefix, fromN, toN, opts)
-		var docName, docKind string
+		var docName, docKind, docAPIVersion string
 		if f, ok := k8sExtractFields(toDoc); ok {
 			docName = f.displayName()
-			docKind = f.kind
+			docKind, docAPIVersion = f.kind, f.apiVersion
 		}
+		docOpts := opts.forResource(docAPIVersion, docKind)
+		nodeDiffs := compareComments(pathPrefix, nil, fromN, nil, toN, opts)
+		nodeDiffs = append(nodeDiffs, compareNodes(pathPrefix, fromN, toN, docOpts)...)
+		nodeDiffs = detectMoves(nodeDiffs, docOpts)
+		nodeDiffs = append(nodeDiffs, certWarnings(pathPrefix, fromN, toN, docOpts)...)
 		for i := range nodeDiffs {
 			nodeDiffs[i].DocumentIndex = docIdx
 			nodeDiffs[i].DocumentName = docName
@@ -413,6 +417,7 @@ func compareK8sDocsCached(fromNodes, toNodes []*yaml.Node, fromDocs, toDocs []an
 			Type:          DiffRemoved,
 			From:          fromDocs[fromIdx],
 			To:            nil,
+			FromPos:       nodePosition(fromNodes[fromIdx]),
 			DocumentIndex: fromIdx,
 			DocumentName:  docName,
 			DocumentKind:  docKind,
@@ -424,20 +429,28 @@ func compareK8sDocsCached(fromNodes, toNodes []*yaml.Node, fromDocs, toDocs []an
 			continue
 		}
 		pathPrefix := DiffPath{fmt.Sprintf("[%d]", toIdx)}
-		var docName, docKind string
+		var docName, docKind, docAPIVersion string
 		if f, ok := k8sExtractFields(toDocs[toIdx]); ok {
 			docName = f.displayName()
-			docKind = f.kind
+			docKind, docAPIVersion = f.kind, f.apiVersion
 		}
 		diffs = append(diffs, Difference{
 			Path:          pathPrefix,
 			Type:          DiffAdded,
 			From:          nil,
 			To:            toDocs[toIdx],
+			ToPos:         nodePosition(toNodes[toIdx]),
 			DocumentIndex: toIdx,
 			DocumentName:  docName,
 			DocumentKind:  docKind,
 		})
+		warnings := certWarnings(pathPrefix, nil, toNodes[toIdx], opts.forResource(docAPIVersion, docKind))
+		for i := range warnings {
+			warnings[i].DocumentIndex = toIdx
+			warnings[i].DocumentName = docName
+			warnings[i].DocumentKind = docKind
+		}
+		diffs = append(diffs, warnings...)
 	}
 
 	return diffs
@@ -0,0 +1,140 @@
+// kubernetes_list_keys.go - Built-in list keys for Kubernetes resources.
+//
+// The strategic-merge patchMergeKeys of the core, apps, batch and
+// networking types, so that container ports, volume mounts, host aliases and
+// the like are matched item by item rather than by position. The table
+// identifies only items without a name or id, which stay matched by name, and
+// applies to documents matched as Kubernetes resources (DetectKubernetes);
+// Options.ListKeys entries and Options.Schema list types take precedence over
+// it.
+// Key functions: builtinListKeys, (*Options).forResource, k8sNodeResource.
+package diffyml
+
+import "go.yaml.in/yaml/v3"
+
+// podSpecListKeys are the patchMergeKeys of a PodSpec's lists, relative to
+// the PodSpec.
+var podSpecListKeys = []ListKey{
+	{Path: "volumes", Fields: []string{"name"}},
+	{Path: "imagePullSecrets", Fields: []string{"name"}},
+	{Path: "hostAliases", Fields: []string{"ip"}},
+	{Path: "topologySpreadConstraints", Fields: []string{"topologyKey", "whenUnsatisfiable"}},
+	{Path: "resourceClaims", Fields: []string{"name"}},
+	{Path: "schedulingGates", Fields: []string{"name"}},
+}
+
+// containerListKeys are the patchMergeKeys of a Container's lists, relative
+// to the container. Ports are keyed like the API server's list-map keys,
+// containerPort and protocol, with the protocol it defaults.
+var containerListKeys = []ListKey{
+	{Path: "ports", Fields: []string{"containerPort", "protocol"}, defaults: portDefaults},
+	{Path: "env", Fields: []string{"name"}},
+	{Path: "volumeMounts", Fields: []string{"mountPath"}},
+	{Path: "volumeDevices", Fields: []string{"devicePath"}},
+	{Path: "resizePolicy", Fields: []string{"resourceName"}},
+}
+
+// portDefaults fills in the protocol a port omits, as the API server does.
+var portDefaults = map[string]any{"protocol": "TCP"}
+
+// podSpecPaths locates the PodSpec of each workload kind.
+var podSpecPaths = []struct {
+	kind string
+	path string
+}{
+	{"Pod", "spec"},
+	{"PodTemplate", "template.spec"},
+	{"ReplicationController", "spec.template.spec"},
+	{"ReplicaSet", "spec.template.spec"},
+	{"Deployment", "spec.template.spec"},
+	{"StatefulSet", "spec.template.spec"},
+	{"DaemonSet", "spec.template.spec"},
+	{"Job", "spec.template.spec"},
+	{"CronJob", "spec.jobTemplate.spec.template.spec"},
+}
+
+// resourceListKeys are the list keys of specific kinds outside a PodSpec, and
+// (with no Kind) of the metadata and status every resource shares. A
+// Service's ports are left out: the API server requires a name on each once
+// there are several, and a lone unnamed port reads better compared in place.
+var resourceListKeys = []ListKey{
+	{Kind: "ServiceAccount", Path: "secrets", Fields: []string{"name"}},
+	{Kind: "ServiceAccount", Path: "imagePullSecrets", Fields: []string{"name"}},
+	{Kind: "Node", Path: "status.addresses", Fields: []string{"type"}},
+	{Path: "metadata.ownerReferences", Fields: []string{"uid"}},
+	{Path: "status.conditions", Fields: []string{"type"}},
+}
+
+// k8sListKeys indexes the built-in table by kind; the "" entry holds the keys
+// of every kind.
+var k8sListKeys = buildK8sListKeys()
+
+// buildK8sListKeys expands the PodSpec and container keys under each
+// workload's PodSpec path.
+func buildK8sListKeys() map[string][]ListKey {
+	table := make(map[string][]ListKey)
+	for _, p := range podSpecPaths {
+		for _, k := range podSpecListKeys {
+			table[p.kind] = append(table[p.kind], ListKey{Kind: p.kind, Path: p.path + "." + k.Path, Fields: k.Fields, defaults: k.defaults})
+		}
+		for _, containers := range []string{"containers", "initContainers", "ephemeralContainers"} {
+			for _, k := range containerListKeys {
+				path := p.path + "." + containers + ".*." + k.Path
+				table[p.kind] = append(table[p.kind], ListKey{Kind: p.kind, Path: path, Fields: k.Fields, defaults: k.defaults})
+			}
+		}
+	}
+	for _, k := range resourceListKeys {
+		table[k.Kind] = append(table[k.Kind], k)
+	}
+	return table
+}
+
+// builtinListKeys returns the built-in list keys that apply to a kind.
+func builtinListKeys(kind string) [][]ListKey {
+	return [][]ListKey{k8sListKeys[kind], k8sListKeys[""]}
+}
+
+// forResource returns the options for comparing a Kubernetes resource of the
+// given apiVersion and kind, so that list identities consult the schema and
+// the built-in table. It returns o itself when there is no kind.
+func (o *Options) forResource(apiVersion, kind string) *Options {
+	if o == nil || kind == "" || o.k8sKind == kind && o.k8sAPIVersion == apiVersion {
+		return o
+	}
+	scoped := *o
+	scoped.k8sAPIVersion, scoped.k8sKind = apiVersion, kind
+	return &scoped
+}
+
+// k8sNodeResource returns the apiVersion and kind of a document node when it
+// is a Kubernetes resource (as IsKubernetesResource decides), else "", "".
+func k8sNodeResource(n *yaml.Node) (apiVersion, kind string) {
+	if n != nil && n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	n = resolveAlias(n)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return "", ""
+	}
+	apiVersionN := resolveAlias(lookupMappingValueNode(n, "apiVersion"))
+	kindN := resolveAlias(lookupMappingValueNode(n, "kind"))
+	if !isStringScalar(apiVersionN) || !isStringScalar(kindN) {
+		return "", ""
+	}
+	metadata := resolveAlias(lookupMappingValueNode(n, "metadata"))
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return "", ""
+	}
+	for _, key := range []string{"name", "generateName"} {
+		if v := resolveAlias(lookupMappingValueNode(metadata, key)); v != nil && v.ShortTag() != "!!null" {
+			return apiVersionN.Value, kindN.Value
+		}
+	}
+	return "", ""
+}
+
+// isStringScalar reports whether n is a string scalar.
+func isStringScalar(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.ScalarNode && n.ShortTag() == "!!str"
+}
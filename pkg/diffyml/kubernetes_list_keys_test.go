@@ -0,0 +1,201 @@
+package diffyml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const deploymentWithMounts = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      hostAliases:
+        - ip: 10.0.0.1
+          hostnames: [db]
+      containers:
+        - name: app
+          volumeMounts:
+%s            - name: data
+              mountPath: /data
+            - name: config
+              mountPath: /etc/app
+              readOnly: true
+`
+
+func TestCompare_K8sBuiltinListKeys(t *testing.T) {
+	from := fmtMounts("")
+	to := strings.Replace(fmtMounts("            - name: cache\n              mountPath: /cache\n"), "ip: 10.0.0.1\n          hostnames: [db]", "ip: 10.0.0.1\n          hostnames: [db, cache]", 1)
+
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		if d.Type != DiffAdded {
+			t.Errorf("%s: type %v, want DiffAdded", d.Path, d.Type)
+		}
+		got = append(got, d.Path.String())
+	}
+	want := []string{
+		"spec.template.spec.containers.app.volumeMounts",
+		"spec.template.spec.hostAliases[10.0.0.1].hostnames.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffs = %v, want %v", got, want)
+	}
+
+	// Without Kubernetes detection the mounts are matched by name, and the
+	// host alias by position.
+	diffs, err = Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, d := range diffs {
+		if strings.Contains(d.Path.String(), "10.0.0.1") {
+			t.Errorf("built-in keys applied without DetectKubernetes: %s", d.Path)
+		}
+	}
+}
+
+func fmtMounts(extra string) string {
+	return strings.Replace(deploymentWithMounts, "%s", extra, 1)
+}
+
+func TestCompare_K8sListKeyOverride(t *testing.T) {
+	from := fmtMounts("")
+	to := strings.Replace(from, "readOnly: true", "readOnly: false", 1)
+	opts := &Options{
+		DetectKubernetes: true,
+		ListKeys:         []ListKey{{Kind: "Deployment", Path: "containers.*.volumeMounts", Fields: []string{"mountPath"}}},
+	}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Path.String() != "spec.template.spec.containers.app.volumeMounts./etc/app.readOnly" {
+		t.Errorf("diffs = %+v, want volumeMounts./etc/app.readOnly", diffs)
+	}
+
+	// A key for another kind leaves the built-in one in place, which leaves
+	// named items to the name.
+	opts.ListKeys[0].Kind = "StatefulSet"
+	diffs, err = Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Path.String() != "spec.template.spec.containers.app.volumeMounts.config.readOnly" {
+		t.Errorf("diffs = %+v, want volumeMounts.config.readOnly", diffs)
+	}
+}
+
+func TestCompare_K8sBuiltinPortKeys(t *testing.T) {
+	pod := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n    - name: app\n      ports:\n"
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{
+			name: "named port renumbered",
+			from: pod + "        - name: http\n          containerPort: 80\n",
+			to:   pod + "        - name: http\n          containerPort: 81\n",
+			want: []string{"spec.containers.app.ports.http.containerPort"},
+		},
+		{
+			name: "protocol defaulted",
+			from: pod + "        - containerPort: 80\n          hostPort: 8080\n",
+			to:   pod + "        - containerPort: 80\n          protocol: TCP\n          hostPort: 8081\n",
+			want: []string{"spec.containers.app.ports.80,TCP", "spec.containers.app.ports.80,TCP.hostPort"},
+		},
+		{
+			name: "another protocol",
+			from: pod + "        - containerPort: 53\n",
+			to:   pod + "        - containerPort: 53\n          protocol: UDP\n",
+			want: []string{"spec.containers.app.ports", "spec.containers.app.ports"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare([]byte(tt.from), []byte(tt.to), &Options{DetectKubernetes: true})
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			var got []string
+			for _, d := range diffs {
+				got = append(got, d.Path.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinListKeys(t *testing.T) {
+	tests := []struct {
+		kind string
+		path DiffPath
+		want []string
+	}{
+		{"Deployment", DiffPath{"spec", "template", "spec", "containers", "app", "ports"}, []string{"containerPort", "protocol"}},
+		{"CronJob", DiffPath{"spec", "jobTemplate", "spec", "template", "spec", "initContainers", "init", "env"}, []string{"name"}},
+		{"Pod", DiffPath{"[2]", "spec", "volumes"}, []string{"name"}},
+		{"Service", DiffPath{"spec", "ports"}, nil},
+		{"ConfigMap", DiffPath{"metadata", "ownerReferences"}, []string{"uid"}},
+		{"Deployment", DiffPath{"status", "conditions"}, []string{"type"}},
+		{"Deployment", DiffPath{"spec", "ports"}, nil},
+		{"", DiffPath{"spec", "ports"}, nil},
+	}
+	for _, tt := range tests {
+		got := identityAt(tt.path, (&Options{}).forResource("v1", tt.kind)).fields
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("identityAt(%v) in %q = %v, want %v", tt.path, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestK8sNodeResource(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n", "v1/Service"},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n", "apps/v1/Deployment"},
+		{"apiVersion: v1\nkind: Pod\nmetadata:\n  generateName: web-\n", "v1/Pod"},
+		{"apiVersion: v1\nkind: Pod\nmetadata:\n  name: null\n", ""},
+		{"apiVersion: v1\nkind: Pod\n", ""},
+		{"kind: Pod\nmetadata:\n  name: web\n", ""},
+		{"- a\n", ""},
+	}
+	for _, tt := range tests {
+		nodes, err := parseNodes([]byte(tt.src))
+		if err != nil {
+			t.Fatalf("parseNodes(%q) failed: %v", tt.src, err)
+		}
+		apiVersion, kind := k8sNodeResource(nodes[0])
+		got := ""
+		if kind != "" {
+			got = apiVersion + "/" + kind
+		}
+		if got != tt.want {
+			t.Errorf("k8sNodeResource(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPatch_K8sBuiltinListKeys(t *testing.T) {
+	source := fmtMounts("")
+	patch := `[{"op": "replace", "path": "/spec/template/spec/hostAliases/10.0.0.1/hostnames/0", "value": "cache"}]`
+	got, err := ApplyPatch([]byte(source), []byte(patch), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if want := strings.Replace(source, "hostnames: [db]", "hostnames: [cache]", 1); string(got) != want {
+		t.Errorf("ApplyPatch =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+// list_align.go - Aligning the items of unidentified lists.
+//
+// A list whose items carry no identifier used to be compared index by index,
+// so one item inserted near the top shifted every later item into a
+// modification and reported the last one as added. alignSequence first runs
+// the Myers diff of detailed_formatter_linediff.go over structural keys of
+// the items: items with equal keys are paired in place, and only the runs
+// between them are paired by position, the surplus of a run being added or
+// removed. An insertion or deletion in the middle is then one addition or
+// removal.
+// Key functions: alignSequence, structuralKeys.
+package diffyml
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// listAlignMaxEdits bounds the edit distance alignSequence searches; lists
+// that differ by more items are paired by position. The Myers trace grows
+// with the square of the bound.
+const listAlignMaxEdits = 512
+
+// itemPair pairs the item at index from in the old list with the item at
+// index to in the new one. A -1 marks an item present on the other side
+// only: added when from is -1, removed when to is -1.
+type itemPair struct {
+	from, to int
+}
+
+// alignSequence pairs the items of two unidentified lists, in list order.
+func alignSequence(from, to []*yaml.Node) []itemPair {
+	ops, ok := computeLineDiffBounded(structuralKeys(from), structuralKeys(to), listAlignMaxEdits)
+	if !ok {
+		return positionalPairs(len(from), len(to))
+	}
+	pairs := make([]itemPair, 0, max(len(from), len(to)))
+	var deleted, inserted []int
+	flush := func() {
+		n := min(len(deleted), len(inserted))
+		for k := range n {
+			pairs = append(pairs, itemPair{deleted[k], inserted[k]})
+		}
+		for _, i := range deleted[n:] {
+			pairs = append(pairs, itemPair{i, -1})
+		}
+		for _, j := range inserted[n:] {
+			pairs = append(pairs, itemPair{-1, j})
+		}
+		deleted, inserted = deleted[:0], inserted[:0]
+	}
+	x, y := 0, 0
+	for _, op := range ops {
+		switch op.Type {
+		case editKeep:
+			flush()
+			pairs = append(pairs, itemPair{x, y})
+			x++
+			y++
+		case editDelete:
+			deleted = append(deleted, x)
+			x++
+		case editInsert:
+			inserted = append(inserted, y)
+			y++
+		}
+	}
+	flush()
+	return pairs
+}
+
+// positionalPairs pairs two lists index by index.
+func positionalPairs(m, n int) []itemPair {
+	pairs := make([]itemPair, 0, max(m, n))
+	for i := range min(m, n) {
+		pairs = append(pairs, itemPair{i, i})
+	}
+	for j := m; j < n; j++ {
+		pairs = append(pairs, itemPair{-1, j})
+	}
+	for i := n; i < m; i++ {
+		pairs = append(pairs, itemPair{i, -1})
+	}
+	return pairs
+}
+
+// structuralKeys returns the structural key of each item (see
+// writeStructuralKey).
+func structuralKeys(items []*yaml.Node) []string {
+	keys := make([]string, len(items))
+	var sb strings.Builder
+	for i, item := range items {
+		sb.Reset()
+		writeStructuralKey(&sb, item)
+		keys[i] = sb.String()
+	}
+	return keys
+}
+
+// writeStructuralKey renders a node so that equal values render alike:
+// mapping keys are sorted (the last of duplicate keys wins, as in comparison)
+// and scalars are rendered by resolved type and value. Values that compare
+// equal only under options such as IgnoreWhitespaceChanges may render
+// differently; the pairing of the runs between equal keys still compares them.
+func writeStructuralKey(sb *strings.Builder, n *yaml.Node) {
+	if isNullNode(n) {
+		sb.WriteString("~")
+		return
+	}
+	n = resolveNode(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		idx := indexMappingValues(n)
+		keys := make([]string, 0, len(idx))
+		for k := range idx {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		sb.WriteByte('{')
+		for _, k := range keys {
+			fmt.Fprintf(sb, "%q:", k)
+			writeStructuralKey(sb, n.Content[idx[k]+1])
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('}')
+	case yaml.SequenceNode:
+		sb.WriteByte('[')
+		for _, item := range n.Content {
+			writeStructuralKey(sb, item)
+			sb.WriteByte(',')
+		}
+		sb.WriteByte(']')
+	default:
+		v := resolveScalar(n)
+		fmt.Fprintf(sb, "%T:%q", v, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,100 @@
+package diffyml
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestCompare_AlignsUnidentifiedLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []setDiff
+	}{
+		{
+			name: "insertion at the top",
+			from: "args: [--b, --c, --d]\n",
+			to:   "args: [--a, --b, --c, --d]\n",
+			want: []setDiff{{DiffAdded, "args.0", "--a"}},
+		},
+		{
+			name: "deletion in the middle",
+			from: "steps:\n  - {run: build}\n  - {run: lint}\n  - {run: test}\n",
+			to:   "steps:\n  - {run: build}\n  - {run: test}\n",
+			want: []setDiff{{DiffRemoved, "steps.1", map[string]any{"run": "lint"}}},
+		},
+		{
+			name: "changed item between kept ones",
+			from: "steps:\n  - {run: build}\n  - {run: lint}\n  - {run: test}\n",
+			to:   "steps:\n  - {run: build}\n  - {run: vet}\n  - {run: test}\n  - {run: push}\n",
+			want: []setDiff{
+				{DiffModified, "steps.1.run", "vet"},
+				{DiffAdded, "steps.3", map[string]any{"run": "push"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare([]byte(tt.from), []byte(tt.to), nil)
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			got := setDiffs(diffs)
+			for i := range got {
+				if m, ok := got[i].value.(*OrderedMap); ok {
+					got[i].value = orderedMapToPlain(m)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignSequence_FallsBackToPositions(t *testing.T) {
+	// Every item differs, so the edit distance exceeds the bound.
+	from := make([]*yaml.Node, listAlignMaxEdits/2+1)
+	to := make([]*yaml.Node, len(from)+1)
+	for i := range from {
+		from[i] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(i)}
+	}
+	for j := range to {
+		to[j] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(-j - 1)}
+	}
+	if got, want := alignSequence(from, to), positionalPairs(len(from), len(to)); !reflect.DeepEqual(got, want) {
+		t.Errorf("alignSequence = %v, want positional pairs", got)
+	}
+	if got, want := positionalPairs(3, 2), []itemPair{{0, 0}, {1, 1}, {2, -1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("positionalPairs = %v, want %v", got, want)
+	}
+}
+
+func TestApplyPatch_AlignedListRoundTrip(t *testing.T) {
+	from := "steps:\n" +
+		"  - {run: build}\n" +
+		"  - {run: lint}\n" +
+		"  - {run: test, with: {a: 1}, args: [--b, --c]}\n" +
+		"  - {run: test}\n"
+	to := "steps:\n" +
+		"  - {run: setup}\n" +
+		"  - {run: build}\n" +
+		"  - {run: test, with: {a: 1, b: 2}, args: [--a, --b, --c]}\n" +
+		"  - {run: test}\n" +
+		"  - {run: push}\n"
+	for _, format := range []string{"json", "json-patch"} {
+		t.Run(format, func(t *testing.T) {
+			got := roundTrip(t, from, to, format)
+			rest, err := Compare([]byte(got), []byte(to), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("patched file still differs from target (%d differences):\n%s", len(rest), got)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// list_keys.go - Per-path list identifiers (Options.ListKeys).
+//
+// By default a list item is identified by its first AdditionalIdentifiers
+// field, then "name", then "id", in every list alike. A ListKey declares the
+// identifier of the lists at one path pattern instead, and may combine
+// several fields: container ports by containerPort and protocol,
+// tolerations by key and effect. A composite identifier joins the field
+// values with commas, escaping any within them, so the ports item
+// {containerPort: 80, protocol: TCP} is reported at "ports.80,TCP".
+// Kubernetes resources add the list types of Options.Schema (schema.go) and a
+// built-in table of keys (kubernetes_list_keys.go), consulted in that order
+// after Options.ListKeys.
+// Key types: ListKey.
+// Key functions: identityAt, ParseListKey, ListKey.UnmarshalYAML.
+package diffyml
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ListKey declares the identifier fields of the lists whose path matches
+// Path.
+type ListKey struct {
+	// Path is a dot-notation pattern matched against the end of a list's
+	// path, with any document index stripped; "*" matches any one segment.
+	// "tolerations" matches every tolerations list, "containers.*.env" the
+	// env list of every container.
+	Path string
+	// Kind restricts the key to Kubernetes resources of this kind, such as
+	// "Deployment"; empty applies to every document.
+	Kind string
+	// Fields name the item fields that together identify an item. A field
+	// an item lacks counts as empty; an item with none of them has no
+	// identifier and is matched like an unnamed item.
+	Fields []string
+	// defaults holds the values a built-in key assumes for fields an item
+	// omits, such as a port's protocol, which the API server defaults to TCP.
+	defaults map[string]any
+}
+
+// ParseListKey parses the command-line form of a ListKey:
+// "[KIND:]PATH=FIELD[,FIELD...]", such as
+// "containers.*.ports=containerPort,protocol" or
+// "Deployment:spec.template.spec.volumes=name".
+func ParseListKey(s string) (ListKey, error) {
+	path, fields, ok := strings.Cut(s, "=")
+	var kind string
+	if k, rest, hasKind := strings.Cut(path, ":"); hasKind {
+		if k == "" {
+			return ListKey{}, fmt.Errorf("invalid list key %q: empty kind", s)
+		}
+		kind, path = k, rest
+	}
+	if !ok || path == "" || fields == "" {
+		return ListKey{}, fmt.Errorf("invalid list key %q: want [KIND:]PATH=FIELD[,FIELD...]", s)
+	}
+	key := ListKey{Path: path, Kind: kind}
+	for _, f := range strings.Split(fields, ",") {
+		if f == "" {
+			return ListKey{}, fmt.Errorf("invalid list key %q: empty field name", s)
+		}
+		key.Fields = append(key.Fields, f)
+	}
+	return key, nil
+}
+
+// UnmarshalYAML reads a ListKey from configuration, either in the
+// command-line form ("tolerations=key,effect") or as a mapping with path,
+// fields and optional kind keys ({path: containers.*.ports, fields:
+// [containerPort, protocol]}).
+func (k *ListKey) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		key, err := ParseListKey(n.Value)
+		if err != nil {
+			return err
+		}
+		*k = key
+		return nil
+	}
+	var raw struct {
+		Path   string   `yaml:"path"`
+		Kind   string   `yaml:"kind"`
+		Fields []string `yaml:"fields"`
+	}
+	if err := n.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.Path == "" || len(raw.Fields) == 0 || slices.Contains(raw.Fields, "") {
+		return fmt.Errorf("line %d: list key needs a path and non-empty fields", n.Line)
+	}
+	*k = ListKey{Path: raw.Path, Kind: raw.Kind, Fields: raw.Fields}
+	return nil
+}
+
+// matches reports whether the key applies to the list at path in a
+// document of the given Kubernetes kind: the pattern matches the end of path.
+func (k ListKey) matches(path DiffPath, kind string) bool {
+	if k.Kind != "" && k.Kind != kind {
+		return false
+	}
+	return matchesListPath(k.Path, path)
+}
+
+// matchesListPath reports whether a dot-notation pattern matches the end of
+// a list's path, with any document index stripped; "*" matches any one
+// segment.
+func matchesListPath(p string, path DiffPath) bool {
+	if _, rest, ok := path.DocIndexPrefix(); ok {
+		path = rest
+	}
+	pattern := strings.Split(p, ".")
+	if len(pattern) > len(path) {
+		return false
+	}
+	tail := path[len(path)-len(pattern):]
+	for i, seg := range pattern {
+		if seg != "*" && seg != tail[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// listIdentity identifies the items of one list: by the fields of the first
+// ListKey matching its path, user-declared or built in, by the list-map keys
+// its schema declares, or by the default name/id rule when none does. A
+// built-in key only identifies the items the default rule cannot, so named
+// items stay matched by name. Items of a schema "set" or "atomic" list have
+// no identifier.
+type listIdentity struct {
+	fields   []string
+	defaults map[string]any // see ListKey.defaults
+	fallback bool           // fields apply only to items without a name or id
+	listType string         // schema list type, or "" when no schema rule applies
+	opts     *Options
+}
+
+// identityAt returns the identity of the items of the list at path.
+func identityAt(path DiffPath, opts *Options) listIdentity {
+	if opts == nil {
+		return listIdentity{}
+	}
+	for _, k := range opts.ListKeys {
+		if k.matches(path, opts.k8sKind) {
+			return listIdentity{fields: k.Fields, opts: opts}
+		}
+	}
+	if opts.k8sKind != "" {
+		if r, ok := opts.Schema.lookup(opts.k8sAPIVersion, opts.k8sKind, path); ok {
+			return listIdentity{fields: r.keys, listType: r.listType, opts: opts}
+		}
+		for _, keys := range builtinListKeys(opts.k8sKind) {
+			for _, k := range keys {
+				if k.matches(path, opts.k8sKind) {
+					return listIdentity{fields: k.Fields, defaults: k.defaults, fallback: true, opts: opts}
+				}
+			}
+		}
+	}
+	return listIdentity{opts: opts}
+}
+
+// of returns the identifier of list item n, or nil when it has none.
+func (li listIdentity) of(n *yaml.Node) any {
+	if li.unkeyed() {
+		return nil
+	}
+	if li.fields == nil || li.fallback {
+		if id := getIdentifierNode(n, li.opts); li.fields == nil || isComparableIdentifier(id) {
+			return id
+		}
+	}
+	n = resolveAlias(n)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	values := make([]any, len(li.fields))
+	for i, f := range li.fields {
+		if v := lookupMappingValueNode(n, f); v != nil {
+			values[i] = materializeIdentifierValue(v)
+		} else {
+			values[i] = li.defaults[f]
+		}
+	}
+	return compositeIdentifier(values)
+}
+
+// ofValue is the any-shaped counterpart of of, for materialized list items.
+func (li listIdentity) ofValue(v any) any {
+	if li.unkeyed() {
+		return nil
+	}
+	if li.fields == nil || li.fallback {
+		var additional []string
+		if li.opts != nil {
+			additional = li.opts.AdditionalIdentifiers
+		}
+		if id := valueIdentifier(v, additional); li.fields == nil || isComparableIdentifier(id) {
+			return id
+		}
+	}
+	var lookup func(string) (any, bool)
+	switch m := v.(type) {
+	case *OrderedMap:
+		lookup = func(k string) (any, bool) {
+			val, ok := m.Values[k]
+			return val, ok
+		}
+	case map[string]any:
+		lookup = func(k string) (any, bool) {
+			val, ok := m[k]
+			return val, ok
+		}
+	default:
+		return nil
+	}
+	values := make([]any, len(li.fields))
+	for i, f := range li.fields {
+		var ok bool
+		if values[i], ok = lookup(f); !ok {
+			values[i] = li.defaults[f]
+		}
+	}
+	return compositeIdentifier(values)
+}
+
+// unkeyed reports whether the schema declares the list a set or atomic, so
+// that its items are never matched by identifier.
+func (li listIdentity) unkeyed() bool {
+	return li.listType == listTypeSet || li.listType == listTypeAtomic
+}
+
+// canMatch reports whether a list can be matched item by item: every item
+// is a mapping, and at least one has an identifier.
+func (li listIdentity) canMatch(items []*yaml.Node) bool {
+	hasIdentifier := false
+	for _, item := range items {
+		item = resolveAlias(item)
+		if item == nil {
+			// Cycle-collapsed alias (resolveAlias returns nil) disqualifies
+			// outright; nodeToInterface would render it as a nil entry.
+			return false
+		}
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+		if isComparableIdentifier(li.of(item)) {
+			hasIdentifier = true
+		}
+	}
+	return hasIdentifier
+}
+
+// identifierPartEscaper escapes the separator in a composite identifier part.
+var identifierPartEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`)
+
+// compositeIdentifier combines the values of a ListKey's fields. A single
+// field keeps its value as is, like the default rule; several are joined
+// with commas into one string, without the empty parts at its end. A comma or
+// backslash within a value is escaped with a backslash, so that no two
+// tuples of values join alike. Missing fields are nil; when all are, or when
+// a value cannot serve as an identifier, the result is nil.
+func compositeIdentifier(values []any) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+	parts := make([]string, len(values))
+	present := false
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		switch v.(type) {
+		case *OrderedMap, map[string]any, []any:
+			return nil
+		}
+		present = true
+		parts[i] = identifierPartEscaper.Replace(sprintIdentifier(v))
+	}
+	if !present {
+		return nil
+	}
+	for parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,256 @@
+package diffyml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestParseListKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ListKey
+		err  bool
+	}{
+		{"tolerations=key,effect", ListKey{Path: "tolerations", Fields: []string{"key", "effect"}}, false},
+		{"containers.*.env=name", ListKey{Path: "containers.*.env", Fields: []string{"name"}}, false},
+		{"tolerations", ListKey{}, true},
+		{"=key", ListKey{}, true},
+		{"tolerations=", ListKey{}, true},
+		{"tolerations=key,,effect", ListKey{}, true},
+		{"Deployment:spec.template.spec.volumes=name", ListKey{Kind: "Deployment", Path: "spec.template.spec.volumes", Fields: []string{"name"}}, false},
+		{":volumes=name", ListKey{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseListKey(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseListKey(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseListKey(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestListKey_UnmarshalYAML(t *testing.T) {
+	var keys []ListKey
+	src := "- tolerations=key,effect\n- path: containers.*.ports\n  fields: [containerPort, protocol]\n"
+	if err := yaml.Unmarshal([]byte(src), &keys); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := []ListKey{
+		{Path: "tolerations", Fields: []string{"key", "effect"}},
+		{Path: "containers.*.ports", Fields: []string{"containerPort", "protocol"}},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %+v, want %+v", keys, want)
+	}
+
+	for _, bad := range []string{"- tolerations\n", "- path: ports\n", "- fields: [name]\n"} {
+		if err := yaml.Unmarshal([]byte(bad), &keys); err == nil {
+			t.Errorf("Unmarshal(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestListKey_Matches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    DiffPath
+		want    bool
+	}{
+		{"tolerations", DiffPath{"spec", "tolerations"}, true},
+		{"tolerations", DiffPath{"[1]", "spec", "tolerations"}, true},
+		{"containers.*.env", DiffPath{"spec", "containers", "app", "env"}, true},
+		{"containers.*.env", DiffPath{"spec", "initContainers", "app", "env"}, false},
+		{"containers.*.env", DiffPath{"env"}, false},
+		{"spec.tolerations", DiffPath{"tolerations"}, false},
+		{"ports", DiffPath{"spec", "ports", "http"}, false},
+	}
+	for _, tt := range tests {
+		k := ListKey{Path: tt.pattern, Fields: []string{"name"}}
+		if got := k.matches(tt.path, ""); got != tt.want {
+			t.Errorf("ListKey{%q}.matches(%v) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityAt(t *testing.T) {
+	opts := &Options{
+		AdditionalIdentifiers: []string{"key"},
+		ListKeys: []ListKey{
+			{Path: "ports", Fields: []string{"containerPort", "protocol"}},
+			{Path: "env", Fields: []string{"name"}},
+		},
+	}
+	item := func(src string) *yaml.Node {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		return doc.Content[0]
+	}
+
+	tests := []struct {
+		path DiffPath
+		item string
+		want any
+	}{
+		{DiffPath{"ports"}, "{containerPort: 80, protocol: TCP, name: http}", "80,TCP"},
+		{DiffPath{"ports"}, "{containerPort: 80}", "80"},
+		{DiffPath{"ports"}, "{protocol: UDP}", ",UDP"},
+		{DiffPath{"ports"}, `{containerPort: "x,y", protocol: z}`, `x\,y,z`},
+		{DiffPath{"ports"}, `{containerPort: x, protocol: "y,z"}`, `x,y\,z`},
+		{DiffPath{"ports"}, `{containerPort: 'x\', protocol: z}`, `x\\,z`},
+		{DiffPath{"ports"}, "{name: http}", nil},
+		{DiffPath{"ports"}, "{containerPort: {nested: 1}, protocol: TCP}", nil},
+		{DiffPath{"env"}, "{name: A, key: k}", "A"},
+		{DiffPath{"other"}, "{name: A, key: k}", "k"},
+		{DiffPath{"ports"}, "[80, TCP]", nil},
+	}
+	for _, tt := range tests {
+		ids := identityAt(tt.path, opts)
+		n := item(tt.item)
+		if got := ids.of(n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("identityAt(%v).of(%s) = %#v, want %#v", tt.path, tt.item, got, tt.want)
+		}
+		if got := ids.ofValue(nodeToInterface(n)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("identityAt(%v).ofValue(%s) = %#v, want %#v", tt.path, tt.item, got, tt.want)
+		}
+	}
+}
+
+func TestCompare_ListKeys(t *testing.T) {
+	from := `spec:
+  containers:
+    - name: app
+      ports:
+        - {containerPort: 80, protocol: TCP, name: http}
+        - {containerPort: 80, protocol: UDP, name: dns}
+  tolerations:
+    - {key: node, effect: NoSchedule, operator: Exists}
+    - {key: node, effect: NoExecute, operator: Exists}
+`
+	to := `spec:
+  containers:
+    - name: app
+      ports:
+        - {containerPort: 80, protocol: UDP, name: dns-udp}
+        - {containerPort: 80, protocol: TCP, name: http}
+  tolerations:
+    - {key: node, effect: NoExecute, operator: Equal}
+    - {key: node, effect: NoSchedule, operator: Exists}
+`
+	opts := &Options{
+		IgnoreOrderChanges: true,
+		ListKeys: []ListKey{
+			{Path: "containers.*.ports", Fields: []string{"containerPort", "protocol"}},
+			{Path: "tolerations", Fields: []string{"key", "effect"}},
+		},
+	}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path.String())
+	}
+	want := []string{
+		"spec.containers.app.ports.80,UDP.name",
+		"spec.tolerations.node,NoExecute.operator",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+
+	// Without the keys, ports and tolerations fall back to positional
+	// matching and report spurious changes.
+	diffs, err = Compare([]byte(from), []byte(to), &Options{IgnoreOrderChanges: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(diffs) <= len(want) {
+		t.Errorf("expected more differences without list keys, got %d", len(diffs))
+	}
+}
+
+func TestCompare_ListKeysSeparatorInValues(t *testing.T) {
+	from := "items:\n  - {a: \"x,y\", b: z}\n"
+	to := "items:\n  - {a: x, b: \"y,z\"}\n"
+	opts := &Options{ListKeys: []ListKey{{Path: "items", Fields: []string{"a", "b"}}}}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []DiffType
+	for _, d := range diffs {
+		got = append(got, d.Type)
+	}
+	if !reflect.DeepEqual(got, []DiffType{DiffRemoved, DiffAdded}) {
+		t.Errorf("diffs = %+v, want the item removed and another added", diffs)
+	}
+}
+
+func TestCompare_ListKeysUnchanged(t *testing.T) {
+	from := "ports:\n  - {containerPort: 80, protocol: TCP}\n  - {containerPort: 53, protocol: UDP}\n"
+	to := "ports:\n  - {containerPort: 53, protocol: UDP}\n  - {containerPort: 80, protocol: TCP}\n"
+	opts := &Options{
+		IgnoreOrderChanges: true,
+		Unchanged:          true,
+		ListKeys:           []ListKey{{Path: "ports", Fields: []string{"containerPort", "protocol"}}},
+	}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path.String())
+	}
+	if strings.Join(got, " ") != "ports.80,TCP ports.53,UDP" {
+		t.Errorf("unchanged paths = %v", got)
+	}
+}
+
+func TestFilterAndMask_ListKeyAliases(t *testing.T) {
+	from := "spec:\n  containers:\n    - name: app\n      env:\n        - {name: TOKEN, value: a}\n"
+	to := "spec: {}\n"
+	keys := []ListKey{{Path: "containers.*.env", Fields: []string{"name"}}}
+	diffs, err := Compare([]byte(from), []byte(to), &Options{ListKeys: keys})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+
+	filtered := FilterDiffs(diffs, &FilterOptions{IncludePaths: []string{"spec.containers.app.env.TOKEN.value"}, ListKeys: keys})
+	if len(filtered) != 1 {
+		t.Errorf("filter by list key alias kept %d diffs, want 1", len(filtered))
+	}
+
+	masked, err := MaskDifferences(diffs, MaskOptions{MaskPaths: []string{"spec.containers.app.env.TOKEN.value"}, ListKeys: keys})
+	if err != nil {
+		t.Fatalf("MaskDifferences failed: %v", err)
+	}
+	containers := masked[0].From.(*OrderedMap).Values["containers"].([]any)
+	env := containers[0].(*OrderedMap).Values["env"].([]any)
+	if v := env[0].(*OrderedMap).Values["value"]; v != DefaultMaskPlaceholder {
+		t.Errorf("masked value = %v, want %q", v, DefaultMaskPlaceholder)
+	}
+}
+
+func TestApplyPatch_ListKeys(t *testing.T) {
+	source := "tolerations:\n  - key: node\n    effect: NoSchedule\n    operator: Exists\n  - key: node\n    effect: NoExecute\n    operator: Exists\n"
+	patch := `[{"op": "replace", "path": "/tolerations/node,NoExecute/operator", "value": "Equal"}]`
+	opts := &Options{ListKeys: []ListKey{{Path: "tolerations", Fields: []string{"key", "effect"}}}}
+	got, err := ApplyPatch([]byte(source), []byte(patch), opts)
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	want := strings.Replace(source, "NoExecute\n    operator: Exists", "NoExecute\n    operator: Equal", 1)
+	if string(got) != want {
+		t.Errorf("ApplyPatch =\n%s\nwant\n%s", got, want)
+	}
+}
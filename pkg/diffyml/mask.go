@@ -4,7 +4,8 @@
 // formatter (including JSON, JSON-Patch, and the AI summarizer). When MaskSecrets
 // is enabled, values under "data" and "stringData" of Kubernetes Secret resources
 // are auto-masked. Users can declare additional paths via MaskPaths and
-// MaskPathRegexp.
+// MaskPathRegexp. PEM private keys are replaced by their summary (see
+// SummarizePEM) whatever the options.
 //
 // Masking runs after Compare and before filtering, so a redacted diff still
 // appears in the report — only the value is replaced with the placeholder.
@@ -12,7 +13,10 @@ package diffyml
 
 import (
 	"maps"
+	"reflect"
 	"regexp"
+
+	"go.yaml.in/yaml/v3"
 )
 
 // DefaultMaskPlaceholder is the value substituted into masked diffs when
@@ -38,15 +42,30 @@ type MaskOptions struct {
 	// AdditionalIdentifiers supplies non-default identifier fields used when
 	// matching paths inside collapsed list values.
 	AdditionalIdentifiers []string
+	// ListKeys supplies per-path list identifiers, as in Options.ListKeys.
+	ListKeys []ListKey
+	// Schema supplies schema-declared list keys, as in Options.Schema.
+	Schema *Schema
 }
 
 // MaskDifferences redacts sensitive values in the given diffs in-place and
 // returns the same slice. Order-change diffs ([DiffOrderChanged]) are never
-// masked (their values are identifier lists, not secrets).
+// masked (their values are identifier lists, not secrets). PEM private keys,
+// as text or base64-encoded, are always replaced by their one-line summary
+// (see [SummarizePEM]), even when opts masks nothing else.
 //
 // Returns an error only if a regex pattern in opts.MaskPathRegexp fails to
 // compile.
 func MaskDifferences(diffs []Difference, opts MaskOptions) ([]Difference, error) {
+	for i := range diffs {
+		if diffs[i].Type != DiffOrderChanged {
+			diffs[i].From, _ = maskPrivateKeys(diffs[i].From)
+			diffs[i].To, _ = maskPrivateKeys(diffs[i].To)
+		}
+		if w := diffs[i].within; w != nil {
+			w.to, _ = maskPrivateKeyText(w.to)
+		}
+	}
 	if !opts.MaskSecrets && len(opts.MaskPaths) == 0 && len(opts.MaskPathRegexp) == 0 {
 		return diffs, nil
 	}
@@ -61,14 +80,17 @@ func MaskDifferences(diffs []Difference, opts MaskOptions) ([]Difference, error)
 		return nil, err
 	}
 
+	ids := identifierOptions(opts.AdditionalIdentifiers, opts.ListKeys, opts.Schema)
 	for i := range diffs {
 		if diffs[i].Type == DiffOrderChanged {
 			continue
 		}
 
+		from, to := diffs[i].From, diffs[i].To
 		bases := maskPathAliases(diffs[i].Path)
-		diffs[i].From = maskValueAtPaths(diffs[i].From, bases, opts, regex, placeholder)
-		diffs[i].To = maskValueAtPaths(diffs[i].To, bases, opts, regex, placeholder)
+		docIDs := ids.forResource("", diffs[i].DocumentKind)
+		diffs[i].From = maskValueAtPaths(diffs[i].From, bases, opts, docIDs, regex, placeholder)
+		diffs[i].To = maskValueAtPaths(diffs[i].To, bases, opts, docIDs, regex, placeholder)
 
 		switch secretMaskScopeFor(diffs[i], opts) {
 		case maskScopeAll:
@@ -78,6 +100,15 @@ func MaskDifferences(diffs []Difference, opts MaskOptions) ([]Difference, error)
 			diffs[i].From = maskSecretSubtrees(diffs[i].From, placeholder)
 			diffs[i].To = maskSecretSubtrees(diffs[i].To, placeholder)
 		}
+
+		// The string a masked difference lies within holds the masked value
+		// too; it is shared by every difference inside it, so all of them
+		// lose it.
+		if w := diffs[i].within; w != nil && (!reflect.DeepEqual(from, diffs[i].From) || !reflect.DeepEqual(to, diffs[i].To) ||
+			secretMaskScopeFor(Difference{Path: w.path, DocumentKind: diffs[i].DocumentKind}, opts) == maskScopeAll ||
+			anyAliasMatches(maskPathAliases(w.path), opts.MaskPaths, regex)) {
+			w.to = placeholder
+		}
 	}
 	return diffs, nil
 }
@@ -122,7 +153,8 @@ func maskPathAliases(path DiffPath) []DiffPath {
 // diff. aliases contains every supported spelling of the current value's path
 // (numeric and identifier list segments); a match at the current path masks the
 // entire subtree, while a more-specific rule is found by descending further.
-func maskValueAtPaths(value any, aliases []DiffPath, opts MaskOptions, regex []*regexp.Regexp, placeholder string) any {
+// ids carries the list identifier settings for identifier segments.
+func maskValueAtPaths(value any, aliases []DiffPath, opts MaskOptions, ids *Options, regex []*regexp.Regexp, placeholder string) any {
 	if anyAliasMatches(aliases, opts.MaskPaths, regex) {
 		return maskValueRecursive(value, placeholder)
 	}
@@ -135,21 +167,21 @@ func maskValueAtPaths(value any, aliases []DiffPath, opts MaskOptions, regex []*
 		}
 		for _, key := range val.Keys {
 			childAliases := mappingValuePathAliases(aliases, key)
-			out.Values[key] = maskValueAtPaths(val.Values[key], childAliases, opts, regex, placeholder)
+			out.Values[key] = maskValueAtPaths(val.Values[key], childAliases, opts, ids, regex, placeholder)
 		}
 		return out
 	case map[string]any:
 		out := make(map[string]any, len(val))
 		for key, child := range val {
 			childAliases := mappingValuePathAliases(aliases, key)
-			out[key] = maskValueAtPaths(child, childAliases, opts, regex, placeholder)
+			out[key] = maskValueAtPaths(child, childAliases, opts, ids, regex, placeholder)
 		}
 		return out
 	case []any:
 		out := make([]any, len(val))
 		for i, child := range val {
-			childAliases := sequenceValuePathAliases(aliases, child, i, opts.AdditionalIdentifiers)
-			out[i] = maskValueAtPaths(child, childAliases, opts, regex, placeholder)
+			childAliases := sequenceValuePathAliases(aliases, child, i, ids)
+			out[i] = maskValueAtPaths(child, childAliases, opts, ids, regex, placeholder)
 		}
 		return out
 	default:
@@ -228,6 +260,66 @@ func maskSecretSubtrees(v any, placeholder string) any {
 	}
 }
 
+// maskedScalarNodes returns the scalar nodes of a parsed document whose values
+// masking redacts, so formatters that print source text rather than
+// difference values hide the same values MaskDifferences does. Paths match
+// like MaskPaths on differences, numeric and identifier list segments alike;
+// with MaskSecrets, the data and stringData of a Secret are redacted. A
+// scalar holding a PEM private key is redacted whatever the options.
+func maskedScalarNodes(doc *yaml.Node, opts MaskOptions) (map[*yaml.Node]bool, error) {
+	hasPaths := len(opts.MaskPaths) > 0 || len(opts.MaskPathRegexp) > 0
+	regex, err := compileRegexPatterns(opts.MaskPathRegexp)
+	if err != nil {
+		return nil, err
+	}
+	if doc != nil && doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	secret := false
+	if opts.MaskSecrets && doc != nil && doc.Kind == yaml.MappingNode {
+		if kind := lookupMappingValueNode(doc, "kind"); kind != nil {
+			secret = kind.Value == "Secret"
+		}
+	}
+
+	ids := identifierOptions(opts.AdditionalIdentifiers, opts.ListKeys, opts.Schema).forResource(k8sNodeResource(doc))
+	masked := make(map[*yaml.Node]bool)
+	var walk func(n *yaml.Node, aliases []DiffPath, root, redact bool)
+	walk = func(n *yaml.Node, aliases []DiffPath, root, redact bool) {
+		if n == nil {
+			return
+		}
+		if !redact && hasPaths && anyAliasMatches(aliases, opts.MaskPaths, regex) {
+			redact = true
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i].Value
+				var child []DiffPath
+				if hasPaths {
+					child = mappingValuePathAliases(aliases, key)
+				}
+				walk(n.Content[i+1], child, false, redact || (root && secret && secretMaskedKeys[key]))
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				var child []DiffPath
+				if hasPaths {
+					child = sequenceValuePathAliases(aliases, nodeToInterface(item), i, ids)
+				}
+				walk(item, child, false, redact)
+			}
+		case yaml.ScalarNode:
+			if redact || holdsPrivateKey(n.Value) {
+				masked[n] = true
+			}
+		}
+	}
+	walk(doc, []DiffPath{{}}, true, false)
+	return masked, nil
+}
+
 // pathWithoutDocIndex renders the diff path without a leading document-index
 // segment like "[0]". The result is what users specify with --mask-path.
 func pathWithoutDocIndex(p DiffPath) string {
@@ -1,7 +1,11 @@
 package diffyml
 
 import (
+	"sort"
+	"strings"
 	"testing"
+
+	"go.yaml.in/yaml/v3"
 )
 
 func TestMaskDifferences_NoOptions_ReturnsUnchanged(t *testing.T) {
@@ -433,3 +437,47 @@ func TestIsWholeDocDiff(t *testing.T) {
 		}
 	}
 }
+
+func TestMaskedScalarNodes(t *testing.T) {
+	src := "kind: Secret\nmetadata:\n  name: s\ndata:\n  a: x\n  b: y\nitems:\n  - name: web\n    token: t1\n  - name: db\n    token: t2\n"
+	docs, err := parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := func(masked map[*yaml.Node]bool) []string {
+		var out []string
+		for n := range masked {
+			out = append(out, n.Value)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	tests := []struct {
+		name string
+		opts MaskOptions
+		want []string
+	}{
+		{"nothing to mask", MaskOptions{}, nil},
+		{"secret data", MaskOptions{MaskSecrets: true}, []string{"x", "y"}},
+		{"path prefix", MaskOptions{MaskPaths: []string{"metadata"}}, []string{"s"}},
+		{"identifier list segment", MaskOptions{MaskPaths: []string{"items.web.token"}}, []string{"t1"}},
+		{"numeric list segment", MaskOptions{MaskPaths: []string{"items.1.token"}}, []string{"t2"}},
+		{"regex", MaskOptions{MaskPathRegexp: []string{`token$`}}, []string{"t1", "t2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, err := maskedScalarNodes(docs[0], tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values(masked); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("masked %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := maskedScalarNodes(docs[0], MaskOptions{MaskPathRegexp: []string{"("}}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
@@ -0,0 +1,602 @@
+// merge.go - Three-way structural merge of YAML files.
+//
+// Merges two descendants of a common base by structure rather than by text.
+// Each value slot (mapping entry, identified list item, document) is resolved
+// on its own: a side that left the slot as it was in base takes the other
+// side's version, and two identical changes agree. Changes are detected with
+// the comparator, and documents and list items are paired the way the
+// comparator pairs them — Kubernetes resources by identity, list items by
+// name/id/AdditionalIdentifiers — so two people adding different containers
+// or editing different keys merge cleanly. Items of lists without
+// identifiers are aligned with base and merged one by one, so edits to
+// different items combine too; entries both sides add at the same place land
+// ours first, as in git. Only a slot both sides changed in different ways is
+// a conflict; it is written between git-style conflict markers and reported
+// in MergeResult.Conflicts.
+//
+// The merged YAML is re-serialized from ours: comments attached to kept
+// nodes survive, but formatting is normalized and merge keys (<<) are
+// expanded, as they are for comparison.
+// Key types: MergeResult, MergeConflict.
+// Key functions: Merge().
+package diffyml
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Conflict markers written around the two versions of a conflicted slot.
+const (
+	MergeMarkerOurs   = "<<<<<<< ours"
+	MergeMarkerSep    = "======="
+	MergeMarkerTheirs = ">>>>>>> theirs"
+)
+
+// MergeConflict is a value both sides changed in different ways.
+type MergeConflict struct {
+	// Path locates the value, in the form Difference.Path uses.
+	Path DiffPath
+	// Ours and Theirs say how each side changed the value relative to base:
+	// DiffAdded, DiffRemoved or DiffModified.
+	Ours, Theirs DiffType
+	// Base, OursValue and TheirsValue are the three versions; nil where the
+	// value is absent.
+	Base, OursValue, TheirsValue any
+	// DocumentName is the Kubernetes resource name, when known.
+	DocumentName string
+}
+
+// MergeResult is the outcome of Merge.
+type MergeResult struct {
+	// Merged is the merged YAML. Each conflict appears in it as both
+	// versions between conflict markers, so it only parses when Conflicts
+	// is empty.
+	Merged []byte
+	// Conflicts lists the values both sides changed differently.
+	Conflicts []MergeConflict
+}
+
+// mergeEntry is one slot of a keyed collection: a mapping entry, an
+// identified list item, or a document.
+type mergeEntry struct {
+	id  string     // mapping key, list item identifier, or document key
+	seg string     // path segment for conflicts
+	key *yaml.Node // mapping key node; nil for list items and documents
+	val *yaml.Node
+}
+
+// mergeConflictNode is a pending conflict. Its placeholder stands in the
+// merged tree until serialization replaces the placeholder's line with both
+// versions.
+type mergeConflictNode struct {
+	token        string
+	key          *yaml.Node // mapping key of the slot; nil for items and documents
+	item         bool       // the slot is a list item
+	ours, theirs *yaml.Node
+}
+
+// merger holds the state of one Merge call.
+type merger struct {
+	opts          *Options
+	docName       string
+	docAPIVersion string // Kubernetes resource of the document, for its list keys
+	docKind       string
+	conflicts     []MergeConflict
+	pending       []mergeConflictNode
+}
+
+// Merge merges ours and theirs, two edited versions of base. Conflicts do not
+// make it fail: they are marked in MergeResult.Merged and listed in
+// MergeResult.Conflicts. Errors are reserved for inputs that do not parse.
+// opts selects document and list-item matching (DetectKubernetes,
+// IgnoreApiVersion, AdditionalIdentifiers, ListKeys) and may be nil.
+func Merge(base, ours, theirs []byte, opts *Options) (*MergeResult, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	var docs [3][]*yaml.Node
+	for i, src := range [][]byte{base, ours, theirs} {
+		nodes, err := parseNodes(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", [...]string{"base", "ours", "theirs"}[i], err)
+		}
+		docs[i] = nodes
+	}
+
+	m := &merger{opts: opts}
+	merged := m.mergeDocuments(docs[0], docs[1], docs[2])
+	out, err := m.serialize(merged, detectIndent(ours))
+	if err != nil {
+		return nil, err
+	}
+	return &MergeResult{Merged: out, Conflicts: m.conflicts}, nil
+}
+
+// mergeDocuments pairs documents across the three files — by Kubernetes
+// identity when the files hold resources, by position otherwise — and merges
+// each pair.
+func (m *merger) mergeDocuments(base, ours, theirs []*yaml.Node) []*yaml.Node {
+	k8s := false
+	if m.opts.DetectKubernetes {
+		for _, side := range [][]*yaml.Node{base, ours, theirs} {
+			for _, d := range materializeK8sDocs(side) {
+				k8s = k8s || IsKubernetesResource(d)
+			}
+		}
+	}
+	multi := max(len(base), len(ours), len(theirs)) > 1
+	entries := func(docs []*yaml.Node) []mergeEntry {
+		var out []mergeEntry
+		for i, doc := range docs {
+			root := resolveNode(doc)
+			if root == nil {
+				continue
+			}
+			e := mergeEntry{id: "#" + strconv.Itoa(i), val: root}
+			if multi {
+				e.seg = "[" + strconv.Itoa(i) + "]"
+			}
+			if k8s {
+				if id := K8sResourceIdentifier(nodeToInterface(root), m.opts.IgnoreApiVersion); id != "" {
+					e.id = id
+				}
+			}
+			out = append(out, e)
+		}
+		return out
+	}
+
+	var roots []*yaml.Node
+	for _, e := range m.mergeEntries(nil, true, entries(base), entries(ours), entries(theirs)) {
+		roots = append(roots, e.val)
+	}
+	return roots
+}
+
+// mergeValue merges one slot. A nil node is an absent slot, and a nil result
+// removes the slot.
+func (m *merger) mergeValue(path DiffPath, key *yaml.Node, item bool, base, ours, theirs *yaml.Node) *yaml.Node {
+	oursChanged := !m.same(base, ours)
+	theirsChanged := !m.same(base, theirs)
+	switch {
+	case !theirsChanged:
+		return ours
+	case !oursChanged:
+		return detachNode(theirs)
+	case m.same(ours, theirs):
+		return ours
+	}
+
+	b, o, t := resolveNode(base), resolveNode(ours), resolveNode(theirs)
+	if o != nil && t != nil && o.Kind == t.Kind && (b == nil || b.Kind == o.Kind) {
+		switch o.Kind {
+		case yaml.MappingNode:
+			return m.mergeMapping(path, b, o, t)
+		case yaml.SequenceNode:
+			if merged := m.mergeSequence(path, b, o, t); merged != nil {
+				return merged
+			}
+			if b != nil {
+				return m.mergeSequenceByPosition(path, b, o, t)
+			}
+		}
+	}
+	return m.conflict(path, key, item, base, ours, theirs)
+}
+
+// same reports whether two slots hold equal values; both absent counts as
+// equal, one absent does not.
+func (m *merger) same(a, b *yaml.Node) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	return a == nil || len(compareNodes(nil, a, b, m.opts)) == 0
+}
+
+// mergeMapping merges two edited versions of a mapping entry by entry.
+func (m *merger) mergeMapping(path DiffPath, base, ours, theirs *yaml.Node) *yaml.Node {
+	entries := func(n *yaml.Node) []mergeEntry {
+		if n == nil {
+			return nil
+		}
+		var out []mergeEntry
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			out = append(out, mergeEntry{id: k, seg: k, key: n.Content[i], val: n.Content[i+1]})
+		}
+		return out
+	}
+	merged := *ours
+	merged.Content = nil
+	for _, e := range m.mergeEntries(path, false, entries(base), entries(ours), entries(theirs)) {
+		merged.Content = append(merged.Content, e.key, e.val)
+	}
+	m.unflowIfConflicted(&merged)
+	return &merged
+}
+
+// mergeSequence merges two edited versions of a list whose items all carry
+// unique identifiers. It returns nil for lists that cannot be matched item by
+// item, leaving the caller to merge them by position.
+func (m *merger) mergeSequence(path DiffPath, base, ours, theirs *yaml.Node) *yaml.Node {
+	var sides [3][]mergeEntry
+	ids := identityAt(path, m.opts.forResource(m.docAPIVersion, m.docKind))
+	for i, n := range []*yaml.Node{base, ours, theirs} {
+		if n == nil {
+			continue
+		}
+		if len(n.Content) > 0 && !ids.canMatch(n.Content) {
+			return nil
+		}
+		seen := make(map[string]bool)
+		for _, item := range n.Content {
+			id := ids.of(item)
+			if !isComparableIdentifier(id) {
+				return nil
+			}
+			seg := sprintIdentifier(id)
+			if seen[seg] {
+				return nil
+			}
+			seen[seg] = true
+			sides[i] = append(sides[i], mergeEntry{id: seg, seg: seg, val: item})
+		}
+	}
+	merged := *ours
+	merged.Content = nil
+	for _, e := range m.mergeEntries(path, false, sides[0], sides[1], sides[2]) {
+		merged.Content = append(merged.Content, e.val)
+	}
+	m.unflowIfConflicted(&merged)
+	return &merged
+}
+
+// mergeSequenceByPosition merges two edited versions of a list whose items
+// cannot be matched by identifier. Each side is aligned with base as the
+// comparator aligns unidentified lists; a base item is then merged on its
+// own, so edits to different items merge cleanly and only an item both sides
+// changed differently is a conflict. Items both sides inserted at the same
+// place are kept once when equal, ours before theirs otherwise, as git orders
+// them.
+func (m *merger) mergeSequenceByPosition(path DiffPath, base, ours, theirs *yaml.Node) *yaml.Node {
+	oursItems, oursInserted := alignWithBase(base, ours)
+	theirsItems, theirsInserted := alignWithBase(base, theirs)
+	merged := *ours
+	merged.Content = nil
+	for i := 0; i <= len(base.Content); i++ {
+		merged.Content = append(merged.Content, oursInserted[i]...)
+		if !m.sameItems(oursInserted[i], theirsInserted[i]) {
+			for _, item := range theirsInserted[i] {
+				merged.Content = append(merged.Content, detachNode(item))
+			}
+		}
+		if i == len(base.Content) {
+			break
+		}
+		if item := m.mergeValue(path.Append(strconv.Itoa(i)), nil, true, base.Content[i], oursItems[i], theirsItems[i]); item != nil {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+	m.unflowIfConflicted(&merged)
+	return &merged
+}
+
+// alignWithBase aligns side with base: items[i] is the side's version of base
+// item i, nil when the side removed it, and inserted[i] the items the side
+// inserted before base item i, or at the end for i == len(base.Content).
+func alignWithBase(base, side *yaml.Node) (items []*yaml.Node, inserted [][]*yaml.Node) {
+	items = make([]*yaml.Node, len(base.Content))
+	inserted = make([][]*yaml.Node, len(base.Content)+1)
+	next := 0 // the base item an insertion precedes
+	for _, p := range alignSequence(base.Content, side.Content) {
+		switch {
+		case p.from < 0:
+			inserted[next] = append(inserted[next], side.Content[p.to])
+		case p.to < 0:
+			next = p.from + 1
+		default:
+			items[p.from] = side.Content[p.to]
+			next = p.from + 1
+		}
+	}
+	return items, inserted
+}
+
+// sameItems reports whether two runs of items are equal item by item.
+func (m *merger) sameItems(a, b []*yaml.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !m.same(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// unflowIfConflicted switches a flow collection holding a conflict
+// placeholder to block style, so the placeholder sits on a line of its own.
+func (m *merger) unflowIfConflicted(n *yaml.Node) {
+	if n.Style&yaml.FlowStyle == 0 {
+		return
+	}
+	for _, c := range n.Content {
+		if m.isPlaceholder(c) || (c.Kind == yaml.MappingNode || c.Kind == yaml.SequenceNode) && c.Style&yaml.FlowStyle == 0 {
+			n.Style &^= yaml.FlowStyle
+			return
+		}
+	}
+}
+
+// isPlaceholder reports whether n stands in for a conflict.
+func (m *merger) isPlaceholder(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && strings.HasPrefix(n.Value, mergeTokenPrefix)
+}
+
+// mergeEntries merges keyed slots; docs marks the documents of the files.
+// The result follows ours' order, or theirs' when only theirs reordered the
+// slots base and ours share; entries only the other side added are placed
+// after the entry they follow there, and after any ours added at the same
+// place, as git orders them.
+func (m *merger) mergeEntries(path DiffPath, docs bool, base, ours, theirs []mergeEntry) []mergeEntry {
+	index := func(entries []mergeEntry) map[string]int {
+		idx := make(map[string]int, len(entries))
+		for i, e := range entries {
+			idx[e.id] = i
+		}
+		return idx
+	}
+	baseIdx, oursIdx, theirsIdx := index(base), index(ours), index(theirs)
+	lookup := func(entries []mergeEntry, idx map[string]int, id string) *yaml.Node {
+		if i, ok := idx[id]; ok {
+			return entries[i].val
+		}
+		return nil
+	}
+
+	primary, secondary := ours, theirs
+	oursFirst := true
+	if sameOrder(base, ours, oursIdx) && !sameOrder(base, theirs, theirsIdx) {
+		primary, secondary = theirs, ours
+		oursFirst = false
+	}
+	addedByOurs := func(id string) bool {
+		_, inBase := baseIdx[id]
+		_, inTheirs := theirsIdx[id]
+		return !inBase && !inTheirs
+	}
+	primaryIdx := index(primary)
+
+	var out []mergeEntry
+	resolve := func(e mergeEntry) (mergeEntry, bool) {
+		b := lookup(base, baseIdx, e.id)
+		o := lookup(ours, oursIdx, e.id)
+		t := lookup(theirs, theirsIdx, e.id)
+		key := e.key
+		if i, ok := oursIdx[e.id]; ok && ours[i].key != nil {
+			key = ours[i].key
+		}
+		childPath := path
+		if e.seg != "" {
+			// A lone document has no path segment of its own.
+			childPath = path.Append(e.seg)
+		}
+		if docs {
+			m.docName, m.docAPIVersion, m.docKind = "", "", ""
+			if named := cmp.Or(o, t); named != nil {
+				doc := nodeToInterface(named)
+				m.docName = K8sResourceDisplayName(doc)
+				if f, ok := k8sExtractFields(doc); ok && m.opts.DetectKubernetes {
+					m.docAPIVersion, m.docKind = f.apiVersion, f.kind
+				}
+			}
+		}
+		merged := m.mergeValue(childPath, key, key == nil && !docs, b, o, t)
+		if merged == nil {
+			return mergeEntry{}, false
+		}
+		if key != nil && lookup(ours, oursIdx, e.id) == nil {
+			key = detachNode(key)
+		}
+		return mergeEntry{id: e.id, seg: e.seg, key: key, val: merged}, true
+	}
+	for _, e := range primary {
+		if r, ok := resolve(e); ok {
+			out = append(out, r)
+		}
+	}
+	pos := 0
+	for _, e := range secondary {
+		if _, ok := primaryIdx[e.id]; ok {
+			// Later additions follow this entry.
+			for i, r := range out {
+				if r.id == e.id {
+					pos = i + 1
+				}
+			}
+			continue
+		}
+		for oursFirst && pos < len(out) && addedByOurs(out[pos].id) {
+			pos++
+		}
+		if r, ok := resolve(e); ok {
+			out = slices.Insert(out, pos, r)
+			pos++
+		}
+	}
+	return out
+}
+
+// sameOrder reports whether the entries side shares with base appear in
+// base's order.
+func sameOrder(base, side []mergeEntry, sideIdx map[string]int) bool {
+	last := -1
+	for _, e := range base {
+		i, ok := sideIdx[e.id]
+		if !ok {
+			continue
+		}
+		if i < last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
+
+// mergeTokenPrefix starts every conflict placeholder.
+const mergeTokenPrefix = "__diffyml_merge_conflict_"
+
+// conflict records a slot both sides changed and returns its placeholder.
+func (m *merger) conflict(path DiffPath, key *yaml.Node, item bool, base, ours, theirs *yaml.Node) *yaml.Node {
+	change := func(side *yaml.Node) DiffType {
+		switch {
+		case base == nil:
+			return DiffAdded
+		case side == nil:
+			return DiffRemoved
+		}
+		return DiffModified
+	}
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:         path,
+		Ours:         change(ours),
+		Theirs:       change(theirs),
+		Base:         nodeToInterface(base),
+		OursValue:    nodeToInterface(ours),
+		TheirsValue:  nodeToInterface(theirs),
+		DocumentName: m.docName,
+	})
+	token := mergeTokenPrefix + strconv.Itoa(len(m.pending)) + "__"
+	m.pending = append(m.pending, mergeConflictNode{token: token, key: key, item: item, ours: detachNode(ours), theirs: detachNode(theirs)})
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token}
+}
+
+// detachNode deep-copies n with aliases expanded, so a subtree taken from
+// one file cannot refer to an anchor that only exists in another.
+func detachNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	n = resolveAlias(n)
+	if n == nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	c := *n
+	c.Anchor = ""
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = detachNode(child)
+	}
+	return &c
+}
+
+// serialize encodes the merged documents with the given indentation, then
+// replaces each conflict placeholder with both versions between markers.
+func (m *merger) serialize(roots []*yaml.Node, indent int) ([]byte, error) {
+	encode := func(roots []*yaml.Node) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(indent)
+		for _, root := range roots {
+			if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+				return nil, fmt.Errorf("encoding merged YAML: %w", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return nil, fmt.Errorf("encoding merged YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	out, err := encode(roots)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseNodes(out); err != nil {
+		// An alias outlived the entry holding its anchor; expand them all.
+		for i, root := range roots {
+			roots[i] = detachNode(root)
+		}
+		if out, err = encode(roots); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.pending) == 0 {
+		return out, nil
+	}
+
+	byToken := make(map[string]mergeConflictNode, len(m.pending))
+	for _, c := range m.pending {
+		byToken[c.token] = c
+	}
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(string(out), "\n") {
+		start := strings.Index(line, mergeTokenPrefix)
+		if start < 0 {
+			sb.WriteString(line)
+			continue
+		}
+		token := line[start:]
+		token = token[:strings.Index(token[len(mergeTokenPrefix):], "__")+len(mergeTokenPrefix)+2]
+		c := byToken[token]
+		pad := strings.Repeat(" ", len(line)-len(strings.TrimLeft(line, " ")))
+		sb.WriteString(MergeMarkerOurs + "\n")
+		sb.WriteString(conflictSideYAML(c, c.ours, pad, indent))
+		sb.WriteString(MergeMarkerSep + "\n")
+		sb.WriteString(conflictSideYAML(c, c.theirs, pad, indent))
+		sb.WriteString(MergeMarkerTheirs + "\n")
+	}
+	return []byte(sb.String()), nil
+}
+
+// conflictSideYAML serializes one version of a conflicted slot in the shape
+// the slot has in its collection, indented by pad. An absent version is
+// empty.
+func conflictSideYAML(c mergeConflictNode, n *yaml.Node, pad string, indent int) string {
+	if n == nil {
+		return ""
+	}
+	wrapped := n
+	switch {
+	case c.key != nil:
+		wrapped = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{c.key, n}}
+	case c.item:
+		wrapped = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{n}}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	_ = enc.Encode(wrapped)
+	_ = enc.Close()
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		sb.WriteString(pad + strings.TrimSuffix(line, "\n") + "\n")
+	}
+	return sb.String()
+}
+
+// detectIndent returns the indentation step of src: the first increase in
+// indentation below a line ending in a colon. It falls back to two spaces.
+func detectIndent(src []byte) int {
+	prevIndent, prevOpens := 0, false
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if prevOpens && indent > prevIndent {
+			return min(max(indent-prevIndent, 2), 9)
+		}
+		prevIndent, prevOpens = indent, strings.HasSuffix(trimmed, ":")
+	}
+	return 2
+}
@@ -0,0 +1,215 @@
+package diffyml
+
+import (
+	"strings"
+	"testing"
+)
+
+func merge(t *testing.T, base, ours, theirs string) *MergeResult {
+	t.Helper()
+	result, err := Merge([]byte(base), []byte(ours), []byte(theirs), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	return result
+}
+
+func TestMerge_NonOverlappingChanges(t *testing.T) {
+	result := merge(t, "a: 1\nb: 2\nc: 3\n", "a: 10\nb: 2\nc: 3\nd: 4\n", "a: 1\nb: 20\n")
+
+	if want := "a: 10\nb: 20\nd: 4\n"; string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_SameChangeOnBothSides(t *testing.T) {
+	result := merge(t, "a: 1\n", "a: 2\n", "a: 2\n")
+	if string(result.Merged) != "a: 2\n" || len(result.Conflicts) != 0 {
+		t.Errorf("got %q with conflicts %+v", result.Merged, result.Conflicts)
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	result := merge(t, "app:\n  replicas: 1\n  port: 80\n", "app:\n  replicas: 3\n  port: 80\n", "app:\n  replicas: 4\n  port: 80\n")
+
+	want := "app:\n" +
+		"<<<<<<< ours\n" +
+		"  replicas: 3\n" +
+		"=======\n" +
+		"  replicas: 4\n" +
+		">>>>>>> theirs\n" +
+		"  port: 80\n"
+	if string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %+v", result.Conflicts)
+	}
+	c := result.Conflicts[0]
+	if c.Path.String() != "app.replicas" || c.Ours != DiffModified || c.Theirs != DiffModified || c.OursValue != 3 || c.TheirsValue != 4 {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+}
+
+func TestMerge_RemovedVersusModified(t *testing.T) {
+	result := merge(t, "a: 1\nb: 2\n", "b: 2\n", "a: 5\nb: 2\n")
+
+	want := "<<<<<<< ours\n=======\na: 5\n>>>>>>> theirs\nb: 2\n"
+	if string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Ours != DiffRemoved || result.Conflicts[0].Theirs != DiffModified {
+		t.Errorf("unexpected conflicts %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_IdentifiedListItems(t *testing.T) {
+	base := "env:\n  - name: A\n    value: 1\n"
+	ours := "env:\n  - name: A\n    value: 1\n  - name: B\n"
+	theirs := "env:\n  - name: C\n  - name: A\n    value: 2\n"
+	result := merge(t, base, ours, theirs)
+
+	want := "env:\n  - name: C\n  - name: A\n    value: 2\n  - name: B\n"
+	if string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_PositionalListItems(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+	}{
+		{
+			name: "ours changes an item, theirs appends",
+			base: "l: [a, b]\n", ours: "l: [x, b]\n", theirs: "l: [a, b, c]\n",
+			want: "l: [x, b, c]\n",
+		},
+		{
+			name: "each side changes a different item",
+			base: "l: [a, b, c]\n", ours: "l: [a, b, d]\n", theirs: "l: [a, x, c]\n",
+			want: "l: [a, x, d]\n",
+		},
+		{
+			name: "ours removes an item, theirs changes another",
+			base: "l:\n  - a\n  - b\n  - c\n", ours: "l:\n  - a\n  - c\n", theirs: "l:\n  - a\n  - b\n  - z\n",
+			want: "l:\n  - a\n  - z\n",
+		},
+		{
+			name: "both append, ours first",
+			base: "l: [a]\n", ours: "l: [a, b]\n", theirs: "l: [a, c]\n",
+			want: "l: [a, b, c]\n",
+		},
+		{
+			name: "both append the same item",
+			base: "l: [a]\n", ours: "l: [a, b]\n", theirs: "l: [a, b]\n",
+			want: "l: [a, b]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := merge(t, tt.base, tt.ours, tt.theirs)
+			if string(result.Merged) != tt.want {
+				t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, tt.want)
+			}
+			if len(result.Conflicts) != 0 {
+				t.Errorf("expected no conflicts, got %+v", result.Conflicts)
+			}
+		})
+	}
+}
+
+func TestMerge_PositionalListItemConflict(t *testing.T) {
+	result := merge(t, "l: [a, b, c]\n", "l: [a, d, c]\n", "l: [a, x, c, e]\n")
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path.String() != "l.1" {
+		t.Fatalf("expected one conflict on l.1, got %+v", result.Conflicts)
+	}
+	merged := string(result.Merged)
+	if !strings.Contains(merged, MergeMarkerOurs) || !strings.HasSuffix(merged, "  - c\n  - e\n") {
+		t.Errorf("expected conflict markers and the untouched items:\n%s", merged)
+	}
+}
+
+func TestMerge_OursAdditionsFirst(t *testing.T) {
+	base := "a: 1\nenv:\n  - name: A\n"
+	ours := "a: 1\nb: 2\nenv:\n  - name: A\n  - name: B\n"
+	theirs := "a: 1\nc: 3\nenv:\n  - name: A\n  - name: C\n"
+	result := merge(t, base, ours, theirs)
+
+	want := "a: 1\nb: 2\nc: 3\nenv:\n  - name: A\n  - name: B\n  - name: C\n"
+	if string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_KubernetesDocuments(t *testing.T) {
+	deploy := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 1\n"
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	svc := "apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n"
+
+	// Theirs reorders the documents; they are still matched by identity.
+	result := merge(t,
+		deploy+"---\n"+cm,
+		strings.Replace(deploy, "replicas: 1", "replicas: 2", 1)+"---\n"+cm,
+		strings.Replace(cm, "k: a", "k: b", 1)+"---\n"+deploy+"---\n"+svc,
+	)
+
+	merged := string(result.Merged)
+	for _, want := range []string{"replicas: 2\n", "k: b\n", "name: svc\n"} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged missing %q:\n%s", want, merged)
+		}
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_KubernetesConflictNamesDocument(t *testing.T) {
+	cm := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  k: a\n"
+	result := merge(t, cm, strings.Replace(cm, "k: a", "k: b", 1), strings.Replace(cm, "k: a", "k: c", 1))
+	if len(result.Conflicts) != 1 || result.Conflicts[0].DocumentName != "v1/ConfigMap/cfg" {
+		t.Errorf("unexpected conflicts %+v", result.Conflicts)
+	}
+}
+
+func TestMerge_KeepsOursIndentation(t *testing.T) {
+	result := merge(t, "a:\n    b: 1\n", "a:\n    b: 2\n", "a:\n  b: 1\n  c: 3\n")
+	if want := "a:\n    b: 2\n    c: 3\n"; string(result.Merged) != want {
+		t.Errorf("merged mismatch\ngot:\n%s\nwant:\n%s", result.Merged, want)
+	}
+}
+
+func TestMerge_ParseError(t *testing.T) {
+	_, err := Merge([]byte("a: 1\n"), []byte("a: 1\n"), []byte("a: [\n"), nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "theirs: ") {
+		t.Errorf("expected theirs parse error, got %v", err)
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"a:\n  b: 1\n", 2},
+		{"a:\n    b: 1\n", 4},
+		{"a: 1\n", 2},
+		{"# c:\nx:\n   - 1\n", 3},
+	}
+	for _, tt := range tests {
+		if got := detectIndent([]byte(tt.in)); got != tt.want {
+			t.Errorf("detectIndent(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
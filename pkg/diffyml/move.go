@@ -0,0 +1,94 @@
+// move.go - Moved and renamed subtrees within a document (Options.DetectMoves).
+//
+// Renaming a mapping key, such as Helm values restructured from db to
+// database, or moving a subtree under another parent reports it removed at
+// the old path and added at the new one. detectMoves pairs the removed and
+// added mapping entries of one document whose values are maps or lists by
+// content similarity, scored like document renames (see similarityIndex), and
+// reports each pair as one DiffMoved followed by the differences between the
+// old and the new subtree, at paths below the new one.
+// Key functions: detectMoves, moveCandidate.
+package diffyml
+
+import "go.yaml.in/yaml/v3"
+
+// moveCandidate returns the value node of an added or removed mapping entry
+// when detectMoves should consider it: under DetectMoves, a non-empty map or
+// list. Scalars are left alone; equal scalars are too common to tell a move.
+func moveCandidate(val *yaml.Node, opts *Options) *yaml.Node {
+	if !opts.DetectMoves {
+		return nil
+	}
+	n := resolveNode(val)
+	if n == nil || (n.Kind != yaml.MappingNode && n.Kind != yaml.SequenceNode) || len(n.Content) == 0 {
+		return nil
+	}
+	return val
+}
+
+// detectMoves replaces each removed mapping entry paired with a similar added
+// one by a DiffMoved and the changes inside the moved subtree. diffs are the
+// differences of one document; a document with more than renameLimit
+// candidates on either side is left as is.
+func detectMoves(diffs []Difference, opts *Options) []Difference {
+	if !opts.DetectMoves {
+		return diffs
+	}
+	values := make([]any, len(diffs))
+	var removed, added []int
+	for i, d := range diffs {
+		if d.entry == nil {
+			continue
+		}
+		values[i] = nodeToInterface(d.entry)
+		if d.Type == DiffRemoved {
+			removed = append(removed, i)
+		} else {
+			added = append(added, i)
+		}
+	}
+
+	var matched map[int]int
+	movedTo := make(map[int]bool)
+	if len(removed) > 0 && len(added) > 0 && max(len(removed), len(added)) <= renameLimit {
+		pairs := buildRenamePairs(values, values, removed, added)
+		sortRenamePairs(pairs)
+		matched, _, _ = greedyAssignRenames(pairs)
+		for _, a := range matched {
+			movedTo[a] = true
+		}
+	}
+
+	// A move takes the place of its removal, which the comparator reports
+	// before the additions of the same mapping.
+	out := make([]Difference, 0, len(diffs))
+	for i, d := range diffs {
+		if movedTo[i] {
+			continue
+		}
+		if a, ok := matched[i]; ok {
+			out = append(out, movedDiffs(d, diffs[a], opts)...)
+			continue
+		}
+		d.entry = nil
+		out = append(out, d)
+	}
+	return out
+}
+
+// movedDiffs reports the mapping entry removed as removed and added as added
+// as moved: a DiffMoved at the new path, then the changes inside the subtree.
+func movedDiffs(removed, added Difference, opts *Options) []Difference {
+	oldPath := removed.Path.Append(removed.From.(*OrderedMap).Keys[0])
+	newPath := added.Path.Append(added.To.(*OrderedMap).Keys[0])
+	diffs := []Difference{{
+		Path:     newPath,
+		Type:     DiffMoved,
+		FromPath: oldPath,
+		FromPos:  removed.FromPos,
+		ToPos:    added.ToPos,
+	}}
+	nested := compareComments(newPath, nil, removed.entry, nil, added.entry, opts)
+	nested = append(nested, compareNodes(newPath, removed.entry, added.entry, opts)...)
+	return append(diffs, detectMoves(nested, opts)...)
+}
@@ -0,0 +1,153 @@
+package diffyml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	moveFrom = "replicas: 2\n" +
+		"db:\n  host: db.internal\n  port: 5432\n  user: app\n  pool: {min: 1, max: 10}\n" +
+		"cache:\n  ttl: 60\n  redis:\n    host: redis\n    port: 6379\n    db: 0\n" +
+		"stores:\n  s3: {bucket: assets}\n"
+	moveTo = "replicas: 2\n" +
+		"database:\n  host: db.internal\n  port: 5433\n  user: app\n  pool: {min: 1, max: 10}\n" +
+		"cache:\n  ttl: 60\n" +
+		"stores:\n  s3: {bucket: assets}\n  redis:\n    host: redis\n    port: 6379\n    db: 0\n"
+)
+
+type moveDiff struct {
+	typ      DiffType
+	path     string
+	fromPath string
+}
+
+func moveDiffs(diffs []Difference) []moveDiff {
+	var out []moveDiff
+	for _, d := range diffs {
+		out = append(out, moveDiff{d.Type, d.Path.String(), d.FromPath.String()})
+	}
+	return out
+}
+
+func TestCompare_DetectMoves(t *testing.T) {
+	diffs, err := Compare([]byte(moveFrom), []byte(moveTo), &Options{DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []moveDiff{
+		{DiffMoved, "stores.redis", "cache.redis"},
+		{DiffMoved, "database", "db"},
+		{DiffModified, "database.port", ""},
+	}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d := diffs[1]; d.FromPos == nil || d.FromPos.Line != 2 || d.ToPos == nil || d.ToPos.Line != 2 {
+		t.Errorf("moved positions = %v, %v, want the old and new entries", d.FromPos, d.ToPos)
+	}
+}
+
+func TestCompare_DetectMovesOff(t *testing.T) {
+	diffs, err := Compare([]byte(moveFrom), []byte(moveTo), nil)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, d := range diffs {
+		if d.Type == DiffMoved {
+			t.Errorf("unexpected move at %s without DetectMoves", d.Path)
+		}
+	}
+}
+
+func TestCompare_DetectMovesLeavesDissimilarEntries(t *testing.T) {
+	from := "db:\n  host: a\n  port: 1\n"
+	to := "web:\n  image: nginx\n  replicas: 3\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []moveDiff{{DiffRemoved, "", ""}, {DiffAdded, "", ""}}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompare_DetectMovesInKubernetesDocuments(t *testing.T) {
+	doc := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  %s:\n    a: \"1\"\n    b: \"2\"\n    c: \"3\"\n"
+	from := strings.Replace(doc, "%s", "old", 1)
+	to := strings.Replace(doc, "%s", "new", 1)
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []moveDiff{{DiffMoved, "data.new", "data.old"}}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFilterDiffs_MovedMatchesOldPath(t *testing.T) {
+	diffs, err := Compare([]byte(moveFrom), []byte(moveTo), &Options{DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	got := FilterDiffs(diffs, &FilterOptions{IncludePaths: []string{"db"}})
+	if len(got) != 1 || got[0].Type != DiffMoved {
+		t.Errorf("include db = %v, want the move", moveDiffs(got))
+	}
+}
+
+func TestFormat_Moved(t *testing.T) {
+	diffs, err := Compare([]byte(moveFrom), []byte(moveTo), &Options{DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"compact", "database (moved from db)"},
+		{"brief", "2 moved"},
+		{"json", `"from_path": "cache.redis"`},
+		{"json-patch", `"from": "/cache/redis"`},
+		{"detailed", "moved from db"},
+		{"github", "Moved: stores.redis moved from cache.redis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, err := FormatterByName(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := DefaultFormatOptions()
+			if out := f.Format(diffs, opts); !strings.Contains(out, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_MovedRoundTrip(t *testing.T) {
+	diffs, err := Compare([]byte(moveFrom), []byte(moveTo), &Options{DetectMoves: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, format := range []string{"json", "json-patch"} {
+		t.Run(format, func(t *testing.T) {
+			f, err := FormatterByName(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := applyPatch(t, moveFrom, f.Format(diffs, &FormatOptions{}))
+			rest, err := Compare([]byte(got), []byte(moveTo), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("patched file still differs from target (%d differences):\n%s", len(rest), got)
+			}
+		})
+	}
+}
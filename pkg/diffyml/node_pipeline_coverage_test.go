@@ -51,10 +51,10 @@ func TestCompareNodes_KindMismatch_IgnoreValueChanges(t *testing.T) {
 		t.Errorf("with IgnoreValueChanges the kind-mismatch must be suppressed, got %v", diffs)
 	}
 
-	// Sanity: without IgnoreValueChanges the same call emits a DiffModified.
+	// Sanity: without IgnoreValueChanges the same call emits a DiffTypeChanged.
 	diffs = compareNodes(DiffPath{"key"}, fromVal, toVal, &Options{})
-	if len(diffs) != 1 || diffs[0].Type != DiffModified {
-		t.Errorf("without IgnoreValueChanges expected one DiffModified, got %v", diffs)
+	if len(diffs) != 1 || diffs[0].Type != DiffTypeChanged {
+		t.Errorf("without IgnoreValueChanges expected one DiffTypeChanged, got %v", diffs)
 	}
 }
 
@@ -0,0 +1,273 @@
+// path_map.go - Comparing deliberately moved fields in place (Options.PathMappings).
+//
+// Restructuring a values file or migrating a CRD version moves fields on
+// purpose, such as spec.tls to spec.security.tls. A PathMapping rewrites the
+// from side before comparison: the entry at From is moved to To, creating the
+// maps on the way, so that it is compared with the to side's entry at To. It
+// generalizes ChrootFrom and ChrootTo to one subtree of each document.
+// Differences inside a mapped subtree name its from-side path in
+// Difference.FromPath, so that the output says a mapping was applied. Under
+// Options.Swap the mappings are reversed, so that they keep naming the inputs
+// as given, and UnmatchedPathMappings finds the ones that move nothing.
+// Key types: PathMapping.
+// Key functions: ParsePathMapping, ReversePathMappings, UnmatchedPathMappings,
+// applyPathMappings, stampMappedPaths.
+package diffyml
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// PathMapping moves the from-side entry at From to To before comparison.
+// Both are dot-notation paths of mapping keys from the document root, or the
+// chroot, with bracket notation for keys holding dots
+// ("metadata.annotations[helm.sh/chart]"); list indices are not supported.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// ParsePathMapping parses the command-line form of a PathMapping, "OLD=NEW",
+// such as "spec.tls=spec.security.tls".
+func ParsePathMapping(s string) (PathMapping, error) {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok || from == "" || to == "" {
+		return PathMapping{}, fmt.Errorf("invalid path mapping %q: want OLD=NEW", s)
+	}
+	m := PathMapping{From: from, To: to}
+	if _, _, err := m.segments(); err != nil {
+		return PathMapping{}, err
+	}
+	return m, nil
+}
+
+// UnmarshalYAML reads a PathMapping from configuration, either in the
+// command-line form ("spec.tls=spec.security.tls") or as a mapping with from
+// and to keys.
+func (m *PathMapping) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		mapping, err := ParsePathMapping(n.Value)
+		if err != nil {
+			return err
+		}
+		*m = mapping
+		return nil
+	}
+	var raw struct {
+		From string `yaml:"from"`
+		To   string `yaml:"to"`
+	}
+	if err := n.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.From == "" || raw.To == "" {
+		return fmt.Errorf("line %d: path mapping needs from and to", n.Line)
+	}
+	mapping := PathMapping{From: raw.From, To: raw.To}
+	if _, _, err := mapping.segments(); err != nil {
+		return err
+	}
+	*m = mapping
+	return nil
+}
+
+// String returns the command-line form of the mapping.
+func (m PathMapping) String() string {
+	return m.From + "=" + m.To
+}
+
+// segments returns the keys of From and To, rejecting list indices and a
+// subtree mapped into itself.
+func (m PathMapping) segments() (from, to DiffPath, err error) {
+	if from, err = mappingKeys(m.From); err != nil {
+		return nil, nil, fmt.Errorf("invalid path mapping %q: %w", m.String(), err)
+	}
+	if to, err = mappingKeys(m.To); err != nil {
+		return nil, nil, fmt.Errorf("invalid path mapping %q: %w", m.String(), err)
+	}
+	n := min(len(from), len(to))
+	if slices.Equal(from[:n], to[:n]) {
+		return nil, nil, fmt.Errorf("invalid path mapping %q: one path contains the other", m.String())
+	}
+	return from, to, nil
+}
+
+// mappingKeys parses a path of mapping keys.
+func mappingKeys(path string) (DiffPath, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	keys := make(DiffPath, len(segs))
+	for i, seg := range segs {
+		if seg.isIndex {
+			return nil, fmt.Errorf("list index in %q: only mapping keys can be mapped", path)
+		}
+		keys[i] = seg.key
+	}
+	return keys, nil
+}
+
+// ReversePathMappings returns the mappings that undo mappings, last first:
+// the same moves stated for the other side of the comparison.
+func ReversePathMappings(mappings []PathMapping) []PathMapping {
+	reversed := make([]PathMapping, len(mappings))
+	for i, m := range mappings {
+		reversed[len(mappings)-1-i] = PathMapping{From: m.To, To: m.From}
+	}
+	return reversed
+}
+
+// comparedPathMappings returns the mappings Compare applies to its from side:
+// opts.PathMappings, reversed under Swap, since the from side is then the to
+// input.
+func comparedPathMappings(opts *Options) []PathMapping {
+	if opts.Swap {
+		return ReversePathMappings(opts.PathMappings)
+	}
+	return opts.PathMappings
+}
+
+// UnmatchedPathMappings returns the mappings Compare(from, to, opts) would
+// apply that match no from-side document, since none has an entry at their
+// From path; such a mapping changes nothing, usually by mistake. Under
+// opts.Swap the mappings are returned reversed, as Compare applies them.
+func UnmatchedPathMappings(from, to []byte, opts *Options) ([]PathMapping, error) {
+	if opts == nil || len(opts.PathMappings) == 0 {
+		return nil, nil
+	}
+	fromNodes, _, err := compareInputs(from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	mappings := comparedPathMappings(opts)
+	applied, err := applyPathMappings(fromNodes, mappings)
+	if err != nil {
+		return nil, err
+	}
+	var unmatched []PathMapping
+	for _, m := range mappings {
+		if !slices.Contains(applied, m) {
+			unmatched = append(unmatched, m)
+		}
+	}
+	return unmatched, nil
+}
+
+// applyPathMappings moves the entries of the from-side documents as
+// mappings says, in order, and returns the mappings that applied to at least
+// one document. A document without the From entry is left as is; one that
+// already has an entry at To, or a non-map on the way to it, is an error.
+func applyPathMappings(docs []*yaml.Node, mappings []PathMapping) ([]PathMapping, error) {
+	var applied []PathMapping
+	for _, m := range mappings {
+		from, to, err := m.segments()
+		if err != nil {
+			return nil, err
+		}
+		hit := false
+		for _, doc := range docs {
+			ok, err := movePathEntry(doc, from, to)
+			if err != nil {
+				return nil, fmt.Errorf("path mapping %q: %w", m.String(), err)
+			}
+			hit = hit || ok
+		}
+		if hit {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// movePathEntry moves the mapping entry at from to to within doc, reporting
+// whether doc had an entry at from. Maps the move leaves empty are removed,
+// as the maps on the way to to are created.
+func movePathEntry(doc *yaml.Node, from, to DiffPath) (bool, error) {
+	parent := resolveNode(doc)
+	parents := []*yaml.Node{parent}
+	for _, key := range from[:len(from)-1] {
+		if parent == nil || parent.Kind != yaml.MappingNode {
+			return false, nil
+		}
+		parent = resolveNode(lookupMappingValueNode(parent, key))
+		parents = append(parents, parent)
+	}
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false, nil
+	}
+	at := -1
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == from.Last() {
+			at = i
+		}
+	}
+	if at < 0 {
+		return false, nil
+	}
+	key, val := *parent.Content[at], parent.Content[at+1]
+
+	target := resolveNode(doc)
+	for i, k := range to[:len(to)-1] {
+		next := lookupMappingValueNode(target, k)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			target.Content = append(target.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, next)
+		}
+		if target = resolveNode(next); target.Kind != yaml.MappingNode {
+			return false, fmt.Errorf("%s is not a map", to[:i+1])
+		}
+	}
+	if lookupMappingValueNode(target, to.Last()) != nil {
+		return false, fmt.Errorf("%s already exists in the from document", to)
+	}
+	parent.Content = slices.Delete(parent.Content, at, at+2)
+	key.Value = to.Last()
+	target.Content = append(target.Content, &key, val)
+	for i := len(parents) - 1; i > 0 && len(parents[i].Content) == 0; i-- {
+		removeMappingKey(parents[i-1], from[i-1])
+	}
+	return true, nil
+}
+
+// removeMappingKey deletes the entries of m with the given key.
+func removeMappingKey(m *yaml.Node, key string) {
+	for i := len(m.Content) - 2; i >= 0; i -= 2 {
+		if m.Content[i].Value == key {
+			m.Content = slices.Delete(m.Content, i, i+2)
+		}
+	}
+}
+
+// stampMappedPaths records the from-side path of each difference inside a
+// mapped subtree in its FromPath. Moves keep the FromPath detectMoves gave
+// them, and warnings, which concern the to document only, get none.
+func stampMappedPaths(diffs []Difference, applied []PathMapping) {
+	if len(applied) == 0 {
+		return
+	}
+	for i := range diffs {
+		d := &diffs[i]
+		if d.Type == DiffMoved || d.Type == DiffWarning {
+			continue
+		}
+		prefix, path := DiffPath(nil), d.Path
+		if _, rest, ok := path.DocIndexPrefix(); ok {
+			prefix, path = path[:1], rest
+		}
+		for _, m := range applied {
+			from, to, _ := m.segments()
+			if len(path) >= len(to) && slices.Equal(path[:len(to)], to) {
+				d.FromPath = slices.Concat(prefix, from, path[len(to):])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package diffyml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestParsePathMapping(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PathMapping
+		err  bool
+	}{
+		{"spec.tls=spec.security.tls", PathMapping{From: "spec.tls", To: "spec.security.tls"}, false},
+		{"metadata.annotations[helm.sh/chart]=metadata.labels[helm.sh/chart]", PathMapping{From: "metadata.annotations[helm.sh/chart]", To: "metadata.labels[helm.sh/chart]"}, false},
+		{"spec.tls", PathMapping{}, true},
+		{"=spec.tls", PathMapping{}, true},
+		{"spec.tls=", PathMapping{}, true},
+		{"spec.ports[0]=spec.port", PathMapping{}, true},
+		{"spec.tls=spec.tls.v2", PathMapping{}, true},
+		{"spec=spec", PathMapping{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePathMapping(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParsePathMapping(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePathMapping(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPathMapping_UnmarshalYAML(t *testing.T) {
+	var mappings []PathMapping
+	src := "- spec.tls=spec.security.tls\n- from: db\n  to: database\n"
+	if err := yaml.Unmarshal([]byte(src), &mappings); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := []PathMapping{{From: "spec.tls", To: "spec.security.tls"}, {From: "db", To: "database"}}
+	if !reflect.DeepEqual(mappings, want) {
+		t.Errorf("got %+v, want %+v", mappings, want)
+	}
+
+	for _, bad := range []string{"- spec.tls\n", "- from: db\n", "- {from: a, to: a.b}\n"} {
+		if err := yaml.Unmarshal([]byte(bad), &mappings); err == nil {
+			t.Errorf("Unmarshal(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestCompare_PathMappings(t *testing.T) {
+	from := "spec:\n  port: 443\n  tls:\n    mode: SIMPLE\n    secretName: old\n"
+	to := "spec:\n  port: 443\n  security:\n    tls:\n      mode: SIMPLE\n      secretName: new\n      ciphers: [ECDHE]\n"
+	opts := &Options{PathMappings: []PathMapping{{From: "spec.tls", To: "spec.security.tls"}}}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []moveDiff{
+		{DiffAdded, "spec.security.tls", "spec.tls"},
+		{DiffModified, "spec.security.tls.secretName", "spec.tls.secretName"},
+	}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if diffs[1].FromPos == nil || diffs[1].FromPos.Line != 5 {
+		t.Errorf("FromPos = %v, want line 5 of the from file", diffs[1].FromPos)
+	}
+
+	got := FilterDiffs(diffs, &FilterOptions{IncludePaths: []string{"spec.tls.ciphers"}})
+	if len(got) != 1 || got[0].Type != DiffAdded {
+		t.Errorf("include spec.tls.ciphers = %v, want the added entry", moveDiffs(got))
+	}
+}
+
+func TestCompare_PathMappingsMultiDocument(t *testing.T) {
+	from := "a: {x: 1}\n---\nb: 2\n"
+	to := "c: {x: 2}\n---\nb: 3\n"
+	opts := &Options{PathMappings: []PathMapping{{From: "a", To: "c"}}}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []moveDiff{
+		{DiffModified, "[0].c.x", "[0].a.x"},
+		{DiffModified, "[1].b", ""},
+	}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompare_PathMappingsSwap(t *testing.T) {
+	from := "spec:\n  tls: {mode: SIMPLE, secretName: old}\n"
+	to := "spec:\n  security:\n    tls: {mode: SIMPLE, secretName: new}\n"
+	opts := &Options{Swap: true, PathMappings: []PathMapping{{From: "spec.tls", To: "spec.security.tls"}}}
+	diffs, err := Compare([]byte(from), []byte(to), opts)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	// The emptied spec.security is removed with the move, not reported.
+	want := []moveDiff{{DiffModified, "spec.tls.secretName", "spec.security.tls.secretName"}}
+	if got := moveDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUnmatchedPathMappings(t *testing.T) {
+	from := "spec:\n  tls: {mode: SIMPLE}\n"
+	to := "spec:\n  security:\n    tls: {mode: SIMPLE}\n"
+	tls := PathMapping{From: "spec.tls", To: "spec.security.tls"}
+	typo := PathMapping{From: "spec.tsl", To: "spec.security.tsl"}
+	tests := []struct {
+		name string
+		opts *Options
+		want []PathMapping
+	}{
+		{name: "no mappings", opts: &Options{}},
+		{name: "matched", opts: &Options{PathMappings: []PathMapping{tls}}},
+		{name: "missing old path", opts: &Options{PathMappings: []PathMapping{tls, typo}}, want: []PathMapping{typo}},
+		{name: "swapped", opts: &Options{Swap: true, PathMappings: []PathMapping{tls, typo}}, want: ReversePathMappings([]PathMapping{typo})},
+		{name: "chrooted away", opts: &Options{ChrootFrom: "spec.tls", PathMappings: []PathMapping{tls}}, want: []PathMapping{tls}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmatchedPathMappings([]byte(from), []byte(to), tt.opts)
+			if err != nil {
+				t.Fatalf("UnmatchedPathMappings failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_PathMappingsErrors(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"target exists", "a: {x: 1}\nb: {x: 1}\n", "b", "b already exists"},
+		{"target parent not a map", "a: {x: 1}\nb: 1\n", "b.c", "b is not a map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &Options{PathMappings: []PathMapping{{From: "a", To: tt.to}}}
+			_, err := Compare([]byte(tt.from), []byte("b: {x: 1}\n"), opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Mapped(t *testing.T) {
+	diffs, err := Compare([]byte("tls: {mode: A}\n"), []byte("security:\n  tls: {mode: B}\n"),
+		&Options{PathMappings: []PathMapping{{From: "tls", To: "security.tls"}}})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"compact", "security.tls.mode (mapped from tls.mode) : A → B"},
+		{"detailed", "security.tls.mode (mapped from tls.mode)\n"},
+		{"json", `"from_path": "tls.mode"`},
+		{"github", "Modified: security.tls.mode (mapped from tls.mode) changed from A to B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, err := FormatterByName(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := f.Format(diffs, DefaultFormatOptions()); !strings.Contains(out, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
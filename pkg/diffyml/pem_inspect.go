@@ -0,0 +1,254 @@
+// pem_inspect.go - Summaries of PEM keys and certificate requests.
+//
+// Formatters show a PEM certificate as its one-line Certificate(...) summary;
+// SummarizePEM extends that to private keys, public keys and certificate
+// signing requests. Private keys are more than noise: MaskDifferences and the
+// source-printing formatters always replace them, whatever the masking
+// options, so key material never reaches any output.
+// Key functions: SummarizePEM, maskPrivateKeys, holdsPrivateKey.
+package diffyml
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// privateKeyBlock matches a PEM private key of any kind — PKCS #1, SEC 1,
+// PKCS #8, encrypted or OpenSSH — and privateKeyRest a private key header
+// left without its footer, which is masked to the end of the text.
+var (
+	privateKeyBlock = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+	privateKeyRest  = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*`)
+)
+
+// SummarizePEM returns a one-line summary of a single PEM certificate,
+// private key, public key or certificate signing request:
+//
+//	Certificate(CN=api.example.com, Issuer=Example CA, Valid=2026-01-01..2027-01-01, Serial=2)
+//	PrivateKey(Key=RSA 2048, Fingerprint=sha256:…)
+//	PublicKey(Key=ECDSA P-256, Fingerprint=sha256:…)
+//	CertificateRequest(CN=api.example.com, SANs=DNS:api.example.com DNS:www.example.com, Key=ECDSA P-256)
+//
+// A key's fingerprint is the SHA-256 of its public key in PKIX form, so a
+// private key and its public key share one. A private key that cannot be
+// parsed, such as an encrypted or OpenSSH one, is summarized without its
+// key. ok is false unless the trimmed string is exactly one such block.
+func SummarizePEM(s string) (summary string, ok bool) {
+	if IsPEMCertificate(s) {
+		return FormatCertificate(s), true
+	}
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "-----BEGIN ") {
+		return "", false
+	}
+	block, rest := pem.Decode([]byte(trimmed))
+	if block == nil || len(strings.TrimSpace(string(rest))) > 0 {
+		return "", false
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return "", false
+		}
+		return "PublicKey(" + publicKeyFields(pub) + ")", true
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return "", false
+		}
+		return "PublicKey(" + publicKeyFields(pub) + ")", true
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return "", false
+		}
+		return csrSummary(csr), true
+	}
+	if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		return privateKeySummary(block), true
+	}
+	return "", false
+}
+
+// privateKeySummary summarizes a PEM private key block by its public half.
+func privateKeySummary(block *pem.Block) string {
+	if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] != "" {
+		return "PrivateKey(encrypted)"
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return "PrivateKey(unrecognized)"
+	}
+	private, ok := key.(interface{ Public() crypto.PublicKey })
+	if err != nil || !ok {
+		return "PrivateKey(unrecognized)"
+	}
+	return "PrivateKey(" + publicKeyFields(private.Public()) + ")"
+}
+
+// csrSummary summarizes a certificate signing request by its subject,
+// subject alternative names and key.
+func csrSummary(csr *x509.CertificateRequest) string {
+	fields := []string{"CN=" + certName(csr.Subject.CommonName, csr.Subject.Organization, csr.DNSNames)}
+	var sans []string
+	for _, name := range csr.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+	for _, ip := range csr.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+	for _, email := range csr.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+	for _, uri := range csr.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+	if len(sans) > 0 {
+		fields = append(fields, "SANs="+strings.Join(sans, " "))
+	}
+	fields = append(fields, "Key="+publicKeyName(csr.PublicKey))
+	return "CertificateRequest(" + strings.Join(fields, ", ") + ")"
+}
+
+// publicKeyFields renders the key and fingerprint fields of a summary.
+func publicKeyFields(pub any) string {
+	fields := "Key=" + publicKeyName(pub)
+	if der, err := x509.MarshalPKIXPublicKey(pub); err == nil {
+		sum := sha256.Sum256(der)
+		fields += ", Fingerprint=sha256:" + hex.EncodeToString(sum[:])
+	}
+	return fields
+}
+
+// publicKeyName names a public key's algorithm and size: "RSA 2048",
+// "ECDSA P-256", "Ed25519", "X25519".
+func publicKeyName(pub any) string {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA " + strconv.Itoa(key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	case *ecdh.PublicKey:
+		return fmt.Sprint(key.Curve())
+	}
+	return "unknown"
+}
+
+// maskPrivateKeyText replaces every PEM private key in s with its summary,
+// reporting whether there was one; a value that held only a key becomes its
+// summary alone. A value holding base64-encoded PEM, as a
+// Secret's tls.key does, is replaced by its decoded text, masked.
+func maskPrivateKeyText(s string) (string, bool) {
+	if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, base64PEMPrefix) {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(trimmed), "")); err == nil {
+			if masked, ok := maskPrivateKeyText(string(decoded)); ok {
+				return masked, true
+			}
+		}
+	}
+	if !strings.Contains(s, "PRIVATE KEY-----") {
+		return s, false
+	}
+	masked := privateKeyBlock.ReplaceAllStringFunc(s, func(match string) string {
+		block, _ := pem.Decode([]byte(match))
+		if block == nil {
+			return "PrivateKey(unrecognized)"
+		}
+		return privateKeySummary(block)
+	})
+	masked = privateKeyRest.ReplaceAllString(masked, "PrivateKey(unrecognized)")
+	if masked == s {
+		return s, false
+	}
+	if trimmed := strings.TrimSpace(masked); !strings.Contains(trimmed, "\n") {
+		// A value that held only a key reads as a one-line value.
+		return trimmed, true
+	}
+	return masked, true
+}
+
+// holdsPrivateKey reports whether s holds a PEM private key, as text or
+// base64-encoded.
+func holdsPrivateKey(s string) bool {
+	_, ok := maskPrivateKeyText(s)
+	return ok
+}
+
+// maskPrivateKeys returns v with every private key in its strings replaced
+// by its summary (see maskPrivateKeyText), reporting whether there was one.
+// Maps and lists are copied only when something in them changes.
+func maskPrivateKeys(v any) (any, bool) {
+	switch val := v.(type) {
+	case string:
+		return maskPrivateKeyText(val)
+	case *OrderedMap:
+		var out *OrderedMap
+		for _, k := range val.Keys {
+			if masked, ok := maskPrivateKeys(val.Values[k]); ok {
+				if out == nil {
+					out = &OrderedMap{Keys: append([]string(nil), val.Keys...), Values: maps.Clone(val.Values)}
+				}
+				out.Values[k] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case map[string]any:
+		var out map[string]any
+		for k, child := range val {
+			if masked, ok := maskPrivateKeys(child); ok {
+				if out == nil {
+					out = maps.Clone(val)
+				}
+				out[k] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []any:
+		var out []any
+		for i, child := range val {
+			if masked, ok := maskPrivateKeys(child); ok {
+				if out == nil {
+					out = slices.Clone(val)
+				}
+				out[i] = masked
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	default:
+		return v, false
+	}
+}
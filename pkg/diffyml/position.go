@@ -0,0 +1,113 @@
+// position.go - Source locations for differences.
+//
+// The comparator walks *yaml.Node trees, which carry the line and column of
+// every key and value. Position preserves that location on each Difference so
+// formatters can point annotations at the changed line instead of the top of
+// the file.
+// Key types: Position.
+// Key functions: nodePosition, entryPosition, containerPosition,
+// stampPositionFiles.
+package diffyml
+
+import (
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Position locates one side of a difference in its source document.
+// Lines and columns are 1-based, as reported by the YAML parser.
+type Position struct {
+	// File is the source file label (Options.FromFile / Options.ToFile).
+	// Empty when the caller did not name the inputs.
+	File string `json:"file,omitempty"`
+	// Line is the first line of the value (or of its key, for map entries).
+	Line int `json:"line"`
+	// Column is the column of the first character on Line.
+	Column int `json:"column"`
+	// EndLine is the last line the value spans. Equal to Line for single-line
+	// values.
+	EndLine int `json:"end_line"`
+}
+
+// nodePosition returns the source position of n, or nil when n is nil or
+// carries no location (nodes synthesized rather than parsed). DocumentNode
+// wrappers are unwrapped so the position points at the document's content.
+// Aliases are not followed: an alias reports where it is written, which is
+// the line a reviewer sees change.
+func nodePosition(n *yaml.Node) *Position {
+	if n != nil && n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return nil
+		}
+		n = n.Content[0]
+	}
+	if n == nil || n.Line == 0 {
+		return nil
+	}
+	return &Position{Line: n.Line, Column: n.Column, EndLine: nodeEndLine(n)}
+}
+
+// entryPosition returns the position of a map entry: it starts at the key and
+// ends where the value ends, so an added or removed entry covers every line it
+// occupies.
+func entryPosition(key, val *yaml.Node) *Position {
+	pos := nodePosition(key)
+	if pos == nil {
+		return nodePosition(val)
+	}
+	if end := nodeEndLine(val); end > pos.EndLine {
+		pos.EndLine = end
+	}
+	return pos
+}
+
+// containerPosition returns the position of the collection an entry was
+// added to or removed from, narrowed to its first line. The entry itself has
+// no node on that side, so the container is the closest real location; the
+// full extent of the container would over-claim every sibling line.
+func containerPosition(n *yaml.Node) *Position {
+	pos := nodePosition(n)
+	if pos != nil {
+		pos.EndLine = pos.Line
+	}
+	return pos
+}
+
+// nodeEndLine returns the last source line spanned by n. Collections end at
+// their last descendant. Literal and folded block scalars start on the line
+// of their indicator and occupy one line per content line after it. Flow
+// scalars that wrap across lines are folded by the parser, so their extent is
+// not recoverable and they are treated as single-line.
+func nodeEndLine(n *yaml.Node) int {
+	if n == nil {
+		return 0
+	}
+	for (n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode || n.Kind == yaml.DocumentNode) && len(n.Content) > 0 {
+		n = n.Content[len(n.Content)-1]
+	}
+	if n.Kind == yaml.ScalarNode && (n.Style&(yaml.LiteralStyle|yaml.FoldedStyle)) != 0 {
+		body := strings.TrimRight(n.Value, "\n")
+		if body == "" {
+			return n.Line
+		}
+		return n.Line + strings.Count(body, "\n") + 1
+	}
+	return n.Line
+}
+
+// stampPositionFiles records the input file labels on every position. The
+// comparator never sees file names, so this runs once after the walk.
+func stampPositionFiles(diffs []Difference, fromFile, toFile string) {
+	if fromFile == "" && toFile == "" {
+		return
+	}
+	for i := range diffs {
+		if diffs[i].FromPos != nil {
+			diffs[i].FromPos.File = fromFile
+		}
+		if diffs[i].ToPos != nil {
+			diffs[i].ToPos.File = toFile
+		}
+	}
+}
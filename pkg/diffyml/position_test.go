@@ -0,0 +1,212 @@
+package diffyml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// findDiff returns the first diff at path with the given type, failing the
+// test when none exists.
+func findDiff(t *testing.T, diffs []Difference, path string, dt DiffType) Difference {
+	t.Helper()
+	for _, d := range diffs {
+		if d.Path.String() == path && d.Type == dt {
+			return d
+		}
+	}
+	t.Fatalf("no %v diff at %q in %+v", dt, path, diffs)
+	return Difference{}
+}
+
+func assertPos(t *testing.T, name string, got *Position, line, column, endLine int) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s: position is nil", name)
+	}
+	if got.Line != line || got.Column != column || got.EndLine != endLine {
+		t.Errorf("%s = %+v, want line=%d column=%d endLine=%d", name, *got, line, column, endLine)
+	}
+}
+
+func TestCompare_Positions_ScalarModified(t *testing.T) {
+	from := "app:\n  name: web\n  version: 1.0\n"
+	to := "app:\n  name: web\n\n  version: 2.0\n"
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := findDiff(t, diffs, "app.version", DiffModified)
+	assertPos(t, "FromPos", d.FromPos, 3, 12, 3)
+	assertPos(t, "ToPos", d.ToPos, 4, 12, 4)
+}
+
+func TestCompare_Positions_MapEntryAddedRemoved(t *testing.T) {
+	from := "app:\n  name: web\n  debug:\n    level: 3\n    trace: true\n"
+	to := "app:\n  name: web\n  replicas: 3\n"
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed := findDiff(t, diffs, "app", DiffRemoved)
+	// The removed entry spans its key through the end of its value.
+	assertPos(t, "removed FromPos", removed.FromPos, 3, 3, 5)
+	// The to side has no entry; it points at the first line of the container.
+	assertPos(t, "removed ToPos", removed.ToPos, 2, 3, 2)
+
+	added := findDiff(t, diffs, "app", DiffAdded)
+	assertPos(t, "added FromPos", added.FromPos, 2, 3, 2)
+	assertPos(t, "added ToPos", added.ToPos, 3, 3, 3)
+}
+
+func TestCompare_Positions_ListItems(t *testing.T) {
+	from := "items:\n  - a\n"
+	to := "items:\n  - a\n  - b\n"
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := findDiff(t, diffs, "items.1", DiffAdded)
+	assertPos(t, "ToPos", d.ToPos, 3, 5, 3)
+	assertPos(t, "FromPos", d.FromPos, 2, 3, 2)
+}
+
+func TestCompare_Positions_BlockScalarEndLine(t *testing.T) {
+	from := "script: |\n  echo a\n  echo b\n"
+	to := "script: |\n  echo a\n  echo c\n  echo d\n"
+	diffs, err := Compare([]byte(from), []byte(to), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := findDiff(t, diffs, "script", DiffModified)
+	assertPos(t, "FromPos", d.FromPos, 1, 9, 3)
+	assertPos(t, "ToPos", d.ToPos, 1, 9, 4)
+}
+
+func TestCompare_Positions_K8sDocumentAdded(t *testing.T) {
+	from := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"
+	to := from + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := findDiff(t, diffs, "[1]", DiffAdded)
+	if d.FromPos != nil {
+		t.Errorf("FromPos = %+v, want nil for a document absent from the from stream", *d.FromPos)
+	}
+	assertPos(t, "ToPos", d.ToPos, 6, 1, 9)
+}
+
+func TestCompare_Positions_Files(t *testing.T) {
+	diffs, err := Compare([]byte("a: 1\n"), []byte("a: 2\n"), &Options{FromFile: "old.yaml", ToFile: "new.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs[0].FromPos.File != "old.yaml" || diffs[0].ToPos.File != "new.yaml" {
+		t.Errorf("files = %q/%q, want old.yaml/new.yaml", diffs[0].FromPos.File, diffs[0].ToPos.File)
+	}
+
+	// Swap exchanges the inputs, so the labels follow them.
+	diffs, err = Compare([]byte("a: 1\n"), []byte("a: 2\n"), &Options{FromFile: "old.yaml", ToFile: "new.yaml", Swap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs[0].FromPos.File != "new.yaml" || diffs[0].ToPos.File != "old.yaml" {
+		t.Errorf("swapped files = %q/%q, want new.yaml/old.yaml", diffs[0].FromPos.File, diffs[0].ToPos.File)
+	}
+}
+
+func TestCompare_Positions_Unchanged(t *testing.T) {
+	diffs, err := Compare([]byte("a: 1\nb: 2\n"), []byte("a: 1\nb: 3\n"), &Options{Unchanged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := findDiff(t, diffs, "a", DiffUnchanged)
+	assertPos(t, "FromPos", d.FromPos, 1, 4, 1)
+	assertPos(t, "ToPos", d.ToPos, 1, 4, 1)
+}
+
+func TestNodePosition_Nil(t *testing.T) {
+	if got := nodePosition(nil); got != nil {
+		t.Errorf("nodePosition(nil) = %+v, want nil", got)
+	}
+	if got := containerPosition(nil); got != nil {
+		t.Errorf("containerPosition(nil) = %+v, want nil", got)
+	}
+}
+
+func TestGitHubFormatter_LinePositions(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"a"}, Type: DiffModified, From: 1, To: 2, ToPos: &Position{Line: 4, Column: 3, EndLine: 4}},
+		{Path: DiffPath{"b"}, Type: DiffAdded, To: "x", ToPos: &Position{Line: 7, Column: 1, EndLine: 9}},
+		{Path: DiffPath{"[1]"}, Type: DiffRemoved, From: "y"},
+	}
+	output := (&GitHubFormatter{}).Format(diffs, &FormatOptions{FilePath: "cfg.yaml"})
+
+	for _, want := range []string{
+		"::warning file=cfg.yaml,line=4,title=",
+		"::notice file=cfg.yaml,line=7,endLine=9,title=",
+		"::error file=cfg.yaml,title=",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	// Without a file GitHub ignores line=, so it is not emitted.
+	output = (&GitHubFormatter{}).Format(diffs, &FormatOptions{})
+	if strings.Contains(output, "line=") {
+		t.Errorf("line= emitted without file=:\n%s", output)
+	}
+}
+
+func TestGitLabFormatter_BeginLine(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"a"}, Type: DiffModified, From: 1, To: 2, ToPos: &Position{Line: 12, Column: 3, EndLine: 12}},
+		{Path: DiffPath{"[1]"}, Type: DiffRemoved, From: "y"},
+	}
+	output := (&GitLabFormatter{}).Format(diffs, &FormatOptions{FilePath: "cfg.yaml"})
+
+	var issues []struct {
+		Location struct {
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(output), &issues); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, output)
+	}
+	if issues[0].Location.Lines.Begin != 12 {
+		t.Errorf("begin = %d, want 12", issues[0].Location.Lines.Begin)
+	}
+	if issues[1].Location.Lines.Begin != 1 {
+		t.Errorf("begin without position = %d, want 1", issues[1].Location.Lines.Begin)
+	}
+}
+
+func TestJSONFormatter_Positions(t *testing.T) {
+	diffs := []Difference{
+		{Path: DiffPath{"a"}, Type: DiffModified, From: 1, To: 2,
+			FromPos: &Position{File: "old.yaml", Line: 1, Column: 4, EndLine: 1},
+			ToPos:   &Position{File: "new.yaml", Line: 2, Column: 4, EndLine: 2}},
+		{Path: DiffPath{"b"}, Type: DiffAdded, To: 3},
+	}
+	output := (&JSONFormatter{}).Format(diffs, nil)
+
+	var got []map[string]any
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, output)
+	}
+	to, ok := got[0]["to_position"].(map[string]any)
+	if !ok {
+		t.Fatalf("to_position missing: %s", output)
+	}
+	if to["file"] != "new.yaml" || to["line"] != float64(2) || to["column"] != float64(4) || to["end_line"] != float64(2) {
+		t.Errorf("to_position = %v", to)
+	}
+	if _, ok := got[1]["from_position"]; ok {
+		t.Errorf("from_position should be omitted when nil: %s", output)
+	}
+}
@@ -0,0 +1,271 @@
+// quantity.go - Kubernetes quantity and duration normalization
+// (Options.NormalizeQuantities).
+//
+// The API server stores "500m" and "0.5" CPU, or "1Gi" and "1024Mi" memory,
+// as the same quantity, and a Go duration "60s" as the same as "1m". At
+// well-known paths, and at paths declared in Options.QuantityPaths and
+// Options.DurationPaths, scalars are parsed into exact values before they are
+// compared: equivalent spellings are equal, and a real change is reported
+// with the normalized delta in Difference.Delta ("+500m", "-512Mi", "+30s").
+// Key functions: normalizedDelta, parseQuantity, parseDuration.
+package diffyml
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// valueUnit is what a path holds under NormalizeQuantities.
+type valueUnit int
+
+const (
+	unitNone valueUnit = iota
+	unitQuantity
+	unitDuration
+)
+
+// quantityParents are the mapping keys whose entries are resource quantities
+// whatever the resource is called: ResourceQuota hard/used, node and volume
+// capacity/allocatable, and RuntimeClass pod overhead.
+var quantityParents = map[string]bool{
+	"hard": true, "used": true, "capacity": true, "allocatable": true, "podFixed": true,
+}
+
+// limitRangeParents are the keys of a LimitRange item holding quantities.
+var limitRangeParents = map[string]bool{
+	"max": true, "min": true, "default": true, "defaultRequest": true, "maxLimitRequestRatio": true,
+}
+
+// specDurations are the spec fields that hold Go durations in common custom
+// resources: cert-manager certificates and Flux sources and kustomizations.
+var specDurations = map[string]bool{
+	"duration": true, "renewBefore": true, "interval": true, "retryInterval": true, "timeout": true,
+}
+
+// unitAt returns what the value at path holds: a quantity, a duration, or
+// neither. User-declared paths take precedence over the well-known ones.
+func unitAt(path DiffPath, opts *Options) valueUnit {
+	if _, rest, ok := path.DocIndexPrefix(); ok {
+		path = rest
+	}
+	s := path.String()
+	if matchesAnyPath(s, opts.QuantityPaths) {
+		return unitQuantity
+	}
+	if matchesAnyPath(s, opts.DurationPaths) {
+		return unitDuration
+	}
+	n := len(path)
+	if n == 0 {
+		return unitNone
+	}
+	key := path[n-1]
+	switch {
+	case n >= 3 && path[n-3] == "resources" && (path[n-2] == "requests" || path[n-2] == "limits"):
+		return unitQuantity
+	case n >= 2 && quantityParents[path[n-2]]:
+		return unitQuantity
+	case n >= 4 && path[n-4] == "limits" && limitRangeParents[path[n-2]]:
+		return unitQuantity
+	case key == "sizeLimit":
+		return unitQuantity
+	case strings.HasSuffix(key, "Seconds") || key == "ttlSecondsAfterFinished":
+		// Probe timings, terminationGracePeriodSeconds, activeDeadlineSeconds
+		// and the like: integer seconds.
+		return unitDuration
+	case n == 2 && path[0] == "spec" && specDurations[key]:
+		return unitDuration
+	}
+	return unitNone
+}
+
+// normalizedDelta compares two scalars by value when the path holds a
+// quantity or duration and both parse. normalized reports whether they did;
+// delta is then "" for equivalent values, otherwise the signed difference
+// from fromN to toN in the notation of the inputs.
+func normalizedDelta(path DiffPath, fromN, toN *yaml.Node, opts *Options) (delta string, normalized bool) {
+	if !opts.NormalizeQuantities || !numericScalar(fromN) || !numericScalar(toN) {
+		return "", false
+	}
+	switch unitAt(path, opts) {
+	case unitQuantity:
+		from, ok := parseQuantity(fromN.Value)
+		if !ok {
+			return "", false
+		}
+		to, ok := parseQuantity(toN.Value)
+		if !ok {
+			return "", false
+		}
+		d := new(big.Rat).Sub(to, from)
+		if d.Sign() == 0 {
+			return "", true
+		}
+		binary := hasBinarySuffix(fromN.Value) || hasBinarySuffix(toN.Value)
+		return signed(d.Sign(), formatQuantity(new(big.Rat).Abs(d), binary)), true
+	case unitDuration:
+		from, ok := parseDuration(fromN.Value)
+		if !ok {
+			return "", false
+		}
+		to, ok := parseDuration(toN.Value)
+		if !ok {
+			return "", false
+		}
+		d := to - from
+		switch {
+		case d == 0:
+			return "", true
+		case d < 0:
+			return signed(-1, formatDuration(-d)), true
+		}
+		return signed(1, formatDuration(d)), true
+	}
+	return "", false
+}
+
+// numericScalar reports whether n is a scalar that can spell a quantity or
+// duration: a string, integer or float.
+func numericScalar(n *yaml.Node) bool {
+	if n.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch n.ShortTag() {
+	case "!!str", "!!int", "!!float":
+		return true
+	}
+	return false
+}
+
+// signed prefixes a magnitude with the sign of a delta.
+func signed(sign int, abs string) string {
+	if sign < 0 {
+		return "-" + abs
+	}
+	return "+" + abs
+}
+
+// binarySuffixes are the Kubernetes binary SI suffixes, each 1024 times the
+// one before.
+var binarySuffixes = []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+
+// decimalSuffixes maps the Kubernetes decimal SI suffixes to their powers of
+// ten, largest first.
+var decimalSuffixes = []struct {
+	suffix string
+	exp    int
+}{
+	{"E", 18}, {"P", 15}, {"T", 12}, {"G", 9}, {"M", 6}, {"k", 3}, {"", 0}, {"m", -3}, {"u", -6}, {"n", -9},
+}
+
+// parseQuantity parses a Kubernetes quantity: a signed decimal number
+// followed by a binary SI suffix (Ki, Mi, ...), a decimal SI suffix (n, u, m,
+// k, M, ...) or a decimal exponent (e3, E-2), into its exact value.
+func parseQuantity(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := false
+	for ; i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.'); i++ {
+		digits = digits || s[i] != '.'
+	}
+	if !digits {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(s[:i])
+	if !ok {
+		return nil, false
+	}
+	suffix := s[i:]
+	for j, b := range binarySuffixes {
+		if suffix == b {
+			return r.Mul(r, binaryUnit(j)), true
+		}
+	}
+	for _, d := range decimalSuffixes {
+		if suffix == d.suffix {
+			return r.Mul(r, pow10(d.exp)), true
+		}
+	}
+	if len(suffix) > 1 && (suffix[0] == 'e' || suffix[0] == 'E') {
+		if exp, err := strconv.Atoi(suffix[1:]); err == nil && exp >= -30 && exp <= 30 {
+			return r.Mul(r, pow10(exp)), true
+		}
+	}
+	return nil, false
+}
+
+// binaryUnit returns the value of binarySuffixes[j]: 1024^(j+1).
+func binaryUnit(j int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(10*(j+1))))
+}
+
+// pow10 returns 10^exp as an exact rational.
+func pow10(exp int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(exp, -exp))), nil)
+	if exp < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+	return new(big.Rat).SetInt(p)
+}
+
+// hasBinarySuffix reports whether a quantity is written with a binary SI
+// suffix, so its delta is best shown in one too.
+func hasBinarySuffix(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, b := range binarySuffixes {
+		if strings.HasSuffix(s, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatQuantity renders a non-negative quantity with the largest suffix
+// that keeps it whole: binary suffixes when binary is set, else decimal ones.
+func formatQuantity(r *big.Rat, binary bool) string {
+	if binary {
+		for j := len(binarySuffixes) - 1; j >= 0; j-- {
+			if q := new(big.Rat).Quo(r, binaryUnit(j)); q.IsInt() {
+				return q.Num().String() + binarySuffixes[j]
+			}
+		}
+	}
+	for _, d := range decimalSuffixes {
+		if q := new(big.Rat).Quo(r, pow10(d.exp)); q.IsInt() {
+			return q.Num().String() + d.suffix
+		}
+	}
+	return strings.TrimRight(r.FloatString(12), "0")
+}
+
+// parseDuration parses a Go duration ("1m30s") or a bare number of seconds,
+// the unit of Kubernetes' *Seconds fields.
+func parseDuration(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(secs) && !math.IsInf(secs, 0) {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+// formatDuration renders a duration like time.Duration.String without the
+// trailing zero units: "1h" rather than "1h0m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
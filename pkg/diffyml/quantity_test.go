@@ -0,0 +1,199 @@
+package diffyml
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // exact value as a big.Rat fraction
+		ok   bool
+	}{
+		{"500m", "1/2", true},
+		{"0.5", "1/2", true},
+		{".5", "1/2", true},
+		{"1", "1", true},
+		{"1Gi", "1073741824", true},
+		{"1024Mi", "1073741824", true},
+		{"1.5k", "1500", true},
+		{"1e3", "1000", true},
+		{"12E-1", "6/5", true},
+		{"1E", "1000000000000000000", true},
+		{"-250u", "-1/4000", true},
+		{"100n", "1/10000000", true},
+		{"1Gb", "", false},
+		{"Mi", "", false},
+		{"", "", false},
+		{"1..5", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseQuantity(tt.in)
+		if ok != tt.ok {
+			t.Errorf("parseQuantity(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && got.RatString() != tt.want {
+			t.Errorf("parseQuantity(%q) = %s, want %s", tt.in, got.RatString(), tt.want)
+		}
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		in     string
+		binary bool
+		want   string
+	}{
+		{"1/2", false, "500m"},
+		{"1500", false, "1500"},
+		{"2000", false, "2k"},
+		{"536870912", true, "512Mi"},
+		{"1000", true, "1k"},
+		{"1/3", false, "0.333333333333"},
+	}
+	for _, tt := range tests {
+		r, _ := new(big.Rat).SetString(tt.in)
+		if got := formatQuantity(r, tt.binary); got != tt.want {
+			t.Errorf("formatQuantity(%s, %v) = %q, want %q", tt.in, tt.binary, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"60", time.Minute, true},
+		{"1.5", 1500 * time.Millisecond, true},
+		{"1m", time.Minute, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"NaN", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDuration(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got := formatDuration(time.Hour); got != "1h" {
+		t.Errorf("formatDuration(1h) = %q", got)
+	}
+	if got := formatDuration(90 * time.Second); got != "1m30s" {
+		t.Errorf("formatDuration(90s) = %q", got)
+	}
+}
+
+func TestUnitAt(t *testing.T) {
+	opts := &Options{QuantityPaths: []string{"app.memory"}, DurationPaths: []string{"app.ttl"}}
+	tests := []struct {
+		path string
+		want valueUnit
+	}{
+		{"spec.containers.web.resources.requests.cpu", unitQuantity},
+		{"[1].spec.resources.limits.memory", unitQuantity},
+		{"spec.hard.pods", unitQuantity},
+		{"status.capacity.storage", unitQuantity},
+		{"spec.limits.0.defaultRequest.cpu", unitQuantity},
+		{"spec.volumes.cache.emptyDir.sizeLimit", unitQuantity},
+		{"spec.containers.web.livenessProbe.periodSeconds", unitDuration},
+		{"spec.template.spec.terminationGracePeriodSeconds", unitDuration},
+		{"spec.renewBefore", unitDuration},
+		{"spec.template.spec.timeout", unitNone},
+		{"spec.replicas", unitNone},
+		{"app.memory.max", unitQuantity},
+		{"app.ttl", unitDuration},
+		{"app.memoryLimit", unitNone},
+	}
+	for _, tt := range tests {
+		if got := unitAt(DiffPath(strings.Split(tt.path, ".")), opts); got != tt.want {
+			t.Errorf("unitAt(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompare_NormalizeQuantities(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		opts     Options
+		delta    string // "" expects no difference
+	}{
+		{"equal cpu", "resources:\n  requests:\n    cpu: 500m\n", "resources:\n  requests:\n    cpu: \"0.5\"\n", Options{}, ""},
+		{"equal memory", "resources:\n  limits:\n    memory: 1Gi\n", "resources:\n  limits:\n    memory: 1024Mi\n", Options{}, ""},
+		{"int to quantity", "resources:\n  limits:\n    cpu: 1\n", "resources:\n  limits:\n    cpu: 1500m\n", Options{}, "+500m"},
+		{"memory shrinks", "resources:\n  limits:\n    memory: 2Gi\n", "resources:\n  limits:\n    memory: 1536Mi\n", Options{}, "-512Mi"},
+		{"equal seconds", "terminationGracePeriodSeconds: 60\n", "terminationGracePeriodSeconds: 1m\n", Options{}, ""},
+		{"user quantity path", "app:\n  heap: 512Mi\n", "app:\n  heap: 1Gi\n", Options{QuantityPaths: []string{"app.heap"}}, "+512Mi"},
+		{"user duration path", "app:\n  ttl: 1h\n", "app:\n  ttl: 3600s\n", Options{DurationPaths: []string{"app"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.NormalizeQuantities = true
+			diffs, err := Compare([]byte(tt.from), []byte(tt.to), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.delta == "" {
+				if len(diffs) != 0 {
+					t.Errorf("expected no difference, got %+v", diffs)
+				}
+				return
+			}
+			if len(diffs) != 1 || diffs[0].Type != DiffModified || diffs[0].Delta != tt.delta {
+				t.Errorf("expected one modification by %s, got %+v", tt.delta, diffs)
+			}
+		})
+	}
+}
+
+func TestCompare_NormalizeQuantitiesOff(t *testing.T) {
+	diffs, err := Compare([]byte("resources:\n  requests:\n    cpu: 500m\n"), []byte("resources:\n  requests:\n    cpu: \"0.5\"\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Delta != "" {
+		t.Errorf("expected a literal modification without a delta, got %+v", diffs)
+	}
+}
+
+func TestCompare_NormalizeQuantitiesUnparsable(t *testing.T) {
+	opts := &Options{NormalizeQuantities: true}
+	diffs, err := Compare([]byte("resources:\n  limits:\n    cpu: 1\n"), []byte("resources:\n  limits:\n    cpu: lots\n"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Type != DiffTypeChanged || diffs[0].Delta != "" {
+		t.Errorf("expected an unnormalized type change, got %+v", diffs)
+	}
+}
+
+func TestFormatters_Delta(t *testing.T) {
+	diff := Difference{Path: DiffPath{"resources", "limits", "cpu"}, Type: DiffModified, From: 1, To: "1500m", Delta: "+500m"}
+	noColor := &FormatOptions{OmitHeader: true}
+	tests := []struct {
+		name string
+		f    Formatter
+		want string
+	}{
+		{"compact", &CompactFormatter{}, "± resources.limits.cpu : 1 → 1500m (+500m)"},
+		{"detailed", &DetailedFormatter{}, "  ± value change by +500m\n    - 1\n    + 1500m\n"},
+		{"github", &GitHubFormatter{}, "changed from 1 to 1500m (+500m)"},
+		{"gitlab", &GitLabFormatter{}, "changed from 1 to 1500m (+500m)"},
+		{"json", &JSONFormatter{}, `"delta": "+500m"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.Format([]Difference{diff}, noColor); !strings.Contains(got, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -14,17 +14,35 @@ const (
 	MaxResponseSize = 10 * 1024 * 1024
 	// DefaultTimeout is the HTTP client timeout for remote fetches.
 	DefaultTimeout = 30 * time.Second
+	// StdinSource is the file argument that reads content from standard input.
+	StdinSource = "-"
+	// StdinLabel is the file name reported for content read from standard
+	// input, in annotations, report sections and positions.
+	StdinLabel = "<stdin>"
 )
 
+// stdinReader is an injectable reader for os.Stdin, enabling LoadContent
+// stdin tests without swapping the process's real standard input.
+var stdinReader io.Reader = os.Stdin
+
 // IsRemoteSource returns true if the source string is an HTTP/HTTPS URL.
 // Uses strict lowercase prefix matching.
 func IsRemoteSource(source string) bool {
 	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
 }
 
+// IsStdinSource returns true if the source string names standard input ("-").
+func IsStdinSource(source string) bool {
+	return source == StdinSource
+}
+
 // ValidateFileExists checks if a file exists and is not a directory.
-// Returns an error with the file path if validation fails.
+// Returns an error with the file path if validation fails. The stdin source
+// "-" always validates: there is nothing to stat until it is read.
 func ValidateFileExists(path string) error {
+	if IsStdinSource(path) {
+		return nil
+	}
 	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -38,12 +56,16 @@ func ValidateFileExists(path string) error {
 	return nil
 }
 
-// LoadContent loads content from a source, which can be a local file path
-// or an HTTP/HTTPS URL. Returns the content bytes or an error.
+// LoadContent loads content from a source, which can be a local file path,
+// an HTTP/HTTPS URL, or "-" for standard input. Returns the content bytes or
+// an error.
 func LoadContent(source string) ([]byte, error) {
 	if IsRemoteSource(source) {
 		return fetchURL(source)
 	}
+	if IsStdinSource(source) {
+		return ReadStdin(stdinReader)
+	}
 
 	if err := ValidateFileExists(source); err != nil {
 		return nil, err
@@ -55,6 +77,18 @@ func LoadContent(source string) ([]byte, error) {
 	return data, nil
 }
 
+// ReadStdin reads the whole of r as the content of the stdin source. Standard
+// input can only be consumed once, so callers reading both sides of a
+// comparison must route at most one of them here. A multi-document stream is
+// returned intact; the parser splits it like any other file.
+func ReadStdin(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return data, nil
+}
+
 // fetchURL fetches content from an HTTP/HTTPS URL with timeout and size limits.
 func fetchURL(url string) ([]byte, error) {
 	client := &http.Client{Timeout: DefaultTimeout}
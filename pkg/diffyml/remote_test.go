@@ -305,3 +305,50 @@ func TestFetchURL_HTTP300Rejected(t *testing.T) {
 func writeTestFile(path string, content []byte) error {
 	return os.WriteFile(path, content, 0o600)
 }
+
+func TestIsStdinSource(t *testing.T) {
+	if !IsStdinSource("-") {
+		t.Error(`IsStdinSource("-") = false, want true`)
+	}
+	for _, s := range []string{"", "--", "-.yaml", "/dev/stdin"} {
+		if IsStdinSource(s) {
+			t.Errorf("IsStdinSource(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestValidateFileExists_Stdin(t *testing.T) {
+	if err := ValidateFileExists("-"); err != nil {
+		t.Errorf(`ValidateFileExists("-") returned error: %v`, err)
+	}
+}
+
+func TestLoadContent_Stdin(t *testing.T) {
+	orig := stdinReader
+	defer func() { stdinReader = orig }()
+	stream := "a: 1\n---\nb: 2\n"
+	stdinReader = strings.NewReader(stream)
+
+	got, err := LoadContent("-")
+	if err != nil {
+		t.Fatalf(`LoadContent("-") returned error: %v`, err)
+	}
+	if string(got) != stream {
+		t.Errorf(`LoadContent("-") = %q, want %q`, got, stream)
+	}
+}
+
+// errReader fails every read, standing in for a broken stdin pipe.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, fmt.Errorf("broken pipe") }
+
+func TestReadStdin_Error(t *testing.T) {
+	_, err := ReadStdin(errReader{})
+	if err == nil {
+		t.Fatal("ReadStdin should return error when the reader fails")
+	}
+	if !strings.Contains(err.Error(), "failed to read stdin") || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
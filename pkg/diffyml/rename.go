@@ -129,6 +129,17 @@ func buildRenamePairs(from, to []any, k8sFrom, k8sTo []int) []renamePair {
 	return pairs
 }
 
+// sortRenamePairs orders rename pairs by descending score, then by index.
+func sortRenamePairs(pairs []renamePair) {
+	slices.SortStableFunc(pairs, func(a, b renamePair) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(a.fromIdx, b.fromIdx),
+			cmp.Compare(a.toIdx, b.toIdx),
+		)
+	})
+}
+
 // greedyAssignRenames performs greedy assignment of rename pairs by descending score.
 func greedyAssignRenames(pairs []renamePair) (matched map[int]int, assignedFrom, assignedTo map[int]bool) {
 	matched = make(map[int]int)
@@ -166,14 +177,7 @@ func detectRenames(from, to []any, unmatchedFrom, unmatchedTo []int, opts *Optio
 	}
 
 	pairs := buildRenamePairs(from, to, k8sFrom, k8sTo)
-
-	slices.SortStableFunc(pairs, func(a, b renamePair) int {
-		return cmp.Or(
-			cmp.Compare(b.score, a.score),
-			cmp.Compare(a.fromIdx, b.fromIdx),
-			cmp.Compare(a.toIdx, b.toIdx),
-		)
-	})
+	sortRenamePairs(pairs)
 
 	renameMatched, assignedFrom, assignedTo := greedyAssignRenames(pairs)
 
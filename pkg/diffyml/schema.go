@@ -0,0 +1,275 @@
+// schema.go - List types from CRD and OpenAPI v3 schemas (Options.Schema).
+//
+// Custom resources declare how their lists merge in their structural schema:
+// x-kubernetes-list-type "map" with x-kubernetes-list-map-keys identifies
+// items by those keys, "set" makes a list of scalars order-independent, and
+// "atomic" makes the list a single value that is replaced as a whole. A
+// Schema collects these declarations from CustomResourceDefinition manifests
+// and OpenAPI v3 documents, per apiVersion and kind, for the comparator to
+// consult when a document is matched as that resource.
+// Key types: Schema.
+// Key functions: ParseSchema, (*Schema).lookup.
+package diffyml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// List types a schema can declare.
+const (
+	listTypeMap    = "map"
+	listTypeSet    = "set"
+	listTypeAtomic = "atomic"
+)
+
+// Schema holds the list types declared by CRD and OpenAPI v3 schemas. The
+// zero value declares nothing.
+type Schema struct {
+	rules map[string][]schemaRule // by kind
+}
+
+// schemaRule is the list type of the lists at one path of a resource.
+type schemaRule struct {
+	apiVersion string
+	path       []string // "*" stands for any list item or map key
+	listType   string
+	keys       []string
+}
+
+// schemaMaxDepth bounds the schema walk, so recursive $refs (as in
+// JSONSchemaProps) terminate.
+const schemaMaxDepth = 32
+
+// ParseSchema reads the list types declared in one or more YAML or JSON
+// sources: CustomResourceDefinition manifests (also inside a List, as kubectl
+// get -o yaml writes them) and OpenAPI v3 documents whose component schemas
+// carry x-kubernetes-group-version-kind. Swagger 2.0 definitions are read
+// the same way. Sources holding neither are ignored.
+func ParseSchema(sources ...[]byte) (*Schema, error) {
+	s := &Schema{rules: make(map[string][]schemaRule)}
+	for _, src := range sources {
+		dec := yaml.NewDecoder(bytes.NewReader(src))
+		for {
+			var doc any
+			if err := dec.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("parsing schema: %w", err)
+			}
+			s.addDocument(doc)
+		}
+	}
+	return s, nil
+}
+
+// addDocument collects the list types of one schema document.
+func (s *Schema) addDocument(doc any) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return
+	}
+	switch {
+	case m["kind"] == "CustomResourceDefinition":
+		s.addCRD(m)
+	case m["kind"] == "List" || m["kind"] == "CustomResourceDefinitionList":
+		items, _ := m["items"].([]any)
+		for _, item := range items {
+			s.addDocument(item)
+		}
+	default:
+		components, _ := m["components"].(map[string]any)
+		schemas, _ := components["schemas"].(map[string]any)
+		if definitions, ok := m["definitions"].(map[string]any); ok && schemas == nil {
+			schemas = definitions
+		}
+		for _, def := range schemas {
+			def, _ := def.(map[string]any)
+			gvks, _ := def["x-kubernetes-group-version-kind"].([]any)
+			for _, gvk := range gvks {
+				gvk, _ := gvk.(map[string]any)
+				kind, _ := gvk["kind"].(string)
+				group, _ := gvk["group"].(string)
+				version, _ := gvk["version"].(string)
+				if kind == "" || version == "" {
+					continue
+				}
+				w := schemaWalker{schema: s, root: m, apiVersion: groupVersion(group, version), kind: kind}
+				w.walk(def, nil, 0)
+			}
+		}
+	}
+}
+
+// addCRD collects the list types of every served version of a
+// CustomResourceDefinition, from apiextensions.k8s.io/v1 per-version schemas
+// or the v1beta1 top-level validation schema.
+func (s *Schema) addCRD(crd map[string]any) {
+	spec, _ := crd["spec"].(map[string]any)
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]any)
+	kind, _ := names["kind"].(string)
+	if kind == "" {
+		return
+	}
+	shared := openAPIV3Schema(spec["validation"])
+	versions, _ := spec["versions"].([]any)
+	if len(versions) == 0 {
+		if v, ok := spec["version"].(string); ok {
+			versions = []any{map[string]any{"name": v}}
+		}
+	}
+	for _, v := range versions {
+		v, _ := v.(map[string]any)
+		name, _ := v["name"].(string)
+		schema := openAPIV3Schema(v["schema"])
+		if schema == nil {
+			schema = shared
+		}
+		if name == "" || schema == nil {
+			continue
+		}
+		w := schemaWalker{schema: s, root: crd, apiVersion: groupVersion(group, name), kind: kind}
+		w.walk(schema, nil, 0)
+	}
+}
+
+// openAPIV3Schema returns the openAPIV3Schema of a CRD validation block.
+func openAPIV3Schema(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	schema, _ := m["openAPIV3Schema"].(map[string]any)
+	return schema
+}
+
+// groupVersion joins an API group and version into an apiVersion; the core
+// group is empty.
+func groupVersion(group, version string) string {
+	if group == "" {
+		return version
+	}
+	return group + "/" + version
+}
+
+// schemaWalker records the list types of one resource's schema.
+type schemaWalker struct {
+	schema     *Schema
+	root       map[string]any // document $refs resolve against
+	apiVersion string
+	kind       string
+}
+
+// walk records the list type declared at node, then descends into its
+// properties, items and additionalProperties.
+func (w schemaWalker) walk(node map[string]any, path []string, depth int) {
+	if node == nil || depth > schemaMaxDepth {
+		return
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		w.walk(w.resolve(ref), path, depth+1)
+	}
+	for _, combinator := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, _ := node[combinator].([]any)
+		for _, sub := range subs {
+			sub, _ := sub.(map[string]any)
+			w.walk(sub, path, depth+1)
+		}
+	}
+	w.record(node, path)
+	if props, ok := node["properties"].(map[string]any); ok {
+		for key, sub := range props {
+			sub, _ := sub.(map[string]any)
+			w.walk(sub, append(path[:len(path):len(path)], key), depth+1)
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		w.walk(items, append(path[:len(path):len(path)], "*"), depth+1)
+	}
+	if extra, ok := node["additionalProperties"].(map[string]any); ok {
+		w.walk(extra, append(path[:len(path):len(path)], "*"), depth+1)
+	}
+}
+
+// record adds the list type node declares, if any. A list with a
+// strategic-merge patch key but no list type is matched by that key.
+func (w schemaWalker) record(node map[string]any, path []string) {
+	listType, _ := node["x-kubernetes-list-type"].(string)
+	var keys []string
+	switch listType {
+	case listTypeMap:
+		raw, _ := node["x-kubernetes-list-map-keys"].([]any)
+		for _, k := range raw {
+			if k, ok := k.(string); ok {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			return
+		}
+	case listTypeSet, listTypeAtomic:
+	case "":
+		key, _ := node["x-kubernetes-patch-merge-key"].(string)
+		if key == "" {
+			return
+		}
+		listType, keys = listTypeMap, []string{key}
+	default:
+		return
+	}
+	w.schema.rules[w.kind] = append(w.schema.rules[w.kind], schemaRule{
+		apiVersion: w.apiVersion,
+		path:       path,
+		listType:   listType,
+		keys:       keys,
+	})
+}
+
+// resolve follows a local JSON pointer $ref ("#/components/schemas/Foo").
+func (w schemaWalker) resolve(ref string) map[string]any {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var cur any = w.root
+	for _, seg := range strings.Split(pointer, "/") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")]
+	}
+	node, _ := cur.(map[string]any)
+	return node
+}
+
+// lookup returns the rule for the list at path in a resource of the given
+// apiVersion and kind. An empty apiVersion matches any version of the kind.
+func (s *Schema) lookup(apiVersion, kind string, path DiffPath) (schemaRule, bool) {
+	if s == nil {
+		return schemaRule{}, false
+	}
+	if _, rest, ok := path.DocIndexPrefix(); ok {
+		path = rest
+	}
+	for _, r := range s.rules[kind] {
+		if apiVersion != "" && r.apiVersion != apiVersion || len(r.path) != len(path) {
+			continue
+		}
+		match := true
+		for i, seg := range r.path {
+			if seg != "*" && seg != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return r, true
+		}
+	}
+	return schemaRule{}, false
+}
@@ -0,0 +1,165 @@
+package diffyml
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gateways.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gateway
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+                listeners:
+                  type: array
+                  x-kubernetes-list-type: map
+                  x-kubernetes-list-map-keys: [port, protocol]
+                  items:
+                    type: object
+                    properties:
+                      hostnames:
+                        type: array
+                        x-kubernetes-list-type: set
+                        items: {type: string}
+                addresses:
+                  type: array
+                  x-kubernetes-list-type: atomic
+                  items:
+                    type: object
+                routes:
+                  type: object
+                  additionalProperties:
+                    type: array
+                    x-kubernetes-patch-merge-key: path
+                    items: {type: object}
+`
+
+const testOpenAPI = `openapi: 3.0.0
+components:
+  schemas:
+    io.example.v1.Route:
+      type: object
+      x-kubernetes-group-version-kind:
+        - {group: example.com, version: v1, kind: Route}
+      properties:
+        spec:
+          $ref: '#/components/schemas/io.example.v1.RouteSpec'
+    io.example.v1.RouteSpec:
+      type: object
+      properties:
+        rules:
+          type: array
+          x-kubernetes-list-type: map
+          x-kubernetes-list-map-keys: [name]
+          items:
+            $ref: '#/components/schemas/io.example.v1.RouteSpec'
+`
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema([]byte(testCRD), []byte(testOpenAPI))
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	tests := []struct {
+		apiVersion string
+		kind       string
+		path       DiffPath
+		listType   string
+		keys       []string
+	}{
+		{"example.com/v1", "Gateway", DiffPath{"spec", "listeners"}, listTypeMap, []string{"port", "protocol"}},
+		{"example.com/v1", "Gateway", DiffPath{"[1]", "spec", "listeners", "80,TCP", "hostnames"}, listTypeSet, nil},
+		{"", "Gateway", DiffPath{"spec", "addresses"}, listTypeAtomic, nil},
+		{"example.com/v1", "Gateway", DiffPath{"spec", "routes", "web"}, listTypeMap, []string{"path"}},
+		{"example.com/v1", "Route", DiffPath{"spec", "rules"}, listTypeMap, []string{"name"}},
+		{"example.com/v1", "Route", DiffPath{"spec", "rules", "a", "rules"}, listTypeMap, []string{"name"}},
+		{"example.com/v2", "Gateway", DiffPath{"spec", "listeners"}, "", nil},
+		{"example.com/v1", "Gateway", DiffPath{"listeners"}, "", nil},
+		{"example.com/v1", "Service", DiffPath{"spec", "listeners"}, "", nil},
+	}
+	for _, tt := range tests {
+		r, _ := s.lookup(tt.apiVersion, tt.kind, tt.path)
+		if r.listType != tt.listType || !reflect.DeepEqual(r.keys, tt.keys) {
+			t.Errorf("lookup(%s %s, %v) = %q %v, want %q %v", tt.apiVersion, tt.kind, tt.path, r.listType, r.keys, tt.listType, tt.keys)
+		}
+	}
+
+	if _, err := ParseSchema([]byte("a: [")); err == nil {
+		t.Error("ParseSchema of invalid YAML succeeded, want error")
+	}
+	var nilSchema *Schema
+	if _, ok := nilSchema.lookup("v1", "Gateway", DiffPath{"spec"}); ok {
+		t.Error("nil schema declared a list type")
+	}
+}
+
+func TestCompare_SchemaListTypes(t *testing.T) {
+	from := `apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  listeners:
+    - {port: 80, protocol: TCP, hostnames: [a.example.com, b.example.com]}
+    - {port: 80, protocol: UDP, hostnames: []}
+  addresses:
+    - {type: IPAddress, value: 10.0.0.1}
+    - {type: IPAddress, value: 10.0.0.2}
+`
+	to := `apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  listeners:
+    - {port: 80, protocol: UDP, hostnames: []}
+    - {port: 80, protocol: TCP, hostnames: [b.example.com, a.example.com]}
+  addresses:
+    - {type: IPAddress, value: 10.0.0.1}
+    - {type: IPAddress, value: 10.0.0.3}
+`
+	schema, err := ParseSchema([]byte(testCRD))
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	diffs, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, Schema: schema})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path.String())
+	}
+	want := []string{"spec.listeners", "spec.addresses"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if diffs[0].Type != DiffOrderChanged {
+		t.Errorf("listeners: got type %v, want DiffOrderChanged", diffs[0].Type)
+	}
+	if diffs[1].Type != DiffModified || len(diffs[1].From.([]any)) != 2 || len(diffs[1].To.([]any)) != 2 {
+		t.Errorf("addresses: got %+v, want the whole list modified", diffs[1])
+	}
+
+	unchanged, err := Compare([]byte(from), []byte(to), &Options{DetectKubernetes: true, Schema: schema, Unchanged: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	for _, d := range unchanged {
+		if d.Path.String() == "spec.addresses.0" {
+			t.Errorf("inverse diff reported an item of an atomic list: %v", d.Path)
+		}
+	}
+}
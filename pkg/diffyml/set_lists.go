@@ -0,0 +1,120 @@
+// set_lists.go - Set semantics for lists of scalars (Options.SetLists).
+//
+// RBAC verbs, container args and ingress hosts are lists of scalars whose
+// order rarely matters. Compared in order, a moved element is reported
+// removed at one index and added at another. Under set semantics such a list is a
+// multiset: elements present on one side only are reported as DiffAdded or
+// DiffRemoved at the list's path, one difference per surplus occurrence, and
+// order is never reported. Lists holding anything but scalars are compared as
+// usual.
+// Key functions: isScalarSet, compareScalarSetNodes, collectUnchangedScalarSet.
+package diffyml
+
+import (
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// isScalarSet reports whether the lists at path compare as sets: both hold
+// only scalars, and SetLists is on, a SetListPaths pattern matches path, or
+// the schema declares the list a set.
+func isScalarSet(path DiffPath, ids listIdentity, fromN, toN *yaml.Node, opts *Options) bool {
+	if !opts.SetLists && ids.listType != listTypeSet && !matchesAnyListPath(opts.SetListPaths, path) {
+		return false
+	}
+	return scalarItems(fromN.Content) && scalarItems(toN.Content)
+}
+
+// matchesAnyListPath reports whether path matches one of the ListKey-style
+// patterns.
+func matchesAnyListPath(patterns []string, path DiffPath) bool {
+	for _, p := range patterns {
+		if matchesListPath(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarItems reports whether every item is a scalar, null included.
+func scalarItems(items []*yaml.Node) bool {
+	for _, item := range items {
+		if item = resolveAlias(item); item == nil || item.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// matchScalarSet pairs equal elements of two scalar lists, each element at
+// most once. match[i] is the index in to of from[i]'s partner, or -1.
+func matchScalarSet(from, to []*yaml.Node, opts *Options) (match []int, toMatched []bool) {
+	match = make([]int, len(from))
+	toMatched = make([]bool, len(to))
+	for i := range from {
+		match[i] = -1
+		for j := range to {
+			if !toMatched[j] && deepEqualNodes(from[i], to[j], opts) {
+				match[i] = j
+				toMatched[j] = true
+				break
+			}
+		}
+	}
+	return match, toMatched
+}
+
+// compareScalarSetNodes compares two lists of scalars as multisets.
+func compareScalarSetNodes(path DiffPath, fromN, toN *yaml.Node, opts *Options) []Difference {
+	match, toMatched := matchScalarSet(fromN.Content, toN.Content, opts)
+	var diffs []Difference
+	for i, item := range fromN.Content {
+		if j := match[i]; j >= 0 {
+			diffs = append(diffs, compareEqualComments(path.Append(strconv.Itoa(i)), item, toN.Content[j], opts)...)
+			continue
+		}
+		diffs = append(diffs, Difference{
+			Path:        path,
+			Type:        DiffRemoved,
+			From:        nodeToInterface(item),
+			FromPos:     nodePosition(item),
+			ToPos:       containerPosition(toN),
+			listEntry:   true,
+			pointerItem: strconv.Itoa(i),
+		})
+	}
+	for j, item := range toN.Content {
+		if toMatched[j] {
+			continue
+		}
+		diffs = append(diffs, Difference{
+			Path:        path,
+			Type:        DiffAdded,
+			To:          nodeToInterface(item),
+			FromPos:     containerPosition(fromN),
+			ToPos:       nodePosition(item),
+			listEntry:   true,
+			pointerItem: "-",
+		})
+	}
+	return diffs
+}
+
+// collectUnchangedScalarSet is the inverse-mode counterpart of
+// compareScalarSetNodes: equal multisets collapse to one entry for the whole
+// list, otherwise each element present on both sides is reported at the
+// list's path.
+func collectUnchangedScalarSet(path DiffPath, fromN, toN *yaml.Node, inList bool, opts *Options) []Difference {
+	match, _ := matchScalarSet(fromN.Content, toN.Content, opts)
+	var diffs []Difference
+	for i, item := range fromN.Content {
+		if j := match[i]; j >= 0 {
+			diffs = append(diffs, unchangedEntry(path, item, toN.Content[j], true))
+		}
+	}
+	if len(diffs) == len(fromN.Content) && len(diffs) == len(toN.Content) {
+		return []Difference{unchangedEntry(path, fromN, toN, inList)}
+	}
+	return diffs
+}
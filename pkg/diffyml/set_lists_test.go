@@ -0,0 +1,157 @@
+package diffyml
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type setDiff struct {
+	typ   DiffType
+	path  string
+	value any
+}
+
+// setDiffs reduces differences to their type, path and value.
+func setDiffs(diffs []Difference) []setDiff {
+	var out []setDiff
+	for _, d := range diffs {
+		v := d.To
+		if v == nil {
+			v = d.From
+		}
+		out = append(out, setDiff{d.Type, d.Path.String(), v})
+	}
+	return out
+}
+
+func TestCompare_SetLists(t *testing.T) {
+	from := "rules:\n  - verbs: [get, list, watch, get]\n    resources: [pods]\nargs: [--a, --b]\n"
+	to := "rules:\n  - verbs: [list, get, create]\n    resources: [pods]\nargs: [--b, --a]\n"
+
+	tests := []struct {
+		name string
+		opts *Options
+		want []setDiff
+	}{
+		{
+			name: "global",
+			opts: &Options{SetLists: true},
+			want: []setDiff{
+				{DiffRemoved, "rules.0.verbs", "watch"},
+				{DiffRemoved, "rules.0.verbs", "get"},
+				{DiffAdded, "rules.0.verbs", "create"},
+			},
+		},
+		{
+			name: "per path",
+			opts: &Options{SetListPaths: []string{"rules.*.verbs"}},
+			want: []setDiff{
+				{DiffRemoved, "rules.0.verbs", "watch"},
+				{DiffRemoved, "rules.0.verbs", "get"},
+				{DiffAdded, "rules.0.verbs", "create"},
+				{DiffRemoved, "args.0", "--a"},
+				{DiffAdded, "args.1", "--a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare([]byte(from), []byte(to), tt.opts)
+			if err != nil {
+				t.Fatalf("Compare failed: %v", err)
+			}
+			if got := setDiffs(diffs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, d := range diffs {
+				if d.Type != DiffModified && !isListEntryDiff(d) {
+					t.Errorf("%v at %s is not rendered as a list entry", d.Type, d.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestCompare_SetListsSkipsNonScalarLists(t *testing.T) {
+	from := "items:\n  - {a: 1}\n  - b\n"
+	to := "items:\n  - {a: 2}\n  - b\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{SetLists: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Path.String() != "items.0.a" {
+		t.Errorf("expected a nested modification, got %v", setDiffs(diffs))
+	}
+}
+
+func TestCompare_SetListsUnchanged(t *testing.T) {
+	from := "verbs: [get, list, watch]\nhosts: [a, b]\n"
+	to := "verbs: [list, get, create]\nhosts: [b, a]\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{SetLists: true, Unchanged: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path.String())
+	}
+	want := []string{"verbs", "verbs", "hosts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unchanged paths = %v, want %v", got, want)
+	}
+	if v, ok := diffs[2].To.([]any); !ok || len(v) != 2 {
+		t.Errorf("reordered set not collapsed to the whole list: %#v", diffs[2].To)
+	}
+	if !isListEntryDiff(diffs[0]) || isListEntryDiff(diffs[2]) {
+		t.Errorf("set elements should render as list entries, the collapsed list as a map entry")
+	}
+}
+
+func TestCompare_SchemaSetOfScalars(t *testing.T) {
+	schema, err := ParseSchema([]byte(testCRD))
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	doc := "apiVersion: example.com/v1\nkind: Gateway\nmetadata:\n  name: gw\nspec:\n  listeners:\n    - {port: 80, protocol: TCP, hostnames: [%s]}\n"
+	from := []byte(fmt.Sprintf(doc, "a.example.com, b.example.com"))
+	to := []byte(fmt.Sprintf(doc, "b.example.com, c.example.com"))
+	diffs, err := Compare(from, to, &Options{DetectKubernetes: true, Schema: schema})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	want := []setDiff{
+		{DiffRemoved, "spec.listeners.80,TCP.hostnames", "a.example.com"},
+		{DiffAdded, "spec.listeners.80,TCP.hostnames", "c.example.com"},
+	}
+	if got := setDiffs(diffs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestJSONPatchFormatter_SetListsRoundTrip(t *testing.T) {
+	from := "rules:\n  - verbs: [get, list, watch, get]\n    resources: [pods]\n"
+	to := "rules:\n  - verbs: [list, get, create]\n    resources: [pods]\n"
+	diffs, err := Compare([]byte(from), []byte(to), &Options{SetLists: true})
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	patch := (&JSONPatchFormatter{}).Format(diffs, &FormatOptions{})
+	var ops []map[string]any
+	if err := json.Unmarshal([]byte(patch), &ops); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+	var got []string
+	for _, op := range ops {
+		got = append(got, fmt.Sprint(op["op"], " ", op["path"]))
+	}
+	want := []string{"remove /rules/0/verbs/3", "remove /rules/0/verbs/2", "add /rules/0/verbs/-"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %q, want %q", got, want)
+	}
+	applied := applyPatch(t, from, patch)
+	if want := "rules:\n  - verbs: [get, list, create]\n    resources: [pods]\n"; applied != want {
+		t.Errorf("applied patch =\n%s\nwant\n%s", applied, want)
+	}
+}
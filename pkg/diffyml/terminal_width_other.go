@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+// terminal_width_other.go - Terminal width fallback for other platforms.
+//
+// No terminal query is attempted; width comes from COLUMNS or --width.
+package diffyml
+
+// terminalWidth reports an unknown width.
+func terminalWidth() int {
+	return 0
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin
+
+// terminal_width_unix.go - Terminal width query for Linux and macOS.
+//
+// Asks the terminal driver for the window size of stdout with the
+// TIOCGWINSZ ioctl. Other platforms use terminal_width_other.go.
+package diffyml
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize mirrors the kernel's struct winsize.
+type winsize struct {
+	Rows, Cols, XPixel, YPixel uint16
+}
+
+// terminalWidth returns the column count of the terminal on stdout, or 0 when
+// stdout is not a terminal.
+func terminalWidth() int {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Cols)
+}
@@ -20,25 +20,29 @@ func mappingValuePathAliases(bases []DiffPath, key string) []DiffPath {
 // sequenceValuePathAliases appends both the numeric index and, when available,
 // the same identifier segment used by the comparator. Supporting both forms
 // preserves existing numeric filtering while allowing paths such as
-// containers.app.image for collapsed named lists.
-func sequenceValuePathAliases(bases []DiffPath, item any, index int, additionalIdentifiers []string) []DiffPath {
+// containers.app.image for collapsed named lists. ids carries the
+// identifier settings (AdditionalIdentifiers and ListKeys); the identifier is
+// derived per base, since a ListKey applies by path.
+func sequenceValuePathAliases(bases []DiffPath, item any, index int, ids *Options) []DiffPath {
 	indexSegment := strconv.Itoa(index)
-	segments := []string{indexSegment}
-	if id := valueIdentifier(item, additionalIdentifiers); isComparableIdentifier(id) {
-		if identifierSegment := sprintIdentifier(id); identifierSegment != indexSegment {
-			segments = append(segments, identifierSegment)
-		}
-	}
-
 	var paths []DiffPath
 	for _, base := range bases {
-		for _, segment := range segments {
-			paths = append(paths, base.Append(segment))
+		paths = append(paths, base.Append(indexSegment))
+		if id := identityAt(base, ids).ofValue(item); isComparableIdentifier(id) {
+			if identifierSegment := sprintIdentifier(id); identifierSegment != indexSegment {
+				paths = append(paths, base.Append(identifierSegment))
+			}
 		}
 	}
 	return paths
 }
 
+// identifierOptions returns the Options subset that identifies list items,
+// for the filter and mask passes that take their own option types.
+func identifierOptions(additionalIdentifiers []string, listKeys []ListKey, schema *Schema) *Options {
+	return &Options{AdditionalIdentifiers: additionalIdentifiers, ListKeys: listKeys, Schema: schema}
+}
+
 func valueIdentifier(value any, additionalIdentifiers []string) any {
 	switch val := value.(type) {
 	case *OrderedMap: